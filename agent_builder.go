@@ -156,6 +156,10 @@ func (b *AgentBuilder) Build() (*AgentRuntimeConfig, error) {
 		Guardrails:   b.guardrails,
 		Tracer:       b.tracer,
 	}
+	config.Card.Normalize()
+	if err := config.Card.Validate(); err != nil {
+		return nil, err
+	}
 
 	return config, nil
 }
@@ -295,3 +295,53 @@ func TestBuilder_MultipleTools(t *testing.T) {
 		t.Errorf("expected 4 tags, got %d: %v", len(tags), tags)
 	}
 }
+
+func TestBuilder_ValidationError_InvalidVisibility(t *testing.T) {
+	_, err := NewAgentBuilder("test", "Test").Visibility("publik").Build()
+	if err == nil {
+		t.Fatal("expected validation error for invalid visibility")
+	}
+}
+
+func TestBuilder_ValidationError_InvalidSafetyLevel(t *testing.T) {
+	_, err := NewAgentBuilder("test", "Test").SafetyLevel("extreme").Build()
+	if err == nil {
+		t.Fatal("expected validation error for invalid safety level")
+	}
+}
+
+func TestBuilder_NormalizesCardEnums(t *testing.T) {
+	cfg, err := NewAgentBuilder("test", "Test").
+		Visibility("  Public ").
+		SafetyLevel("HIGH").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Card.Visibility != "public" || cfg.Card.SafetyLevel != "high" {
+		t.Fatalf("expected normalized enums, got visibility=%q safety=%q", cfg.Card.Visibility, cfg.Card.SafetyLevel)
+	}
+}
+
+func TestAgentCardPublic_Validate(t *testing.T) {
+	valid := []AgentCardPublic{
+		{},
+		{Visibility: "org", SafetyLevel: "medium"},
+		{Visibility: "private", SafetyLevel: "low"},
+	}
+	for _, c := range valid {
+		if err := c.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", c, err)
+		}
+	}
+	invalid := []AgentCardPublic{
+		{Visibility: "Public"},
+		{Visibility: "everyone"},
+		{SafetyLevel: " low"},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", c)
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package agentsdk
 
+import (
+	"fmt"
+	"strings"
+)
+
 // AgentCardPublic is the serializable Agent metadata (can register to Zapry platform).
 type AgentCardPublic struct {
 	AgentID             string             `json:"agent_id"`
@@ -27,6 +32,28 @@ func (c AgentCardPublic) EffectiveSkillTags() []string {
 	return c.Skills
 }
 
+// Normalize trims and lowercases the enum-like fields (Visibility, SafetyLevel).
+func (c *AgentCardPublic) Normalize() {
+	c.Visibility = strings.ToLower(strings.TrimSpace(c.Visibility))
+	c.SafetyLevel = strings.ToLower(strings.TrimSpace(c.SafetyLevel))
+}
+
+// Validate checks the enum-like fields; empty values are allowed.
+// Call Normalize first to accept values differing only in case or whitespace.
+func (c AgentCardPublic) Validate() error {
+	switch c.Visibility {
+	case "", "public", "private", "org":
+	default:
+		return fmt.Errorf("agent card %q: invalid visibility %q (want public | private | org)", c.AgentID, c.Visibility)
+	}
+	switch c.SafetyLevel {
+	case "", "low", "medium", "high":
+	default:
+		return fmt.Errorf("agent card %q: invalid safety_level %q (want low | medium | high)", c.AgentID, c.SafetyLevel)
+	}
+	return nil
+}
+
 // AgentRuntimeConfig is the local runtime binding (not serializable).
 type AgentRuntimeConfig struct {
 	Card         AgentCardPublic
@@ -100,29 +100,80 @@ func defaultRetryableLLMError(err error) bool {
 
 // ToolCallRecord records a single tool invocation.
 type ToolCallRecord struct {
-	ToolName  string                 `json:"tool_name"`
-	Arguments map[string]interface{} `json:"arguments"`
-	Result    string                 `json:"result"`
-	Error     string                 `json:"error,omitempty"`
-	CallID    string                 `json:"call_id"`
+	ToolName       string                 `json:"tool_name"`
+	Arguments      map[string]interface{} `json:"arguments"`
+	Result         string                 `json:"result"`
+	Error          string                 `json:"error,omitempty"`
+	CallID         string                 `json:"call_id"`
+	OriginalLength int                    `json:"original_length,omitempty"` // result length in chars before MaxToolResultChars truncation
+	Cached         bool                   `json:"cached,omitempty"`          // result reused via DedupeToolCalls, not executed
 }
 
 // TurnRecord records a single LLM turn.
 type TurnRecord struct {
-	TurnNumber int              `json:"turn_number"`
-	LLMOutput  string           `json:"llm_output,omitempty"`
-	ToolCalls  []ToolCallRecord `json:"tool_calls,omitempty"`
-	IsFinal    bool             `json:"is_final"`
+	TurnNumber   int              `json:"turn_number"`
+	LLMOutput    string           `json:"llm_output,omitempty"`
+	ToolCalls    []ToolCallRecord `json:"tool_calls,omitempty"`
+	IsFinal      bool             `json:"is_final"`
+	StartedAt    time.Time        `json:"started_at"`
+	EndedAt      time.Time        `json:"ended_at"`
+	LLMDuration  time.Duration    `json:"llm_duration_ns"`            // LLM call incl. retries
+	ToolDuration time.Duration    `json:"tool_duration_ns,omitempty"` // all tool calls of the turn
 }
 
 // AgentLoopResult is the final result of an AgentLoop run.
 type AgentLoopResult struct {
-	FinalOutput    string                   `json:"final_output"`
-	Turns          []TurnRecord             `json:"turns"`
-	ToolCallsCount int                      `json:"tool_calls_count"`
-	TotalTurns     int                      `json:"total_turns"`
-	StoppedReason  string                   `json:"stopped_reason"` // "completed", "max_turns", "error"
-	Messages       []map[string]interface{} `json:"messages"`
+	FinalOutput     string                   `json:"final_output"`
+	Turns           []TurnRecord             `json:"turns"`
+	ToolCallsCount  int                      `json:"tool_calls_count"`
+	TotalTurns      int                      `json:"total_turns"`
+	StoppedReason   string                   `json:"stopped_reason"` // "completed", "max_turns", "error", "guardrail", "rewrite_error", ...
+	Messages        []map[string]interface{} `json:"messages"`
+	LoopInfo        *LoopWarning             `json:"loop_info,omitempty"`        // last loop pattern detected, if any
+	EstimatedTokens int                      `json:"estimated_tokens,omitempty"` // set when AgentLoop.TokenEstimator is configured
+	Narrations      []string                 `json:"narrations,omitempty"`       // assistant text emitted alongside tool calls
+	TotalDuration   time.Duration            `json:"total_duration_ns"`          // wall-clock time of the whole run
+	GuardrailBlock  *GuardrailBlockInfo      `json:"guardrail_block,omitempty"`  // set when StoppedReason is "guardrail"
+	LoopStats       *LoopStats               `json:"loop_stats,omitempty"`       // this run's LoopDetector window, when one is configured
+}
+
+// ToTranscript returns Messages as a clean OpenAI chat transcript, ending with
+// the final assistant answer when the run completed. Each message keeps only
+// the standard keys (role, content, name, tool_calls, tool_call_id); an
+// assistant turn that only calls tools gets a null content. With
+// dropInjected, system messages the SDK inserted mid-run (e.g. loop
+// warnings) are omitted; the leading system prompt is always kept.
+func (r *AgentLoopResult) ToTranscript(dropInjected bool) []map[string]interface{} {
+	transcript := make([]map[string]interface{}, 0, len(r.Messages)+1)
+	leading := true
+	for _, m := range r.Messages {
+		role, _ := m["role"].(string)
+		if role != "system" {
+			leading = false
+		} else if dropInjected && !leading {
+			continue
+		}
+		out := map[string]interface{}{"role": role, "content": m["content"]}
+		if name, ok := m["name"]; ok {
+			out["name"] = name
+		}
+		switch role {
+		case "assistant":
+			if calls, ok := m["tool_calls"]; ok {
+				out["tool_calls"] = calls
+				if c, _ := m["content"].(string); c == "" {
+					out["content"] = nil
+				}
+			}
+		case "tool":
+			out["tool_call_id"] = m["tool_call_id"]
+		}
+		transcript = append(transcript, out)
+	}
+	if r.StoppedReason == "completed" {
+		transcript = append(transcript, map[string]interface{}{"role": "assistant", "content": r.FinalOutput})
+	}
+	return transcript
 }
 
 // AgentLoopHooks provides optional event callbacks.
@@ -133,6 +184,18 @@ type AgentLoopHooks struct {
 	OnToolEnd   func(name string, result string, err string)
 	OnTurnEnd   func(turn *TurnRecord)
 	OnError     func(err error)
+	// OnAssistantNarration fires when a tool-call turn also carries text
+	// (e.g. "Let me check the weather…").
+	OnAssistantNarration func(turn int, text string)
+	// OnToolDelta fires for each chunk a streaming tool emits, before
+	// OnToolEnd. With ParallelToolCalls it may be called concurrently.
+	OnToolDelta func(name string, delta string)
+	// OnBeforeToolExec runs after argument parsing and access checks, before
+	// loop detection and execution. Its return value replaces the tool's
+	// args, so it can enforce server-controlled parameters (e.g. overwrite a
+	// user_id the model produced); returning nil keeps the args unchanged.
+	// With ParallelToolCalls it is still called sequentially.
+	OnBeforeToolExec func(name string, args map[string]interface{}) map[string]interface{}
 }
 
 // AgentLoop implements the ReAct reasoning cycle.
@@ -150,8 +213,124 @@ type AgentLoop struct {
 	Tracer            *AgentTracer
 	LoopDetector      *LoopDetector      // optional: detects repetitive tool call patterns
 	Capabilities      *AgentCapabilities // optional: if set, enforces tool whitelist via ToolGrant
+	TokenEstimator    TokenEstimator     // optional: fills AgentLoopResult.EstimatedTokens
+	Metrics           *AgentMetrics      // optional: aggregate counters across runs (safe to share)
+	Session           *MemorySession     // optional: exposed to tool handlers as ToolContext.Session
+	Model             string             // optional: model name recorded on tracing spans
+	// PreTurnFn, if set, is called before every LLM call with a copy of the
+	// conversation so far. The returned slice is sent for that turn only
+	// (e.g. to add a "[now: ...]" system message) and is not kept in
+	// history; returning nil sends the messages unchanged.
+	PreTurnFn func(turn int, messages []map[string]interface{}) []map[string]interface{}
+	// MaxToolResultChars caps each tool result (in characters) before it is
+	// fed back to the LLM; 0 = unlimited.
+	MaxToolResultChars int
+	// StructuredToolErrors feeds tool failures back as a JSON object
+	// {"error": "...", "tool": "...", "retryable": bool} instead of the
+	// plain "Error: ..." text.
+	StructuredToolErrors bool
+	// ExtraContextPlacement controls where RunContext's extraContext goes;
+	// the zero value behaves like ExtraContextSystem.
+	ExtraContextPlacement ExtraContextPlacement
+	// DedupeToolCalls reuses the result of an earlier successful call with
+	// the exact same tool name and arguments in this run instead of
+	// executing it again. Reused calls are still counted in ToolCallsCount.
+	DedupeToolCalls bool
+	// OnLoopDetected, if set, is called when LoopDetector reports a "repeat".
+	// With stop=true the run ends with StoppedReason "loop_detected" and
+	// finalOutput as FinalOutput (instead of the internal warning text).
+	// With stop=false the repeated call is skipped, finalOutput is returned
+	// to the LLM as that call's tool result (a default hint if empty), and
+	// the run continues.
+	OnLoopDetected func(warning *LoopWarning) (finalOutput string, stop bool)
+	// ForceAnswerOnLastTurn calls the LLM without tools on the last allowed
+	// turn (turn == MaxTurns), so the run ends "completed" with a text
+	// answer instead of "max_turns".
+	ForceAnswerOnLastTurn bool
+	// ContentLoopThreshold, when > 0, stops the run with StoppedReason
+	// "content_loop" once this many consecutive tool-calling turns carry the
+	// same assistant content (compared case- and whitespace-insensitively).
+	// The repeated content becomes FinalOutput. 0 = off.
+	ContentLoopThreshold int
+	// AllowedTools and BlockedTools restrict which tools may run, by name
+	// with path.Match wildcards (e.g. "mcp.fs.*"). BlockedTools wins; an
+	// empty AllowedTools allows everything. They are checked before
+	// Capabilities, and a call must pass both.
+	AllowedTools []string
+	BlockedTools []string
+	// MaxHistoryMessages, when > 0, keeps only the most recent N entries of
+	// conversationHistory (plus any leading system messages) when building
+	// the prompt. Tool results whose assistant call was cut off are dropped
+	// from the start of the window. 0 = unlimited.
+	MaxHistoryMessages int
+	// EmptyOutputFallback replaces a blank final answer (empty or
+	// whitespace-only content with no tool calls), so callers never get an
+	// empty reply. "" keeps the blank output.
+	EmptyOutputFallback string
+	// RetryOnEmpty calls the LLM once more, within the same turn, when it
+	// returns a blank final answer, before EmptyOutputFallback applies.
+	RetryOnEmpty bool
+}
+
+// limitHistory keeps the leading system messages of history plus its last
+// max entries, skipping tool results orphaned at the start of that window.
+// max <= 0 returns history unchanged.
+func limitHistory(history []map[string]interface{}, max int) []map[string]interface{} {
+	if max <= 0 {
+		return history
+	}
+	lead := 0
+	for lead < len(history) && history[lead]["role"] == "system" {
+		lead++
+	}
+	rest := history[lead:]
+	if len(rest) <= max {
+		return history
+	}
+	rest = rest[len(rest)-max:]
+	for len(rest) > 0 && rest[0]["role"] == "tool" {
+		rest = rest[1:]
+	}
+	out := make([]map[string]interface{}, 0, lead+len(rest))
+	out = append(out, history[:lead]...)
+	return append(out, rest...)
+}
+
+// checkToolAccess applies AllowedTools/BlockedTools, then Capabilities.
+func (a *AgentLoop) checkToolAccess(toolName string) ToolCallDecision {
+	for _, p := range a.BlockedTools {
+		if matchToolFilter(p, toolName) {
+			return ToolCallDecision{Allowed: false, DenyReason: "tool blocked for this run: " + toolName}
+		}
+	}
+	if len(a.AllowedTools) > 0 {
+		allowed := false
+		for _, p := range a.AllowedTools {
+			if matchToolFilter(p, toolName) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ToolCallDecision{Allowed: false, DenyReason: "tool not allowed for this run: " + toolName}
+		}
+	}
+	return CheckToolGrant(a.Capabilities, toolName)
 }
 
+// ExtraContextPlacement selects how extraContext is added to the messages.
+type ExtraContextPlacement string
+
+const (
+	// ExtraContextSystem adds extraContext as its own system message after SystemPrompt.
+	ExtraContextSystem ExtraContextPlacement = "system"
+	// ExtraContextPrependUser prefixes extraContext to the user message, for
+	// backends that handle multiple system messages poorly.
+	ExtraContextPrependUser ExtraContextPlacement = "prepend_user"
+	// ExtraContextMergeSystem appends extraContext to SystemPrompt in a single system message.
+	ExtraContextMergeSystem ExtraContextPlacement = "merge_system"
+)
+
 // callLLM invokes the LLM using the context-aware function if available, otherwise falls back to LLMFn.
 func (a *AgentLoop) callLLM(ctx context.Context, messages []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
 	if a.LLMFnCtx != nil {
@@ -201,6 +380,7 @@ type pendingToolCall struct {
 	ToolCall ToolCallInput
 	ToolName string
 	Args     map[string]interface{}
+	Cached   *executedToolCall // earlier identical call (DedupeToolCalls)
 }
 
 type executedToolCall struct {
@@ -223,7 +403,18 @@ func parseToolCallArguments(raw string) (map[string]interface{}, error) {
 	return args, nil
 }
 
-func (a *AgentLoop) executeToolCall(ctx context.Context, tc ToolCallInput, funcName string, funcArgs map[string]interface{}) executedToolCall {
+// beforeToolExec applies Hooks.OnBeforeToolExec to args.
+func (a *AgentLoop) beforeToolExec(funcName string, funcArgs map[string]interface{}) map[string]interface{} {
+	if a.Hooks.OnBeforeToolExec == nil {
+		return funcArgs
+	}
+	if rewritten := a.Hooks.OnBeforeToolExec(funcName, funcArgs); rewritten != nil {
+		return rewritten
+	}
+	return funcArgs
+}
+
+func (a *AgentLoop) executeToolCall(ctx context.Context, turn int, tc ToolCallInput, funcName string, funcArgs map[string]interface{}) executedToolCall {
 	if a.Hooks.OnToolStart != nil {
 		a.Hooks.OnToolStart(funcName, funcArgs)
 	}
@@ -237,9 +428,22 @@ func (a *AgentLoop) executeToolCall(ctx context.Context, tc ToolCallInput, funcN
 	// Execute (with tracing), pass ctx through ToolContext
 	var toolSpan *TracingSpan
 	if a.Tracer != nil && a.Tracer.enabled {
-		toolSpan = a.Tracer.ToolSpan(funcName, funcArgs)
+		attrs := make(map[string]interface{}, len(funcArgs)+2)
+		for k, v := range funcArgs {
+			attrs[k] = v
+		}
+		attrs["tool"] = funcName
+		attrs["turn"] = turn
+		toolSpan = a.Tracer.ToolSpan(funcName, attrs)
+	}
+	toolCtx := &ToolContext{ToolName: funcName, CallID: tc.ID, Extra: make(map[string]interface{}), Ctx: ctx, Session: a.Session}
+	if toolSpan != nil {
+		toolCtx.Tracer = a.Tracer
+		toolCtx.Span = toolSpan
+	}
+	if a.Hooks.OnToolDelta != nil {
+		toolCtx.OnDelta = func(delta string) { a.Hooks.OnToolDelta(funcName, delta) }
 	}
-	toolCtx := &ToolContext{ToolName: funcName, CallID: tc.ID, Extra: make(map[string]interface{}), Ctx: ctx}
 	var (
 		toolResult interface{}
 		toolErr    error
@@ -248,6 +452,11 @@ func (a *AgentLoop) executeToolCall(ctx context.Context, tc ToolCallInput, funcN
 		toolErr = fmt.Errorf("tool registry is nil")
 	} else {
 		toolResult, toolErr = a.ToolRegistry.Execute(funcName, funcArgs, toolCtx)
+		if errors.Is(toolErr, ErrToolNotFound) {
+			if suggestions := a.ToolRegistry.Suggest(funcName); len(suggestions) > 0 {
+				toolErr = fmt.Errorf("%w (did you mean: %s?)", toolErr, strings.Join(suggestions, ", "))
+			}
+		}
 	}
 	if toolSpan != nil {
 		status := "ok"
@@ -262,7 +471,11 @@ func (a *AgentLoop) executeToolCall(ctx context.Context, tc ToolCallInput, funcN
 	var toolResultStr string
 	if toolErr != nil {
 		record.Error = toolErr.Error()
-		toolResultStr = fmt.Sprintf("Error: %v", toolErr)
+		if a.StructuredToolErrors {
+			toolResultStr = structuredToolError(funcName, toolErr)
+		} else {
+			toolResultStr = fmt.Sprintf("Error: %v", toolErr)
+		}
 		logWarnf("[AgentLoop] Tool %s failed: %v", funcName, toolErr)
 	} else {
 		switch v := toolResult.(type) {
@@ -280,6 +493,15 @@ func (a *AgentLoop) executeToolCall(ctx context.Context, tc ToolCallInput, funcN
 		record.Result = toolResultStr
 	}
 
+	if truncated, originalLen, ok := truncateToolResult(toolResultStr, a.MaxToolResultChars); ok {
+		logWarnf("[AgentLoop] Tool %s result truncated from %d to %d chars", funcName, originalLen, a.MaxToolResultChars)
+		toolResultStr = truncated
+		record.OriginalLength = originalLen
+		if record.Error == "" {
+			record.Result = truncated
+		}
+	}
+
 	if a.Hooks.OnToolEnd != nil {
 		a.Hooks.OnToolEnd(funcName, record.Result, record.Error)
 	}
@@ -294,6 +516,69 @@ func (a *AgentLoop) executeToolCall(ctx context.Context, tc ToolCallInput, funcN
 	}
 }
 
+// dedupedToolCallNote is appended to reused results so the LLM can tell.
+const dedupedToolCallNote = "(cached from earlier identical call)"
+
+func toolCallKey(name string, args map[string]interface{}) string {
+	return name + ":" + hashArgs(args)
+}
+
+// reuseToolCall answers tc with the result of an earlier identical call.
+func reuseToolCall(prev executedToolCall, tc ToolCallInput) executedToolCall {
+	record := prev.Record
+	record.CallID = tc.ID
+	record.Cached = true
+	content, _ := prev.Message["content"].(string)
+	return executedToolCall{
+		Record: record,
+		Message: map[string]interface{}{
+			"role":         "tool",
+			"tool_call_id": tc.ID,
+			"content":      content + "\n\n" + dedupedToolCallNote,
+		},
+	}
+}
+
+// structuredToolError renders a tool failure as JSON for the LLM.
+func structuredToolError(tool string, err error) string {
+	b, _ := json.Marshal(map[string]interface{}{
+		"error":     err.Error(),
+		"tool":      tool,
+		"retryable": isRetryableToolError(err),
+	})
+	return string(b)
+}
+
+// isRetryableToolError reports whether calling the tool again may succeed:
+// retryable MCP transport failures, MCP tool errors flagged retryable by the
+// server, timeouts, and missing arguments the model can supply on the next
+// call.
+func isRetryableToolError(err error) bool {
+	var transportErr *MCPTransportError
+	if errors.As(err, &transportErr) {
+		return transportErr.IsRetryable()
+	}
+	var toolErr *MCPToolError
+	if errors.As(err, &toolErr) {
+		retryable, _ := toolErr.Retryable()
+		return retryable
+	}
+	return errors.Is(err, ErrToolTimeout) || errors.Is(err, ErrToolMissingRequiredArg)
+}
+
+// truncateToolResult caps s at maxChars runes, appending a
+// "...[truncated N chars]" marker. Returns ok=false when no truncation is needed.
+func truncateToolResult(s string, maxChars int) (string, int, bool) {
+	if maxChars <= 0 || len(s) <= maxChars {
+		return s, 0, false
+	}
+	runes := []rune(s)
+	if len(runes) <= maxChars {
+		return s, 0, false
+	}
+	return fmt.Sprintf("%s...[truncated %d chars]", string(runes[:maxChars]), len(runes)-maxChars), len(runes), true
+}
+
 // NewAgentLoop creates a new agent loop.
 func NewAgentLoop(llmFn LLMFunc, registry *ToolRegistry, systemPrompt string, maxTurns int, hooks *AgentLoopHooks) *AgentLoop {
 	if maxTurns <= 0 {
@@ -322,12 +607,48 @@ func (a *AgentLoop) Run(userInput string, conversationHistory []map[string]inter
 // RunContext executes the agent loop with context support for cancellation/timeout.
 // When ctx is cancelled, the loop stops at the next check point and returns
 // StoppedReason "cancelled".
+//
+// Each run works on a snapshot of the loop taken at entry (see Clone), so a
+// single *AgentLoop may serve concurrent runs: config changes made while a
+// run is in flight apply from the next run, and LoopDetector history never
+// carries over between runs; the run's detector window is reported in
+// AgentLoopResult.LoopStats.
 func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversationHistory []map[string]interface{}, extraContext string) *AgentLoopResult {
+	run := a.Clone()
+	start := time.Now()
+	result := run.runContext(ctx, userInput, conversationHistory, extraContext)
+	result.TotalDuration = time.Since(start)
+	if run.LoopDetector != nil {
+		stats := run.LoopDetector.Stats()
+		result.LoopStats = &stats
+	}
+	if a.Metrics != nil {
+		a.Metrics.record(result, result.TotalDuration)
+	}
+	return result
+}
+
+// Clone returns a copy of the loop whose configuration (SystemPrompt,
+// MaxTurns, ...) can be changed without affecting the original. Shared
+// collaborators (ToolRegistry, Guardrails, Tracer, Hooks, Metrics) are not
+// copied; LoopDetector is replaced by a fresh detector with the same config.
+func (a *AgentLoop) Clone() *AgentLoop {
+	c := *a
+	if a.LoopDetector != nil {
+		c.LoopDetector = a.LoopDetector.Clone()
+	}
+	return &c
+}
+
+func (a *AgentLoop) runContext(ctx context.Context, userInput string, conversationHistory []map[string]interface{}, extraContext string) *AgentLoopResult {
 	// --- Tracing: agent span ---
 	var agentSpan *TracingSpan
 	if a.Tracer != nil && a.Tracer.enabled {
 		a.Tracer.NewTrace()
 		agentSpan = a.Tracer.AgentSpan("agent_loop")
+		if a.Model != "" {
+			agentSpan.SetAttribute("model", a.Model)
+		}
 		defer func() {
 			if agentSpan != nil {
 				a.Tracer.EndSpan(agentSpan, agentSpan.Status, agentSpan.Error)
@@ -352,16 +673,18 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 					agentSpan.Error = err.Error()
 				}
 				return &AgentLoopResult{
-					StoppedReason: "guardrail",
-					FinalOutput:   err.Error(),
+					StoppedReason:  "guardrail",
+					FinalOutput:    err.Error(),
+					GuardrailBlock: guardrailBlockInfo(err),
 				}
 			}
 			a.Tracer.EndSpan(gs, "ok", "")
 		} else {
 			if err := a.Guardrails.CheckInputWithContext(ctx, userInput, nil, nil); err != nil {
 				return &AgentLoopResult{
-					StoppedReason: "guardrail",
-					FinalOutput:   err.Error(),
+					StoppedReason:  "guardrail",
+					FinalOutput:    err.Error(),
+					GuardrailBlock: guardrailBlockInfo(err),
 				}
 			}
 		}
@@ -370,16 +693,30 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 	// Build initial messages
 	var messages []map[string]interface{}
 
-	if a.SystemPrompt != "" {
-		messages = append(messages, map[string]interface{}{"role": "system", "content": a.SystemPrompt})
+	systemPrompt, userContent := a.SystemPrompt, userInput
+	if extraContext != "" {
+		switch a.ExtraContextPlacement {
+		case ExtraContextPrependUser:
+			userContent = extraContext + "\n\n" + userInput
+			extraContext = ""
+		case ExtraContextMergeSystem:
+			if systemPrompt != "" {
+				systemPrompt += "\n\n"
+			}
+			systemPrompt += extraContext
+			extraContext = ""
+		}
+	}
+	if systemPrompt != "" {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": systemPrompt})
 	}
 	if extraContext != "" {
 		messages = append(messages, map[string]interface{}{"role": "system", "content": extraContext})
 	}
 	if conversationHistory != nil {
-		messages = append(messages, conversationHistory...)
+		messages = append(messages, limitHistory(conversationHistory, a.MaxHistoryMessages)...)
 	}
-	messages = append(messages, map[string]interface{}{"role": "user", "content": userInput})
+	messages = append(messages, map[string]interface{}{"role": "user", "content": userContent})
 
 	// Get tools schema
 	var toolsSchema []map[string]interface{}
@@ -390,6 +727,16 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 	result := &AgentLoopResult{}
 	turnNumber := 0
 
+	// Successful tool calls by name+args, for DedupeToolCalls.
+	var executedCalls map[string]executedToolCall
+	if a.DedupeToolCalls {
+		executedCalls = make(map[string]executedToolCall)
+	}
+
+	// Consecutive identical assistant contents, for ContentLoopThreshold.
+	var lastContentHash string
+	contentRepeats := 0
+
 	for turnNumber < a.MaxTurns {
 		// --- Check cancellation at start of each turn ---
 		if ctx.Err() != nil {
@@ -398,18 +745,59 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 		}
 
 		turnNumber++
-		turn := TurnRecord{TurnNumber: turnNumber}
+		turn := TurnRecord{TurnNumber: turnNumber, StartedAt: time.Now()}
 
 		// --- LLM Call ---
+		llmMessages := messages
+		if a.PreTurnFn != nil {
+			// Hand over a copy so per-turn additions never leak into history.
+			if out := a.PreTurnFn(turnNumber, append([]map[string]interface{}(nil), messages...)); out != nil {
+				llmMessages = out
+			}
+		}
+
+		// --- Conversation Guardrails (full history, once per turn) ---
+		if a.Guardrails != nil && a.Guardrails.ConversationCount() > 0 {
+			var gs *TracingSpan
+			if a.Tracer != nil && a.Tracer.enabled {
+				gs = a.Tracer.GuardrailSpan("conversation_guardrails")
+			}
+			err := a.Guardrails.CheckConversationWithContext(ctx, llmMessages)
+			if gs != nil {
+				if err != nil {
+					a.Tracer.EndSpan(gs, "error", err.Error())
+				} else {
+					a.Tracer.EndSpan(gs, "ok", "")
+				}
+			}
+			if err != nil {
+				if agentSpan != nil {
+					agentSpan.Status = "error"
+					agentSpan.Error = err.Error()
+				}
+				result.StoppedReason = "guardrail"
+				result.FinalOutput = err.Error()
+				result.GuardrailBlock = guardrailBlockInfo(err)
+				break
+			}
+		}
+
 		if a.Hooks.OnLLMStart != nil {
-			a.Hooks.OnLLMStart(turnNumber, messages)
+			a.Hooks.OnLLMStart(turnNumber, llmMessages)
 		}
 
 		var llmSpan *TracingSpan
 		if a.Tracer != nil && a.Tracer.enabled {
-			llmSpan = a.Tracer.LLMSpan("", map[string]interface{}{"turn": turnNumber})
+			llmSpan = a.Tracer.LLMSpan(a.Model, map[string]interface{}{"turn": turnNumber})
+		}
+		turnTools := toolsSchema
+		forceAnswer := a.ForceAnswerOnLastTurn && turnNumber == a.MaxTurns
+		if forceAnswer {
+			turnTools = nil // tool_choice "none": the model must answer in text
 		}
-		llmResp, err := a.callLLMWithRetry(ctx, messages, toolsSchema)
+		llmStart := time.Now()
+		llmResp, err := a.callLLMWithRetry(ctx, llmMessages, turnTools)
+		turn.LLMDuration = time.Since(llmStart)
 		if llmSpan != nil {
 			status := "ok"
 			errMsg := ""
@@ -438,7 +826,28 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 			a.Hooks.OnLLMEnd(turnNumber, llmResp)
 		}
 
+		if a.RetryOnEmpty && (len(llmResp.ToolCalls) == 0 || forceAnswer) && strings.TrimSpace(llmResp.Content) == "" {
+			logWarnf("[AgentLoop] Empty LLM output at turn %d, retrying once", turnNumber)
+			retryStart := time.Now()
+			retry, err := a.callLLMWithRetry(ctx, llmMessages, turnTools)
+			turn.LLMDuration += time.Since(retryStart)
+			if err != nil {
+				logWarnf("[AgentLoop] Retry after empty output failed: %v", err)
+			} else {
+				if a.Hooks.OnLLMEnd != nil {
+					a.Hooks.OnLLMEnd(turnNumber, retry)
+				}
+				llmResp = retry
+			}
+		}
+
 		turn.LLMOutput = llmResp.Content
+		if forceAnswer && len(llmResp.ToolCalls) > 0 {
+			// Tools were not offered; ignore any calls the model made anyway.
+			forced := *llmResp
+			forced.ToolCalls = nil
+			llmResp = &forced
+		}
 
 		// --- Check: Final output (no tool calls) ---
 		if len(llmResp.ToolCalls) == 0 {
@@ -451,6 +860,7 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 						a.Tracer.EndSpan(gs, "error", err.Error())
 						result.StoppedReason = "guardrail"
 						result.FinalOutput = err.Error()
+						result.GuardrailBlock = guardrailBlockInfo(err)
 						if agentSpan != nil {
 							agentSpan.Status = "error"
 							agentSpan.Error = err.Error()
@@ -461,12 +871,30 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 				} else if err := a.Guardrails.CheckOutputWithContext(ctx, llmResp.Content, nil, nil); err != nil {
 					result.StoppedReason = "guardrail"
 					result.FinalOutput = err.Error()
+					result.GuardrailBlock = guardrailBlockInfo(err)
+					break
+				}
+			}
+
+			finalOutput := llmResp.Content
+			if a.Guardrails != nil && a.Guardrails.RewriterCount() > 0 && finalOutput != "" {
+				rewritten, err := a.Guardrails.RewriteOutput(finalOutput)
+				if err != nil {
+					logErrorf("[AgentLoop] %v", err)
+					result.StoppedReason = "rewrite_error"
+					result.FinalOutput = err.Error()
 					break
 				}
+				finalOutput = rewritten
+			}
+			if a.EmptyOutputFallback != "" && strings.TrimSpace(finalOutput) == "" {
+				logWarnf("[AgentLoop] Empty final output at turn %d, using fallback", turnNumber)
+				finalOutput = a.EmptyOutputFallback
 			}
 
 			turn.IsFinal = true
-			result.FinalOutput = llmResp.Content
+			turn.EndedAt = time.Now()
+			result.FinalOutput = finalOutput
 			result.StoppedReason = "completed"
 			result.Turns = append(result.Turns, turn)
 			if a.Hooks.OnTurnEnd != nil {
@@ -475,7 +903,32 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 			break
 		}
 
+		// --- Content loop: same narration turn after turn ---
+		if a.ContentLoopThreshold > 0 {
+			h := contentHash(llmResp.Content)
+			if h != "" && h == lastContentHash {
+				contentRepeats++
+			} else {
+				contentRepeats = 1
+			}
+			lastContentHash = h
+			if h != "" && contentRepeats >= a.ContentLoopThreshold {
+				logWarnf("[AgentLoop] Same assistant content repeated %d times, stopping", contentRepeats)
+				turn.EndedAt = time.Now()
+				result.StoppedReason = "content_loop"
+				result.FinalOutput = llmResp.Content
+				result.Turns = append(result.Turns, turn)
+				break
+			}
+		}
+
 		// --- Execute tool calls ---
+		if llmResp.Content != "" {
+			result.Narrations = append(result.Narrations, llmResp.Content)
+			if a.Hooks.OnAssistantNarration != nil {
+				a.Hooks.OnAssistantNarration(turnNumber, llmResp.Content)
+			}
+		}
 		assistantMsg := map[string]interface{}{
 			"role":    "assistant",
 			"content": llmResp.Content,
@@ -494,6 +947,7 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 		assistantMsg["tool_calls"] = serializedCalls
 		messages = append(messages, assistantMsg)
 
+		toolStart := time.Now()
 		cancelled := false
 		loopDetected := false
 		canParallelToolCalls := a.ParallelToolCalls &&
@@ -525,7 +979,7 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 					})
 					continue
 				}
-				if decision := CheckToolGrant(a.Capabilities, funcName); !decision.Allowed {
+				if decision := a.checkToolAccess(funcName); !decision.Allowed {
 					logWarnf("[AgentLoop] Tool %s denied: %s", funcName, decision.DenyReason)
 					messages = append(messages, map[string]interface{}{
 						"role":         "tool",
@@ -537,11 +991,18 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 					})
 					continue
 				}
-				pending = append(pending, pendingToolCall{
+				funcArgs = a.beforeToolExec(funcName, funcArgs)
+				call := pendingToolCall{
 					ToolCall: tc,
 					ToolName: funcName,
 					Args:     funcArgs,
-				})
+				}
+				if executedCalls != nil {
+					if prev, ok := executedCalls[toolCallKey(funcName, funcArgs)]; ok {
+						call.Cached = &prev
+					}
+				}
+				pending = append(pending, call)
 			}
 			if !cancelled {
 				executed := make([]executedToolCall, len(pending))
@@ -551,11 +1012,18 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 					wg.Add(1)
 					go func() {
 						defer wg.Done()
-						executed[i] = a.executeToolCall(ctx, call.ToolCall, call.ToolName, call.Args)
+						if call.Cached != nil {
+							executed[i] = reuseToolCall(*call.Cached, call.ToolCall)
+							return
+						}
+						executed[i] = a.executeToolCall(ctx, turnNumber, call.ToolCall, call.ToolName, call.Args)
 					}()
 				}
 				wg.Wait()
-				for _, exec := range executed {
+				for i, exec := range executed {
+					if executedCalls != nil && !exec.Record.Cached && exec.Record.Error == "" {
+						executedCalls[toolCallKey(pending[i].ToolName, pending[i].Args)] = exec
+					}
 					turn.ToolCalls = append(turn.ToolCalls, exec.Record)
 					result.ToolCallsCount++
 					messages = append(messages, exec.Message)
@@ -587,8 +1055,8 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 					continue
 				}
 
-				// Capability enforcement: check ToolGrant before execution
-				if decision := CheckToolGrant(a.Capabilities, funcName); !decision.Allowed {
+				// Access control: AllowedTools/BlockedTools, then ToolGrant
+				if decision := a.checkToolAccess(funcName); !decision.Allowed {
 					logWarnf("[AgentLoop] Tool %s denied: %s", funcName, decision.DenyReason)
 					messages = append(messages, map[string]interface{}{
 						"role":         "tool",
@@ -600,14 +1068,37 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 					})
 					continue
 				}
+				funcArgs = a.beforeToolExec(funcName, funcArgs)
 
 				// Loop detection: check before executing
 				if a.LoopDetector != nil {
 					if warning := a.LoopDetector.Check(funcName, funcArgs); warning != nil {
+						result.LoopInfo = warning
 						if warning.Type == "repeat" {
-							loopDetected = true
-							result.FinalOutput = warning.Message
-							break
+							if a.OnLoopDetected == nil {
+								loopDetected = true
+								result.FinalOutput = warning.Message
+								break
+							}
+							out, stop := a.OnLoopDetected(warning)
+							if stop {
+								loopDetected = true
+								result.FinalOutput = out
+								break
+							}
+							// Skip the repeated call and answer it with the guidance.
+							if out == "" {
+								out = "[Warning] " + warning.Message + ". Try a different approach."
+							}
+							messages = append(messages, map[string]interface{}{
+								"role":         "tool",
+								"tool_call_id": tc.ID,
+								"content":      out,
+							})
+							turn.ToolCalls = append(turn.ToolCalls, ToolCallRecord{
+								ToolName: funcName, Arguments: funcArgs, CallID: tc.ID, Error: warning.Message,
+							})
+							continue
 						}
 						// flood/ping_pong: inject warning into messages, continue
 						messages = append(messages, map[string]interface{}{
@@ -617,7 +1108,17 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 					}
 				}
 
-				exec := a.executeToolCall(ctx, tc, funcName, funcArgs)
+				var exec executedToolCall
+				if executedCalls == nil {
+					exec = a.executeToolCall(ctx, turnNumber, tc, funcName, funcArgs)
+				} else if prev, ok := executedCalls[toolCallKey(funcName, funcArgs)]; ok {
+					exec = reuseToolCall(prev, tc)
+				} else {
+					exec = a.executeToolCall(ctx, turnNumber, tc, funcName, funcArgs)
+					if exec.Record.Error == "" {
+						executedCalls[toolCallKey(funcName, funcArgs)] = exec
+					}
+				}
 				turn.ToolCalls = append(turn.ToolCalls, exec.Record)
 				result.ToolCallsCount++
 
@@ -630,6 +1131,9 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 			}
 		}
 
+		turn.ToolDuration = time.Since(toolStart)
+		turn.EndedAt = time.Now()
+
 		if loopDetected {
 			result.StoppedReason = "loop_detected"
 			result.Turns = append(result.Turns, turn)
@@ -658,5 +1162,8 @@ func (a *AgentLoop) RunContext(ctx context.Context, userInput string, conversati
 
 	result.TotalTurns = turnNumber
 	result.Messages = messages
+	if a.TokenEstimator != nil {
+		result.EstimatedTokens = EstimateMessagesTokens(a.TokenEstimator, messages)
+	}
 	return result
 }
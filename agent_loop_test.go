@@ -278,6 +278,52 @@ func TestAgentLoop_MaxTurnsReached(t *testing.T) {
 	}
 }
 
+func TestAgentLoop_ForceAnswerOnLastTurn(t *testing.T) {
+	var toolsPerCall []int
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		toolsPerCall = append(toolsPerCall, len(tools))
+		if len(tools) == 0 {
+			return makeFinalResp("best answer so far"), nil
+		}
+		return makeToolCallResp([]struct{ Name, Args string }{
+			{"search", `{"query":"infinite"}`},
+		}, "still thinking"), nil
+	}
+
+	loop := NewAgentLoop(llm, testRegistry(), "", 3, nil)
+	loop.ForceAnswerOnLastTurn = true
+	result := loop.Run("loop forever", nil, "")
+
+	if result.StoppedReason != "completed" || result.FinalOutput != "best answer so far" {
+		t.Fatalf("expected completed with forced answer, got %s / %q", result.StoppedReason, result.FinalOutput)
+	}
+	if len(toolsPerCall) != 3 || toolsPerCall[0] == 0 || toolsPerCall[1] == 0 || toolsPerCall[2] != 0 {
+		t.Fatalf("expected tools on turns 1-2 and none on turn 3, got %v", toolsPerCall)
+	}
+	if result.ToolCallsCount != 2 {
+		t.Fatalf("expected 2 tool calls, got %d", result.ToolCallsCount)
+	}
+}
+
+func TestAgentLoop_ForceAnswerOnLastTurn_IgnoresToolCalls(t *testing.T) {
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		return makeToolCallResp([]struct{ Name, Args string }{
+			{"search", `{"query":"infinite"}`},
+		}, "partial answer"), nil
+	}
+
+	loop := NewAgentLoop(llm, testRegistry(), "", 2, nil)
+	loop.ForceAnswerOnLastTurn = true
+	result := loop.Run("loop forever", nil, "")
+
+	if result.StoppedReason != "completed" || result.FinalOutput != "partial answer" {
+		t.Fatalf("expected completed with text content, got %s / %q", result.StoppedReason, result.FinalOutput)
+	}
+	if result.ToolCallsCount != 1 {
+		t.Fatalf("tool calls on the forced turn must not run, got %d", result.ToolCallsCount)
+	}
+}
+
 func TestAgentLoop_MaxTurns1(t *testing.T) {
 	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
 		return makeToolCallResp([]struct{ Name, Args string }{
@@ -320,6 +366,370 @@ func TestAgentLoop_ToolError(t *testing.T) {
 	}
 }
 
+func TestAgentLoop_StructuredToolErrors(t *testing.T) {
+	reg := NewToolRegistry()
+	reg.Register(&Tool{
+		Name: "remote",
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("mcp call: %w", &MCPTransportError{StatusCode: 503, BodyPreview: "busy"})
+		},
+	})
+
+	var toolContent string
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		callCount++
+		if callCount == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{
+				{"remote", `{}`},
+				{"nonexistent", `{}`},
+			}, ""), nil
+		}
+		for _, m := range msgs {
+			if m["role"] == "tool" {
+				toolContent += m["content"].(string) + "\n"
+			}
+		}
+		return makeFinalResp("done"), nil
+	}
+
+	loop := NewAgentLoop(llm, reg, "", 10, nil)
+	loop.StructuredToolErrors = true
+	loop.Run("test", nil, "")
+
+	lines := strings.Split(strings.TrimSpace(toolContent), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 tool messages, got %q", toolContent)
+	}
+	var remote, missing map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &remote); err != nil {
+		t.Fatalf("tool error should be JSON: %v (%q)", err, lines[0])
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &missing); err != nil {
+		t.Fatalf("tool error should be JSON: %v (%q)", err, lines[1])
+	}
+	if remote["tool"] != "remote" || remote["retryable"] != true || !strings.Contains(remote["error"].(string), "503") {
+		t.Fatalf("unexpected structured error: %v", remote)
+	}
+	if missing["tool"] != "nonexistent" || missing["retryable"] != false {
+		t.Fatalf("unknown tool should not be retryable: %v", missing)
+	}
+}
+
+func TestAgentLoop_ExtraContextPlacement(t *testing.T) {
+	history := []map[string]interface{}{{"role": "assistant", "content": "earlier"}}
+	cases := []struct {
+		placement ExtraContextPlacement
+		want      []map[string]interface{}
+	}{
+		{"", []map[string]interface{}{
+			{"role": "system", "content": "SYS"},
+			{"role": "system", "content": "CTX"},
+			{"role": "assistant", "content": "earlier"},
+			{"role": "user", "content": "hi"},
+		}},
+		{ExtraContextSystem, []map[string]interface{}{
+			{"role": "system", "content": "SYS"},
+			{"role": "system", "content": "CTX"},
+			{"role": "assistant", "content": "earlier"},
+			{"role": "user", "content": "hi"},
+		}},
+		{ExtraContextPrependUser, []map[string]interface{}{
+			{"role": "system", "content": "SYS"},
+			{"role": "assistant", "content": "earlier"},
+			{"role": "user", "content": "CTX\n\nhi"},
+		}},
+		{ExtraContextMergeSystem, []map[string]interface{}{
+			{"role": "system", "content": "SYS\n\nCTX"},
+			{"role": "assistant", "content": "earlier"},
+			{"role": "user", "content": "hi"},
+		}},
+	}
+
+	for _, c := range cases {
+		var got []map[string]interface{}
+		llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+			got = msgs
+			return makeFinalResp("ok"), nil
+		}
+		loop := NewAgentLoop(llm, nil, "SYS", 3, nil)
+		loop.ExtraContextPlacement = c.placement
+		loop.Run("hi", history, "CTX")
+
+		if len(got) != len(c.want) {
+			t.Fatalf("%q: expected %d messages, got %v", c.placement, len(c.want), got)
+		}
+		for i := range c.want {
+			if got[i]["role"] != c.want[i]["role"] || got[i]["content"] != c.want[i]["content"] {
+				t.Fatalf("%q: message %d = %v, want %v", c.placement, i, got[i], c.want[i])
+			}
+		}
+	}
+}
+
+func TestAgentLoop_EmptyOutputFallback(t *testing.T) {
+	calls := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		calls++
+		return makeFinalResp("  \n "), nil
+	}
+
+	loop := NewAgentLoop(llm, nil, "", 3, nil)
+	loop.EmptyOutputFallback = "Sorry, I have nothing to add."
+	result := loop.Run("hi", nil, "")
+
+	if result.StoppedReason != "completed" || result.FinalOutput != "Sorry, I have nothing to add." {
+		t.Fatalf("expected fallback answer, got %q (%s)", result.FinalOutput, result.StoppedReason)
+	}
+	if calls != 1 {
+		t.Fatalf("expected no retry without RetryOnEmpty, got %d calls", calls)
+	}
+
+	// Retry also blank: one extra call, then the fallback.
+	calls = 0
+	loop.RetryOnEmpty = true
+	result = loop.Run("hi", nil, "")
+	if calls != 2 || result.FinalOutput != "Sorry, I have nothing to add." {
+		t.Fatalf("expected one retry then fallback, got %d calls / %q", calls, result.FinalOutput)
+	}
+}
+
+func TestAgentLoop_RetryOnEmptyThenSuccess(t *testing.T) {
+	calls := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		calls++
+		if calls == 1 {
+			return makeFinalResp(""), nil
+		}
+		return makeFinalResp("Here you go."), nil
+	}
+
+	loop := NewAgentLoop(llm, nil, "", 3, nil)
+	loop.RetryOnEmpty = true
+	loop.EmptyOutputFallback = "fallback"
+	result := loop.Run("hi", nil, "")
+
+	if result.FinalOutput != "Here you go." || result.StoppedReason != "completed" {
+		t.Fatalf("expected retried answer, got %q (%s)", result.FinalOutput, result.StoppedReason)
+	}
+	if calls != 2 || result.TotalTurns != 1 {
+		t.Fatalf("expected the retry within one turn, got %d calls / %d turns", calls, result.TotalTurns)
+	}
+}
+
+func TestAgentLoop_UnknownToolSuggestion(t *testing.T) {
+	calls := 0
+	var toolContent interface{}
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		calls++
+		if calls == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{{"get_wether", `{"city":"Paris"}`}}, ""), nil
+		}
+		toolContent = msgs[len(msgs)-1]["content"]
+		return makeFinalResp("done"), nil
+	}
+
+	loop := NewAgentLoop(llm, testRegistry(), "", 5, nil)
+	loop.Run("weather?", nil, "")
+
+	content, _ := toolContent.(string)
+	if !strings.Contains(content, "did you mean: get_weather?") {
+		t.Fatalf("expected a suggestion in the tool error, got %q", content)
+	}
+}
+
+func TestAgentLoop_StreamingTool(t *testing.T) {
+	reg := NewToolRegistry()
+	reg.Register(&Tool{
+		Name: "count",
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			ch := make(chan string)
+			go func() {
+				defer close(ch)
+				for _, chunk := range []string{"one ", "two ", "three"} {
+					ch <- chunk
+				}
+			}()
+			return (<-chan string)(ch), nil
+		},
+	})
+
+	calls := 0
+	var toolContent interface{}
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		calls++
+		if calls == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{{"count", "{}"}}, ""), nil
+		}
+		toolContent = msgs[len(msgs)-1]["content"]
+		return makeFinalResp("done"), nil
+	}
+
+	var deltas []string
+	var endResult string
+	loop := NewAgentLoop(llm, reg, "", 5, &AgentLoopHooks{
+		OnToolDelta: func(name, delta string) {
+			if name != "count" {
+				t.Errorf("unexpected tool name %q", name)
+			}
+			deltas = append(deltas, delta)
+		},
+		OnToolEnd: func(name, result, errStr string) { endResult = result },
+	})
+	result := loop.Run("count", nil, "")
+
+	if result.StoppedReason != "completed" {
+		t.Fatalf("expected completed, got %s", result.StoppedReason)
+	}
+	if strings.Join(deltas, "|") != "one |two |three" {
+		t.Fatalf("unexpected deltas: %q", deltas)
+	}
+	if toolContent != "one two three" || endResult != "one two three" {
+		t.Fatalf("expected assembled result, got content=%v end=%q", toolContent, endResult)
+	}
+}
+
+func TestAgentLoop_OnBeforeToolExecInjectsArgs(t *testing.T) {
+	var gotUserID interface{}
+	reg := NewToolRegistry()
+	reg.Register(&Tool{
+		Name: "lookup",
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			gotUserID = args["user_id"]
+			return "ok", nil
+		},
+	})
+
+	for _, parallel := range []bool{false, true} {
+		gotUserID = nil
+		calls := 0
+		llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+			calls++
+			if calls == 1 {
+				return makeToolCallResp([]struct{ Name, Args string }{{"lookup", `{"user_id":"attacker"}`}}, ""), nil
+			}
+			return makeFinalResp("done"), nil
+		}
+		var hookName string
+		loop := NewAgentLoop(llm, reg, "", 5, &AgentLoopHooks{
+			OnBeforeToolExec: func(name string, args map[string]interface{}) map[string]interface{} {
+				hookName = name
+				out := make(map[string]interface{}, len(args)+1)
+				for k, v := range args {
+					out[k] = v
+				}
+				out["user_id"] = "u-42"
+				return out
+			},
+		})
+		loop.ParallelToolCalls = parallel
+		result := loop.Run("who am I", nil, "")
+
+		if result.StoppedReason != "completed" {
+			t.Fatalf("parallel=%v: expected completed, got %s", parallel, result.StoppedReason)
+		}
+		if hookName != "lookup" {
+			t.Fatalf("parallel=%v: hook saw tool %q", parallel, hookName)
+		}
+		if gotUserID != "u-42" {
+			t.Fatalf("parallel=%v: expected tool to receive injected user_id, got %v", parallel, gotUserID)
+		}
+		if args := result.Turns[0].ToolCalls[0].Arguments; args["user_id"] != "u-42" {
+			t.Fatalf("parallel=%v: expected record to carry rewritten args, got %v", parallel, args)
+		}
+	}
+}
+
+func TestAgentLoop_OnBeforeToolExecSkippedForDeniedTools(t *testing.T) {
+	called := false
+	calls := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		calls++
+		if calls == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{{"search", `{"q":"x"}`}}, ""), nil
+		}
+		return makeFinalResp("done"), nil
+	}
+	loop := NewAgentLoop(llm, testRegistry(), "", 5, &AgentLoopHooks{
+		OnBeforeToolExec: func(name string, args map[string]interface{}) map[string]interface{} {
+			called = true
+			return nil
+		},
+	})
+	loop.BlockedTools = []string{"search"}
+	loop.Run("search", nil, "")
+
+	if called {
+		t.Fatal("expected hook not to run for a denied tool")
+	}
+}
+
+func TestAgentLoop_MaxHistoryMessages(t *testing.T) {
+	history := []map[string]interface{}{{"role": "system", "content": "summary"}}
+	for i := 0; i < 50; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		history = append(history, map[string]interface{}{"role": role, "content": fmt.Sprintf("m%d", i)})
+	}
+
+	var got []map[string]interface{}
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		got = msgs
+		return makeFinalResp("ok"), nil
+	}
+	loop := NewAgentLoop(llm, nil, "SYS", 3, nil)
+	loop.MaxHistoryMessages = 10
+	loop.Run("hi", history, "")
+
+	// system prompt + leading history system message + 10 recent + user input
+	if len(got) != 13 {
+		t.Fatalf("expected 13 messages, got %d", len(got))
+	}
+	if got[1]["content"] != "summary" {
+		t.Fatalf("leading system message not preserved: %v", got[1])
+	}
+	if got[2]["content"] != "m40" || got[11]["content"] != "m49" {
+		t.Fatalf("expected m40..m49, got %v..%v", got[2]["content"], got[11]["content"])
+	}
+	if len(history) != 51 {
+		t.Fatalf("caller history modified: %d", len(history))
+	}
+}
+
+func TestLimitHistory_DropsOrphanedToolResults(t *testing.T) {
+	history := []map[string]interface{}{
+		{"role": "user", "content": "q"},
+		{"role": "assistant", "content": nil, "tool_calls": []interface{}{}},
+		{"role": "tool", "content": "r1"},
+		{"role": "tool", "content": "r2"},
+		{"role": "assistant", "content": "a"},
+	}
+	got := limitHistory(history, 3)
+	if len(got) != 1 || got[0]["content"] != "a" {
+		t.Fatalf("expected only the final assistant message, got %v", got)
+	}
+	if got := limitHistory(history, 0); len(got) != len(history) {
+		t.Fatalf("0 should mean unlimited, got %d", len(got))
+	}
+}
+
+func TestAgentLoop_ExtraContextMergeWithoutSystemPrompt(t *testing.T) {
+	var got []map[string]interface{}
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		got = msgs
+		return makeFinalResp("ok"), nil
+	}
+	loop := NewAgentLoop(llm, nil, "", 3, nil)
+	loop.ExtraContextPlacement = ExtraContextMergeSystem
+	loop.Run("hi", nil, "CTX")
+
+	if len(got) != 2 || got[0]["content"] != "CTX" || got[0]["role"] != "system" {
+		t.Fatalf("unexpected messages: %v", got)
+	}
+}
+
 func TestAgentLoop_LLMError(t *testing.T) {
 	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
 		return nil, fmt.Errorf("API connection failed")
@@ -624,6 +1034,135 @@ func TestAgentLoop_JSONSerialization(t *testing.T) {
 	if len(data) == 0 {
 		t.Fatal("empty JSON")
 	}
+	for _, key := range []string{`"total_duration_ns"`, `"started_at"`, `"llm_duration_ns"`} {
+		if !strings.Contains(string(data), key) {
+			t.Fatalf("expected %s in JSON: %s", key, data)
+		}
+	}
+}
+
+func TestAgentLoop_TurnTimings(t *testing.T) {
+	const sleep = 30 * time.Millisecond
+	reg := NewToolRegistry()
+	reg.Register(&Tool{
+		Name:        "slow",
+		Description: "sleeps",
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			time.Sleep(sleep)
+			return "ok", nil
+		},
+	})
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		callCount++
+		if callCount == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{{"slow", `{}`}}, ""), nil
+		}
+		return makeFinalResp("Done"), nil
+	}
+
+	result := NewAgentLoop(llm, reg, "", 5, nil).Run("go", nil, "")
+	if len(result.Turns) != 2 {
+		t.Fatalf("expected 2 turns, got %d", len(result.Turns))
+	}
+	toolTurn := result.Turns[0]
+	if toolTurn.ToolDuration < sleep {
+		t.Fatalf("expected tool duration >= %v, got %v", sleep, toolTurn.ToolDuration)
+	}
+	if toolTurn.LLMDuration <= 0 || toolTurn.LLMDuration >= sleep {
+		t.Fatalf("unexpected LLM duration %v", toolTurn.LLMDuration)
+	}
+	if span := toolTurn.EndedAt.Sub(toolTurn.StartedAt); span < toolTurn.LLMDuration+toolTurn.ToolDuration {
+		t.Fatalf("turn span %v shorter than LLM+tool %v", span, toolTurn.LLMDuration+toolTurn.ToolDuration)
+	}
+	final := result.Turns[1]
+	if final.ToolDuration != 0 || final.EndedAt.Before(final.StartedAt) || final.StartedAt.Before(toolTurn.EndedAt) {
+		t.Fatalf("unexpected final turn timings: %+v", final)
+	}
+	if result.TotalDuration < sleep || result.TotalDuration < final.EndedAt.Sub(toolTurn.StartedAt) {
+		t.Fatalf("unexpected total duration %v", result.TotalDuration)
+	}
+}
+
+func TestAgentLoop_DedupeToolCalls(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		executions := 0
+		reg := NewToolRegistry()
+		reg.Register(&Tool{
+			Name:        "search",
+			Description: "search",
+			Parameters:  []ToolParam{{Name: "q", Type: "string", Required: true}},
+			Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+				executions++
+				return "result for " + args["q"].(string), nil
+			},
+		})
+		callCount := 0
+		llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+			callCount++
+			switch callCount {
+			case 1:
+				return makeToolCallResp([]struct{ Name, Args string }{{"search", `{"q":"x"}`}}, ""), nil
+			case 2:
+				return makeToolCallResp([]struct{ Name, Args string }{
+					{"search", `{"q":"x"}`}, {"search", `{"q":"y"}`},
+				}, ""), nil
+			}
+			return makeFinalResp("Done"), nil
+		}
+
+		loop := NewAgentLoop(llm, reg, "", 5, nil)
+		loop.DedupeToolCalls = true
+		loop.ParallelToolCalls = parallel
+		result := loop.Run("go", nil, "")
+
+		if executions != 2 {
+			t.Fatalf("parallel=%v: expected 2 executions (x once, y once), got %d", parallel, executions)
+		}
+		if result.ToolCallsCount != 3 {
+			t.Fatalf("parallel=%v: expected reused call to be counted, got %d", parallel, result.ToolCallsCount)
+		}
+		rec := result.Turns[1].ToolCalls[0]
+		if !rec.Cached || rec.Result != "result for x" || rec.CallID != "call_0" {
+			t.Fatalf("parallel=%v: unexpected reused record %+v", parallel, rec)
+		}
+		if result.Turns[1].ToolCalls[1].Cached {
+			t.Fatalf("parallel=%v: distinct args must not be reused", parallel)
+		}
+		found := false
+		for _, m := range result.Messages {
+			if c, _ := m["content"].(string); m["role"] == "tool" && strings.Contains(c, "(cached from earlier identical call)") {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("parallel=%v: expected cached note in tool message", parallel)
+		}
+	}
+}
+
+func TestAgentLoop_DedupeToolCalls_Disabled(t *testing.T) {
+	executions := 0
+	reg := NewToolRegistry()
+	reg.Register(&Tool{
+		Name: "search",
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			executions++
+			return "r", nil
+		},
+	})
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		callCount++
+		if callCount <= 2 {
+			return makeToolCallResp([]struct{ Name, Args string }{{"search", `{"q":"x"}`}}, ""), nil
+		}
+		return makeFinalResp("Done"), nil
+	}
+	NewAgentLoop(llm, reg, "", 5, nil).Run("go", nil, "")
+	if executions != 2 {
+		t.Fatalf("expected both calls executed without DedupeToolCalls, got %d", executions)
+	}
 }
 
 // ══════════════════════════════════════════════
@@ -731,3 +1270,321 @@ func TestRunContext_BackwardsCompat(t *testing.T) {
 		t.Fatalf("expected completed, got %s", result.StoppedReason)
 	}
 }
+
+func TestAgentLoop_MaxToolResultChars(t *testing.T) {
+	reg := NewToolRegistry()
+	reg.Register(&Tool{
+		Name:        "dump",
+		Description: "Return a large payload",
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			return strings.Repeat("x", 50), nil
+		},
+	})
+
+	var toolMsg string
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		callCount++
+		if callCount == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{{"dump", `{}`}}, ""), nil
+		}
+		for _, m := range msgs {
+			if m["role"] == "tool" {
+				toolMsg, _ = m["content"].(string)
+			}
+		}
+		return makeFinalResp("ok"), nil
+	}
+
+	loop := NewAgentLoop(llm, reg, "", 10, nil)
+	loop.MaxToolResultChars = 10
+	result := loop.Run("go", nil, "")
+
+	want := strings.Repeat("x", 10) + "...[truncated 40 chars]"
+	if toolMsg != want {
+		t.Fatalf("expected truncated tool message %q, got %q", want, toolMsg)
+	}
+	rec := result.Turns[0].ToolCalls[0]
+	if rec.OriginalLength != 50 {
+		t.Fatalf("expected original length 50, got %d", rec.OriginalLength)
+	}
+	if rec.Result != want {
+		t.Fatalf("expected record result to be truncated, got %q", rec.Result)
+	}
+}
+
+func TestTruncateToolResult(t *testing.T) {
+	if s, n, ok := truncateToolResult("short", 10); ok || s != "short" || n != 0 {
+		t.Fatalf("unexpected truncation: %q %d %v", s, n, ok)
+	}
+	if _, _, ok := truncateToolResult(strings.Repeat("a", 100), 0); ok {
+		t.Fatal("0 should mean unlimited")
+	}
+	// Counted in runes, not bytes.
+	if _, _, ok := truncateToolResult("你好世界", 4); ok {
+		t.Fatal("4 CJK runes should fit in 4 chars")
+	}
+	s, n, ok := truncateToolResult("你好世界", 2)
+	if !ok || n != 4 || s != "你好...[truncated 2 chars]" {
+		t.Fatalf("unexpected result: %q %d %v", s, n, ok)
+	}
+}
+
+func TestAgentLoop_AssistantNarration(t *testing.T) {
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		callCount++
+		if callCount == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{
+				{"get_weather", `{"city":"Shanghai"}`},
+			}, "Let me check the weather..."), nil
+		}
+		return makeFinalResp("Shanghai is 25°C."), nil
+	}
+
+	var hookTurns []int
+	var hookTexts []string
+	hooks := &AgentLoopHooks{
+		OnAssistantNarration: func(turn int, text string) {
+			hookTurns = append(hookTurns, turn)
+			hookTexts = append(hookTexts, text)
+		},
+	}
+
+	loop := NewAgentLoop(llm, testRegistry(), "", 10, hooks)
+	result := loop.Run("weather?", nil, "")
+
+	if len(result.Narrations) != 1 || result.Narrations[0] != "Let me check the weather..." {
+		t.Fatalf("unexpected narrations: %v", result.Narrations)
+	}
+	if len(hookTexts) != 1 || hookTurns[0] != 1 || hookTexts[0] != "Let me check the weather..." {
+		t.Fatalf("unexpected hook calls: turns=%v texts=%v", hookTurns, hookTexts)
+	}
+	if result.FinalOutput != "Shanghai is 25°C." {
+		t.Fatalf("narration must not replace final output, got %q", result.FinalOutput)
+	}
+}
+
+func TestAgentLoop_ConcurrentRunsIsolated(t *testing.T) {
+	// Each run calls search twice with identical args. With MaxRepeatCalls=3 a
+	// shared LoopDetector history would see 4 calls and flag a loop.
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		toolMsgs := 0
+		for _, m := range msgs {
+			if m["role"] == "tool" {
+				toolMsgs++
+			}
+		}
+		if toolMsgs < 2 {
+			time.Sleep(5 * time.Millisecond)
+			return makeToolCallResp([]struct{ Name, Args string }{
+				{"search", `{"query":"go"}`},
+			}, ""), nil
+		}
+		return makeFinalResp("done"), nil
+	}
+
+	loop := NewAgentLoop(llm, testRegistry(), "sys", 10, nil)
+	loop.LoopDetector = NewLoopDetector(LoopDetectorConfig{Enabled: true, MaxRepeatCalls: 3, WindowSize: 10})
+
+	results := make([]*AgentLoopResult, 2)
+	done := make(chan int, 2)
+	for i := range results {
+		go func(i int) {
+			results[i] = loop.RunContext(context.Background(), "search go", nil, "")
+			done <- i
+		}(i)
+	}
+	<-done
+	<-done
+
+	for i, r := range results {
+		if r.StoppedReason != "completed" {
+			t.Fatalf("run %d: expected completed, got %s (%+v)", i, r.StoppedReason, r.LoopInfo)
+		}
+		if r.ToolCallsCount != 2 {
+			t.Fatalf("run %d: expected 2 tool calls, got %d", i, r.ToolCallsCount)
+		}
+		if r.LoopStats == nil || r.LoopStats.ToolCounts["search"] != 2 {
+			t.Fatalf("run %d: expected per-run loop stats with 2 searches, got %+v", i, r.LoopStats)
+		}
+	}
+	if stats := loop.LoopDetector.Stats(); len(stats.Recent) != 0 {
+		t.Fatalf("shared detector should not accumulate run history, got %v", stats.Recent)
+	}
+}
+
+func TestAgentLoop_Clone(t *testing.T) {
+	loop := NewAgentLoop(func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		return makeFinalResp("ok"), nil
+	}, testRegistry(), "original", 10, nil)
+	loop.LoopDetector = NewLoopDetector()
+
+	c := loop.Clone()
+	c.SystemPrompt = "changed"
+	if loop.SystemPrompt != "original" {
+		t.Fatal("clone must not share SystemPrompt")
+	}
+	if c.LoopDetector == loop.LoopDetector {
+		t.Fatal("clone must get its own LoopDetector")
+	}
+	if c.ToolRegistry != loop.ToolRegistry {
+		t.Fatal("clone should share the ToolRegistry")
+	}
+}
+
+func TestAgentLoop_PreTurnFn(t *testing.T) {
+	var received [][]map[string]interface{}
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		received = append(received, msgs)
+		callCount++
+		if callCount == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{
+				{"get_weather", `{"city":"Shanghai"}`},
+			}, ""), nil
+		}
+		return makeFinalResp("done"), nil
+	}
+
+	loop := NewAgentLoop(llm, testRegistry(), "sys", 10, nil)
+	loop.PreTurnFn = func(turn int, messages []map[string]interface{}) []map[string]interface{} {
+		return append(messages, map[string]interface{}{
+			"role":    "system",
+			"content": fmt.Sprintf("[now: turn %d]", turn),
+		})
+	}
+	result := loop.Run("weather?", nil, "")
+
+	if len(received) != 2 {
+		t.Fatalf("expected 2 LLM calls, got %d", len(received))
+	}
+	for i, msgs := range received {
+		want := fmt.Sprintf("[now: turn %d]", i+1)
+		last := msgs[len(msgs)-1]
+		if last["content"] != want {
+			t.Fatalf("call %d: expected last message %q, got %v", i+1, want, last["content"])
+		}
+		nowCount := 0
+		for _, m := range msgs {
+			if s, _ := m["content"].(string); strings.HasPrefix(s, "[now:") {
+				nowCount++
+			}
+		}
+		if nowCount != 1 {
+			t.Fatalf("call %d: expected exactly one [now] message, got %d", i+1, nowCount)
+		}
+	}
+	for _, m := range result.Messages {
+		if s, _ := m["content"].(string); strings.HasPrefix(s, "[now:") {
+			t.Fatal("per-turn messages must not be kept in history")
+		}
+	}
+}
+
+func TestAgentLoopResult_ToTranscript_TwoTools(t *testing.T) {
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		callCount++
+		if callCount == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{
+				{"get_weather", `{"city":"Paris"}`},
+				{"add", `{"a":1,"b":2}`},
+			}, ""), nil
+		}
+		return makeFinalResp("Paris is 25°C and 1+2=3."), nil
+	}
+	result := NewAgentLoop(llm, testRegistry(), "sys", 5, nil).Run("weather and sum?", nil, "")
+
+	got, _ := json.Marshal(result.ToTranscript(false))
+	want := `[` +
+		`{"content":"sys","role":"system"},` +
+		`{"content":"weather and sum?","role":"user"},` +
+		`{"content":null,"role":"assistant","tool_calls":[` +
+		`{"function":{"arguments":"{\"city\":\"Paris\"}","name":"get_weather"},"id":"call_0","type":"function"},` +
+		`{"function":{"arguments":"{\"a\":1,\"b\":2}","name":"add"},"id":"call_1","type":"function"}]},` +
+		`{"content":"Paris: 25°C","role":"tool","tool_call_id":"call_0"},` +
+		`{"content":"3","role":"tool","tool_call_id":"call_1"},` +
+		`{"content":"Paris is 25°C and 1+2=3.","role":"assistant"}]`
+	if string(got) != want {
+		t.Fatalf("unexpected transcript:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestAgentLoopResult_ToTranscript_DropInjected(t *testing.T) {
+	result := &AgentLoopResult{
+		StoppedReason: "max_turns",
+		Messages: []map[string]interface{}{
+			{"role": "system", "content": "sys"},
+			{"role": "user", "content": "hi"},
+			{"role": "assistant", "content": "checking", "tool_calls": []map[string]interface{}{}},
+			{"role": "system", "content": "[Warning] loop. Try a different approach."},
+		},
+	}
+	if all := result.ToTranscript(false); len(all) != 4 {
+		t.Fatalf("expected injected message kept, got %d messages", len(all))
+	}
+	clean := result.ToTranscript(true)
+	if len(clean) != 3 || clean[0]["role"] != "system" || clean[2]["content"] != "checking" {
+		t.Fatalf("expected only the injected system message dropped, got %v", clean)
+	}
+}
+
+// runToolAccessLoop has the LLM call get_weather, add and search once, then
+// finish; it returns the recorded tool calls.
+func runToolAccessLoop(configure func(*AgentLoop)) []ToolCallRecord {
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		callCount++
+		if callCount == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{
+				{"get_weather", `{"city":"Paris"}`},
+				{"add", `{"a":1,"b":2}`},
+				{"search", `{"query":"go"}`},
+			}, ""), nil
+		}
+		return makeFinalResp("done"), nil
+	}
+	loop := NewAgentLoop(llm, testRegistry(), "", 5, nil)
+	configure(loop)
+	return loop.Run("go", nil, "").Turns[0].ToolCalls
+}
+
+func TestAgentLoop_AllowedTools(t *testing.T) {
+	calls := runToolAccessLoop(func(l *AgentLoop) { l.AllowedTools = []string{"get_weather", "add"} })
+	if calls[0].Error != "" || calls[1].Error != "" {
+		t.Fatalf("allowed tools should run: %+v", calls[:2])
+	}
+	if calls[2].Error != "tool not allowed for this run: search" || calls[2].Result != "" {
+		t.Fatalf("expected search denied, got %+v", calls[2])
+	}
+}
+
+func TestAgentLoop_BlockedToolsWildcard(t *testing.T) {
+	calls := runToolAccessLoop(func(l *AgentLoop) {
+		l.AllowedTools = []string{"*"}
+		l.BlockedTools = []string{"get_*", "sea?ch"}
+	})
+	if calls[0].Error != "tool blocked for this run: get_weather" || calls[2].Error != "tool blocked for this run: search" {
+		t.Fatalf("expected wildcard blocks, got %+v", calls)
+	}
+	if calls[1].Error != "" || calls[1].Result != "3" {
+		t.Fatalf("expected add to run, got %+v", calls[1])
+	}
+}
+
+func TestAgentLoop_AllowedToolsComposeWithCapabilities(t *testing.T) {
+	calls := runToolAccessLoop(func(l *AgentLoop) {
+		l.AllowedTools = []string{"get_weather", "add"}
+		l.Capabilities = &AgentCapabilities{ToolManifest: []ToolSpec{{Name: "get_weather"}, {Name: "search"}}}
+	})
+	if calls[0].Error != "" {
+		t.Fatalf("tool allowed by both should run: %+v", calls[0])
+	}
+	if !strings.Contains(calls[1].Error, "capability manifest") {
+		t.Fatalf("expected add denied by capabilities, got %+v", calls[1])
+	}
+	if !strings.Contains(calls[2].Error, "not allowed for this run") {
+		t.Fatalf("expected search denied by AllowedTools, got %+v", calls[2])
+	}
+}
@@ -0,0 +1,73 @@
+package agentsdk
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ──────────────────────────────────────────────
+// Agent Metrics — per-loop aggregate counters
+// ──────────────────────────────────────────────
+
+// AgentMetrics aggregates counters across every run of an AgentLoop.
+// All methods are safe for concurrent use, so a single instance can be
+// shared by loops running on many goroutines and scraped at any time.
+//
+// Usage:
+//
+//	loop.Metrics = agentsdk.NewAgentMetrics()
+//	loop.Run("hi", nil, "")
+//	snap := loop.Metrics.Snapshot() // {"runs": 1, "turns": 1, ...}
+type AgentMetrics struct {
+	runs            atomic.Int64
+	turns           atomic.Int64
+	toolCalls       atomic.Int64
+	errors          atomic.Int64
+	guardrailBlocks atomic.Int64
+	latencyMs       atomic.Int64
+}
+
+// NewAgentMetrics creates an empty metrics collector.
+func NewAgentMetrics() *AgentMetrics {
+	return &AgentMetrics{}
+}
+
+// Snapshot returns the current counter values keyed by metric name:
+// runs, turns, tool_calls, errors, guardrail_blocks, total_latency_ms.
+func (m *AgentMetrics) Snapshot() map[string]int64 {
+	return map[string]int64{
+		"runs":             m.runs.Load(),
+		"turns":            m.turns.Load(),
+		"tool_calls":       m.toolCalls.Load(),
+		"errors":           m.errors.Load(),
+		"guardrail_blocks": m.guardrailBlocks.Load(),
+		"total_latency_ms": m.latencyMs.Load(),
+	}
+}
+
+// Reset zeroes all counters.
+func (m *AgentMetrics) Reset() {
+	m.runs.Store(0)
+	m.turns.Store(0)
+	m.toolCalls.Store(0)
+	m.errors.Store(0)
+	m.guardrailBlocks.Store(0)
+	m.latencyMs.Store(0)
+}
+
+// record folds one finished run into the counters.
+func (m *AgentMetrics) record(result *AgentLoopResult, elapsed time.Duration) {
+	m.runs.Add(1)
+	m.latencyMs.Add(elapsed.Milliseconds())
+	if result == nil {
+		return
+	}
+	m.turns.Add(int64(result.TotalTurns))
+	m.toolCalls.Add(int64(result.ToolCallsCount))
+	switch result.StoppedReason {
+	case "error", "rewrite_error":
+		m.errors.Add(1)
+	case "guardrail":
+		m.guardrailBlocks.Add(1)
+	}
+}
@@ -0,0 +1,95 @@
+package agentsdk
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestAgentMetrics_AccumulatesAcrossRuns(t *testing.T) {
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		callCount++
+		if callCount == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{
+				{"get_weather", `{"city":"Shanghai"}`},
+			}, ""), nil
+		}
+		return makeFinalResp("done"), nil
+	}
+
+	loop := NewAgentLoop(llm, testRegistry(), "", 10, nil)
+	loop.Metrics = NewAgentMetrics()
+	loop.Run("first", nil, "")  // 2 turns, 1 tool call
+	loop.Run("second", nil, "") // 1 turn
+
+	snap := loop.Metrics.Snapshot()
+	if snap["runs"] != 2 {
+		t.Fatalf("expected 2 runs, got %d", snap["runs"])
+	}
+	if snap["turns"] != 3 {
+		t.Fatalf("expected 3 turns, got %d", snap["turns"])
+	}
+	if snap["tool_calls"] != 1 {
+		t.Fatalf("expected 1 tool call, got %d", snap["tool_calls"])
+	}
+	if snap["errors"] != 0 || snap["guardrail_blocks"] != 0 {
+		t.Fatalf("unexpected failure counters: %v", snap)
+	}
+	if _, ok := snap["total_latency_ms"]; !ok {
+		t.Fatal("expected total_latency_ms in snapshot")
+	}
+}
+
+func TestAgentMetrics_ErrorsAndGuardrailBlocks(t *testing.T) {
+	metrics := NewAgentMetrics()
+
+	failing := NewAgentLoop(func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		return nil, fmt.Errorf("boom")
+	}, testRegistry(), "", 10, nil)
+	failing.Metrics = metrics
+	failing.Run("hi", nil, "")
+
+	mgr := NewGuardrailManager(false)
+	mgr.AddInput("deny", func(ctx *GuardrailContext) *GuardrailResultData {
+		return &GuardrailResultData{Passed: false, Reason: "no"}
+	})
+	guarded := NewAgentLoop(func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		return makeFinalResp("ok"), nil
+	}, testRegistry(), "", 10, nil)
+	guarded.Guardrails = mgr
+	guarded.Metrics = metrics
+	guarded.Run("hi", nil, "")
+
+	snap := metrics.Snapshot()
+	if snap["runs"] != 2 || snap["errors"] != 1 || snap["guardrail_blocks"] != 1 {
+		t.Fatalf("unexpected snapshot: %v", snap)
+	}
+
+	metrics.Reset()
+	if metrics.Snapshot()["runs"] != 0 {
+		t.Fatal("expected counters to reset")
+	}
+}
+
+func TestAgentMetrics_ConcurrentRuns(t *testing.T) {
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		return makeFinalResp("ok"), nil
+	}
+	loop := NewAgentLoop(llm, testRegistry(), "", 10, nil)
+	loop.Metrics = NewAgentMetrics()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loop.Run("hi", nil, "")
+		}()
+	}
+	wg.Wait()
+
+	if got := loop.Metrics.Snapshot()["runs"]; got != 20 {
+		t.Fatalf("expected 20 runs, got %d", got)
+	}
+}
@@ -0,0 +1,92 @@
+package agentsdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CanonicalJSON renders v as compact JSON with object keys sorted at every
+// level, numbers in a single normalized form (1, 1.0 and int64(1) all become
+// "1") and no HTML escaping, so logically equal values produce identical
+// strings. Use it to derive cache, dedupe and idempotency keys. Values that
+// cannot be marshaled yield "".
+func CanonicalJSON(v interface{}) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	writeCanonical(&sb, generic)
+	return sb.String()
+}
+
+func writeCanonical(sb *strings.Builder, v interface{}) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeCanonicalString(sb, k)
+			sb.WriteByte(':')
+			writeCanonical(sb, x[k])
+		}
+		sb.WriteByte('}')
+	case []interface{}:
+		sb.WriteByte('[')
+		for i, e := range x {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeCanonical(sb, e)
+		}
+		sb.WriteByte(']')
+	case json.Number:
+		sb.WriteString(canonicalNumber(x))
+	case string:
+		writeCanonicalString(sb, x)
+	case bool:
+		sb.WriteString(strconv.FormatBool(x))
+	default:
+		sb.WriteString("null")
+	}
+}
+
+func writeCanonicalString(sb *strings.Builder, s string) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	sb.Write(bytes.TrimRight(buf.Bytes(), "\n"))
+}
+
+// canonicalNumber formats integers exactly and other numbers via float64.
+func canonicalNumber(n json.Number) string {
+	if i, err := n.Int64(); err == nil {
+		return strconv.FormatInt(i, 10)
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return n.String()
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,52 @@
+package agentsdk
+
+import "testing"
+
+func TestCanonicalJSON_InsertionOrderIndependent(t *testing.T) {
+	a := map[string]interface{}{}
+	a["b"] = 2
+	a["a"] = map[string]interface{}{"y": []interface{}{1, "x"}, "x": true}
+	a["c"] = nil
+
+	b := map[string]interface{}{}
+	b["c"] = nil
+	b["a"] = map[string]interface{}{"x": true, "y": []interface{}{1.0, "x"}}
+	b["b"] = float64(2)
+
+	got, want := CanonicalJSON(a), `{"a":{"x":true,"y":[1,"x"]},"b":2,"c":null}`
+	if got != want {
+		t.Fatalf("unexpected canonical form:\n got %s\nwant %s", got, want)
+	}
+	if CanonicalJSON(b) != got {
+		t.Fatalf("logically equal maps differ:\n%s\n%s", CanonicalJSON(b), got)
+	}
+}
+
+func TestCanonicalJSON_NumbersAndStrings(t *testing.T) {
+	cases := map[string]interface{}{
+		`1000`:         1e3,
+		`-7`:           int64(-7),
+		`0.5`:          0.5,
+		`"<a&b>"`:      "<a&b>",
+		`{"k":"中文"}`:   map[string]string{"k": "中文"},
+		`[1,2.25,"3"]`: []interface{}{1, 2.25, "3"},
+		`{"A":1,"b":""}`: struct {
+			B string `json:"b"`
+			A int
+		}{A: 1},
+	}
+	for want, v := range cases {
+		if got := CanonicalJSON(v); got != want {
+			t.Errorf("CanonicalJSON(%#v) = %s, want %s", v, got, want)
+		}
+	}
+	if got := CanonicalJSON(func() {}); got != "" {
+		t.Fatalf("expected empty string for unmarshalable value, got %q", got)
+	}
+}
+
+func TestHashArgs_StableAcrossNumberTypes(t *testing.T) {
+	if hashArgs(map[string]interface{}{"n": 1, "q": "x"}) != hashArgs(map[string]interface{}{"q": "x", "n": 1.0}) {
+		t.Fatal("expected equal hashes for equal args")
+	}
+}
@@ -176,6 +176,15 @@ type KeyboardButton = zapry.KeyboardButton
 // BotCommand represents a bot command.
 type BotCommand = zapry.BotCommand
 
+// BotCommandScope describes which chats/users a command list applies to.
+type BotCommandScope = zapry.BotCommandScope
+
+// SetMyCommandsConfig sets the bot's commands for a scope/language.
+type SetMyCommandsConfig = zapry.SetMyCommandsConfig
+
+// GetMyCommandsConfig gets the bot's commands for a scope/language.
+type GetMyCommandsConfig = zapry.GetMyCommandsConfig
+
 // ─── Constructors ───
 
 // NewAgentAPI creates a new Bot API client with the default endpoint.
@@ -268,6 +277,45 @@ var NewCallback = zapry.NewCallback
 // NewSetMyCommands creates a command to set the bot's commands.
 var NewSetMyCommands = zapry.NewSetMyCommands
 
+// NewSetMyCommandsWithLanguage sets the bot's commands for a language code.
+var NewSetMyCommandsWithLanguage = zapry.NewSetMyCommandsWithLanguage
+
+// NewSetMyCommandsWithScope sets the bot's commands for a scope.
+var NewSetMyCommandsWithScope = zapry.NewSetMyCommandsWithScope
+
+// NewSetMyCommandsWithScopeAndLanguage sets the bot's commands for a scope and language code.
+var NewSetMyCommandsWithScopeAndLanguage = zapry.NewSetMyCommandsWithScopeAndLanguage
+
+// NewGetMyCommandsWithLanguage gets the bot's commands for a language code.
+var NewGetMyCommandsWithLanguage = zapry.NewGetMyCommandsWithLanguage
+
+// NewGetMyCommandsWithScope gets the bot's commands for a scope.
+var NewGetMyCommandsWithScope = zapry.NewGetMyCommandsWithScope
+
+// NewGetMyCommandsWithScopeAndLanguage gets the bot's commands for a scope and language code.
+var NewGetMyCommandsWithScopeAndLanguage = zapry.NewGetMyCommandsWithScopeAndLanguage
+
+// NewBotCommandScopeDefault is the default command scope.
+var NewBotCommandScopeDefault = zapry.NewBotCommandScopeDefault
+
+// NewBotCommandScopeAllPrivateChats covers all private chats.
+var NewBotCommandScopeAllPrivateChats = zapry.NewBotCommandScopeAllPrivateChats
+
+// NewBotCommandScopeAllGroupChats covers all group chats.
+var NewBotCommandScopeAllGroupChats = zapry.NewBotCommandScopeAllGroupChats
+
+// NewBotCommandScopeAllChatAdministrators covers all group chat administrators.
+var NewBotCommandScopeAllChatAdministrators = zapry.NewBotCommandScopeAllChatAdministrators
+
+// NewBotCommandScopeChat covers a specific chat.
+var NewBotCommandScopeChat = zapry.NewBotCommandScopeChat
+
+// NewBotCommandScopeChatAdministrators covers the administrators of a specific chat.
+var NewBotCommandScopeChatAdministrators = zapry.NewBotCommandScopeChatAdministrators
+
+// NewBotCommandScopeChatMember covers a specific member of a specific chat.
+var NewBotCommandScopeChatMember = zapry.NewBotCommandScopeChatMember
+
 // NewEditMessageText creates an edit message text config.
 var NewEditMessageText = zapry.NewEditMessageText
 
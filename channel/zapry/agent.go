@@ -40,6 +40,8 @@ type ZapryAgent struct {
 	onError     func(*AgentAPI, Update, error)
 	pipeline    *MiddlewarePipeline
 	pollingLock *pollingInstanceLock
+
+	skillDescriber func(skills []string) (*DerivedProfile, error)
 }
 
 // NewZapryAgent creates a high-level agent from configuration.
@@ -85,17 +87,50 @@ func (zb *ZapryAgent) AddCommand(name string, handler HandlerFunc) {
 	zb.Router.AddCommand(name, handler)
 }
 
+// AddCommandRegex registers a handler for commands whose name matches the pattern.
+func (zb *ZapryAgent) AddCommandRegex(pattern string, handler HandlerFunc) {
+	zb.Router.AddCommandRegex(pattern, handler)
+}
+
+// AddCommandContext registers a context handler for a bot command; see
+// Router.AddCommandContext.
+func (zb *ZapryAgent) AddCommandContext(name string, handler ContextHandlerFunc) {
+	zb.Router.AddCommandContext(name, handler)
+}
+
+// AddCommandRegexContext registers a context handler for commands whose name
+// matches the pattern; see Router.AddCommandRegexContext.
+func (zb *ZapryAgent) AddCommandRegexContext(pattern string, handler ContextHandlerFunc) {
+	zb.Router.AddCommandRegexContext(pattern, handler)
+}
+
 // AddCallbackQuery registers a handler for callback queries matching the pattern.
 func (zb *ZapryAgent) AddCallbackQuery(pattern string, handler HandlerFunc) {
 	zb.Router.AddCallbackQuery(pattern, handler)
 }
 
+// AddCallbackQueryContext registers a context handler for callback queries
+// matching the pattern; see Router.AddCallbackQueryContext.
+func (zb *ZapryAgent) AddCallbackQueryContext(pattern string, handler ContextHandlerFunc) {
+	zb.Router.AddCallbackQueryContext(pattern, handler)
+}
+
 // AddMessage registers a handler for text messages.
 // filter: "private", "group", or "all".
 func (zb *ZapryAgent) AddMessage(filter string, handler HandlerFunc) {
 	zb.Router.AddMessage(filter, handler)
 }
 
+// AddMyChatMember registers a handler for the bot being added to or removed from a chat.
+func (zb *ZapryAgent) AddMyChatMember(handler HandlerFunc) {
+	zb.Router.AddMyChatMember(handler)
+}
+
+// AddChatMember registers a handler for other members joining or leaving a chat.
+func (zb *ZapryAgent) AddChatMember(handler HandlerFunc) {
+	zb.Router.AddChatMember(handler)
+}
+
 // --- Middleware ---
 
 // Use registers a global middleware (onion model).
@@ -136,6 +171,16 @@ func (zb *ZapryAgent) SetProfileSource(source *ProfileSource) {
 	zb.Config.ProfileSource = source
 }
 
+// SetSkillDescriber installs a local profile generator. When set, Run calls
+// fn with the skill keys of Config.ProfileSource and sends the returned
+// profile alongside profileSource, so the platform does not need to derive
+// one itself. This is intended for self-hosted deployments without the
+// Zapry coordinator. If fn returns an error, registration falls back to
+// server-side derivation.
+func (zb *ZapryAgent) SetSkillDescriber(fn func(skills []string) (*DerivedProfile, error)) {
+	zb.skillDescriber = fn
+}
+
 type profileRegisterResponse struct {
 	OK                       bool `json:"ok"`
 	UnsupportedProfileSource bool `json:"unsupported_profile_source"`
@@ -158,12 +203,17 @@ func (zb *ZapryAgent) registerProfile() {
 
 	url := fmt.Sprintf("%s%s/setMyProfile", normalizeZapryAPIBaseURL(zb.Config.APIBaseURL), zb.Config.BotToken)
 
-	log.Printf("[ZapryAgent][ProfileTrace] stage=request mode=profileSource snapshot=%s sourceSkills=%d",
-		strings.TrimSpace(zb.Config.ProfileSource.SnapshotID), len(zb.Config.ProfileSource.Skills))
-
+	mode := "profileSource"
 	extendedPayload := map[string]interface{}{
 		"profileSource": zb.Config.ProfileSource,
 	}
+	if profile := zb.describeSkillsLocally(); profile != nil {
+		mode = "local"
+		extendedPayload["profile"] = profile
+	}
+
+	log.Printf("[ZapryAgent][ProfileTrace] stage=request mode=%s snapshot=%s sourceSkills=%d",
+		mode, strings.TrimSpace(zb.Config.ProfileSource.SnapshotID), len(zb.Config.ProfileSource.Skills))
 	status, body, err := zb.postProfilePayload(url, extendedPayload)
 	if err != nil {
 		log.Printf("[ZapryAgent] Failed to register extended profileSource: %v", err)
@@ -178,7 +228,8 @@ func (zb *ZapryAgent) registerProfile() {
 		derivedSummary = strings.TrimSpace(parsed.Derived.Profile.Summary)
 		derivedSkills = len(parsed.Derived.Profile.Skills)
 	}
-	log.Printf("[ZapryAgent][ProfileTrace] stage=response mode=profileSource status=%d unsupported=%v derivedSnapshot=%s derivedName=%q derivedSummary=%q derivedSkills=%d body=%s",
+	log.Printf("[ZapryAgent][ProfileTrace] stage=response mode=%s status=%d unsupported=%v derivedSnapshot=%s derivedName=%q derivedSummary=%q derivedSkills=%d body=%s",
+		mode,
 		status,
 		parsed.UnsupportedProfileSource,
 		strings.TrimSpace(parsed.Derived.SnapshotID),
@@ -193,6 +244,20 @@ func (zb *ZapryAgent) registerProfile() {
 	log.Printf("[ZapryAgent] Extended profileSource registration returned status %d body=%s", status, strings.TrimSpace(string(body)))
 }
 
+// describeSkillsLocally runs the skill describer, if any. It returns nil when
+// no describer is set or it fails, leaving derivation to the server.
+func (zb *ZapryAgent) describeSkillsLocally() *DerivedProfile {
+	if zb.skillDescriber == nil {
+		return nil
+	}
+	profile, err := zb.skillDescriber(SkillKeysFromProfileSource(zb.Config.ProfileSource))
+	if err != nil {
+		log.Printf("[ZapryAgent] Skill describer failed, falling back to server derivation: %v", err)
+		return nil
+	}
+	return profile
+}
+
 func (zb *ZapryAgent) postProfilePayload(url string, payload interface{}) (int, []byte, error) {
 	body, err := json.Marshal(payload)
 	if err != nil {
@@ -348,10 +413,19 @@ func (zb *ZapryAgent) runWebhook() {
 
 	// Listen on the same path
 	listenPath := "/" + webhookPath
+	listenAddr := fmt.Sprintf("%s:%d", zb.Config.WebhookHost, zb.Config.WebhookPort)
+
+	if zb.Config.WebhookInlineReplies {
+		http.HandleFunc(listenPath, zb.ServeWebhook)
+		log.Printf("[ZapryAgent] Webhook listening on %s (path: %s, inline replies)", listenAddr, listenPath)
+		if err := http.ListenAndServe(listenAddr, nil); err != nil {
+			log.Fatalf("[ZapryAgent] Webhook server error: %v", err)
+		}
+		return
+	}
 
 	updates := zb.Bot.ListenForWebhook(listenPath)
 
-	listenAddr := fmt.Sprintf("%s:%d", zb.Config.WebhookHost, zb.Config.WebhookPort)
 	log.Printf("[ZapryAgent] Webhook listening on %s (path: %s)", listenAddr, listenPath)
 
 	go func() {
@@ -365,8 +439,51 @@ func (zb *ZapryAgent) runWebhook() {
 	}
 }
 
-// handleUpdate processes a single update with panic recovery.
+// ServeWebhook handles one webhook request synchronously. A reply set via
+// MiddlewareContext.RespondInline is written into the HTTP response body
+// (see WriteToHTTPResponse); otherwise an empty 200 is returned once the
+// update has been handled.
+func (zb *ZapryAgent) ServeWebhook(w http.ResponseWriter, r *http.Request) {
+	update, err := zb.Bot.HandleUpdate(r)
+	if err != nil {
+		errMsg, _ := json.Marshal(map[string]string{"error": err.Error()})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write(errMsg)
+		return
+	}
+
+	inline := zb.processUpdate(*update)
+	if inline == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err := WriteToHTTPResponse(w, inline); err != nil {
+		log.Printf("[ZapryAgent] inline webhook response failed: %v", err)
+		if zb.onError != nil {
+			zb.onError(zb.Bot, *update, err)
+		}
+	}
+}
+
+// handleUpdate processes a single update; an inline response set by
+// middleware is sent as a regular API request.
 func (zb *ZapryAgent) handleUpdate(update Update) {
+	inline := zb.processUpdate(update)
+	if inline == nil {
+		return
+	}
+	if _, err := zb.Bot.Request(inline); err != nil {
+		log.Printf("[ZapryAgent] inline response send failed: %v", err)
+		if zb.onError != nil {
+			zb.onError(zb.Bot, update, err)
+		}
+	}
+}
+
+// processUpdate runs the middleware pipeline and router for update with
+// panic recovery, returning the reply set via RespondInline, if any.
+func (zb *ZapryAgent) processUpdate(update Update) (inline Chattable) {
 	defer func() {
 		if r := recover(); r != nil {
 			err := fmt.Errorf("panic in handler: %v", r)
@@ -393,25 +510,35 @@ func (zb *ZapryAgent) handleUpdate(update Update) {
 	handled := false
 	middlewareHandled := false
 
-	// Run through middleware pipeline → Router.Dispatch as core
+	// Run through middleware pipeline → Router.DispatchContext as core. The
+	// context is built even without middleware so handlers can read route
+	// captures from it.
+	ctx := &MiddlewareContext{
+		Update: update,
+		Agent:  zb.Bot,
+		Extra:  make(map[string]interface{}),
+	}
+	zb.pipeline.Execute(ctx, func() {
+		handled = zb.Router.DispatchContext(ctx)
+		ctx.Handled = handled
+	})
 	if zb.pipeline.Len() > 0 {
-		ctx := &MiddlewareContext{
-			Update: update,
-			Agent:  zb.Bot,
-			Extra:  make(map[string]interface{}),
-		}
-		zb.pipeline.Execute(ctx, func() {
-			handled = zb.Router.Dispatch(zb.Bot, update)
-			ctx.Handled = handled
-		})
 		middlewareHandled = ctx.Handled
-	} else {
-		handled = zb.Router.Dispatch(zb.Bot, update)
+	}
+	inline = ctx.InlineResponse()
+	if ctx.Aborted() {
+		if trace {
+			log.Printf("[RouteTrace] aborted by middleware err=%v", ctx.Err())
+		}
+		if ctx.Err() != nil && zb.onError != nil {
+			zb.onError(zb.Bot, update, ctx.Err())
+		}
 	}
 
 	if trace {
 		log.Printf("[RouteTrace] done handled=%t middleware_handled=%t %s", handled, middlewareHandled, summarizeUpdateForTrace(update))
 	}
+	return inline
 }
 
 func (zb *ZapryAgent) routeTraceEnabled() bool {
@@ -2,6 +2,7 @@ package zapry
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -139,3 +140,72 @@ func TestNormalizeZapryAPIBaseURL(t *testing.T) {
 		}
 	}
 }
+
+func TestRegisterProfileUsesSkillDescriber(t *testing.T) {
+	requests := make([]capturedProfileRequest, 0, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request body failed: %v", err)
+		}
+		requests = append(requests, capturedProfileRequest{Path: r.URL.Path, Body: body})
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	agent := newTestZapryAgentForProfile(server.URL, buildTestProfileSource())
+	agent.Bot.Client = server.Client()
+
+	var describedSkills []string
+	agent.SetSkillDescriber(func(skills []string) (*DerivedProfile, error) {
+		describedSkills = skills
+		return &DerivedProfile{Name: "林晚晴", Summary: "塔罗占卜师", Skills: skills}, nil
+	})
+	agent.registerProfile()
+
+	if len(describedSkills) != 1 || describedSkills[0] != "tarot-reading" {
+		t.Fatalf("describer should receive skill keys, got %v", describedSkills)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected exactly one request, got %d", len(requests))
+	}
+	profile, ok := requests[0].Body["profile"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("request should carry the locally described profile, got %v", requests[0].Body)
+	}
+	if profile["name"] != "林晚晴" || profile["summary"] != "塔罗占卜师" {
+		t.Fatalf("unexpected profile payload: %v", profile)
+	}
+	if _, ok := requests[0].Body["profileSource"]; !ok {
+		t.Fatalf("request should still carry profileSource")
+	}
+}
+
+func TestRegisterProfileSkillDescriberErrorFallsBack(t *testing.T) {
+	requests := make([]capturedProfileRequest, 0, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, capturedProfileRequest{Path: r.URL.Path, Body: body})
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	agent := newTestZapryAgentForProfile(server.URL, buildTestProfileSource())
+	agent.Bot.Client = server.Client()
+	agent.SetSkillDescriber(func(skills []string) (*DerivedProfile, error) {
+		return nil, errors.New("offline")
+	})
+	agent.registerProfile()
+
+	if len(requests) != 1 {
+		t.Fatalf("expected exactly one request, got %d", len(requests))
+	}
+	if _, ok := requests[0].Body["profile"]; ok {
+		t.Fatalf("failed describer should leave derivation to the server")
+	}
+}
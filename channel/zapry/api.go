@@ -2,6 +2,7 @@
 package zapry
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -26,8 +27,11 @@ type AgentAPI struct {
 	Debug  bool   `json:"debug"`
 	Buffer int    `json:"buffer"`
 
-	Self            User       `json:"-"`
-	Client          HTTPClient `json:"-"`
+	Self   User       `json:"-"`
+	Client HTTPClient `json:"-"`
+	// UploadTimeout bounds each multipart file upload; 0 = no limit beyond
+	// the caller's context.
+	UploadTimeout   time.Duration `json:"-"`
 	shutdownChannel chan interface{}
 
 	apiEndpoint string
@@ -110,6 +114,11 @@ func buildParams(in Params) url.Values {
 
 // MakeRequest makes a request to a specific endpoint with our token.
 func (bot *AgentAPI) MakeRequest(endpoint string, params Params) (*APIResponse, error) {
+	return bot.MakeRequestWithContext(context.Background(), endpoint, params)
+}
+
+// MakeRequestWithContext is MakeRequest bound to ctx.
+func (bot *AgentAPI) MakeRequestWithContext(ctx context.Context, endpoint string, params Params) (*APIResponse, error) {
 	// Zapry compatibility: outgoing request normalization hook
 	if bot.zapryCompat {
 		NormalizeSendParams(params)
@@ -122,7 +131,7 @@ func (bot *AgentAPI) MakeRequest(endpoint string, params Params) (*APIResponse,
 	method := fmt.Sprintf(bot.apiEndpoint, bot.Token, endpoint)
 
 	values := buildParams(params)
-	req, err := http.NewRequest("POST", method, strings.NewReader(values.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", method, strings.NewReader(values.Encode()))
 	if err != nil {
 		return &APIResponse{}, err
 	}
@@ -145,17 +154,7 @@ func (bot *AgentAPI) MakeRequest(endpoint string, params Params) (*APIResponse,
 	}
 
 	if !apiResp.Ok {
-		var parameters ResponseParameters
-
-		if apiResp.Parameters != nil {
-			parameters = *apiResp.Parameters
-		}
-
-		return &apiResp, &Error{
-			Code:               apiResp.ErrorCode,
-			Message:            apiResp.Description,
-			ResponseParameters: parameters,
-		}
+		return &apiResp, newAPIError(&apiResp)
 	}
 
 	return &apiResp, nil
@@ -186,14 +185,33 @@ func (bot *AgentAPI) decodeAPIResponse(responseBody io.Reader, resp *APIResponse
 
 // UploadFiles makes a request to the API with files.
 func (bot *AgentAPI) UploadFiles(endpoint string, params Params, files []RequestFile) (*APIResponse, error) {
+	return bot.UploadFilesWithContext(context.Background(), endpoint, params, files)
+}
+
+// UploadFilesWithContext is UploadFiles bound to ctx. Cancelling ctx (or
+// hitting UploadTimeout) aborts the upload mid-stream instead of after the
+// whole body has been sent.
+func (bot *AgentAPI) UploadFilesWithContext(ctx context.Context, endpoint string, params Params, files []RequestFile) (*APIResponse, error) {
 	// Zapry compatibility: outgoing request normalization hook
 	if bot.zapryCompat {
 		NormalizeSendParams(params)
 	}
 
+	if bot.UploadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, bot.UploadTimeout)
+		defer cancel()
+	}
+
 	r, w := io.Pipe()
 	m := multipart.NewWriter(w)
 
+	// Unblock the writer goroutine as soon as ctx is done.
+	stop := context.AfterFunc(ctx, func() {
+		r.CloseWithError(ctx.Err())
+	})
+	defer stop()
+
 	// This code modified from the very helpful @HirbodBehnam
 	// https://github.com/imbot-io/imbot-sdk-go/issues/354#issuecomment-663856473
 	go func() {
@@ -221,7 +239,7 @@ func (bot *AgentAPI) UploadFiles(endpoint string, params Params, files []Request
 					return
 				}
 
-				if _, err := io.Copy(part, reader); err != nil {
+				if _, err := io.Copy(part, &contextReader{ctx: ctx, r: reader}); err != nil {
 					w.CloseWithError(err)
 					return
 				}
@@ -249,7 +267,7 @@ func (bot *AgentAPI) UploadFiles(endpoint string, params Params, files []Request
 
 	method := fmt.Sprintf(bot.apiEndpoint, bot.Token, endpoint)
 
-	req, err := http.NewRequest("POST", method, r)
+	req, err := http.NewRequestWithContext(ctx, "POST", method, r)
 	if err != nil {
 		return nil, err
 	}
@@ -258,6 +276,9 @@ func (bot *AgentAPI) UploadFiles(endpoint string, params Params, files []Request
 
 	resp, err := bot.Client.Do(req)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -273,16 +294,7 @@ func (bot *AgentAPI) UploadFiles(endpoint string, params Params, files []Request
 	}
 
 	if !apiResp.Ok {
-		var parameters ResponseParameters
-
-		if apiResp.Parameters != nil {
-			parameters = *apiResp.Parameters
-		}
-
-		return &apiResp, &Error{
-			Message:            apiResp.Description,
-			ResponseParameters: parameters,
-		}
+		return &apiResp, newAPIError(&apiResp)
 	}
 
 	return &apiResp, nil
@@ -335,8 +347,28 @@ func hasFilesNeedingUpload(files []RequestFile) bool {
 	return false
 }
 
+// contextReader fails reads once ctx is done, so copying from a slow source
+// stops between chunks after cancellation.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
 // Request sends a Chattable to de-im, and returns the APIResponse.
 func (bot *AgentAPI) Request(c Chattable) (*APIResponse, error) {
+	return bot.RequestWithContext(context.Background(), c)
+}
+
+// RequestWithContext is Request bound to ctx; cancelling ctx aborts the
+// HTTP request, including in-flight file uploads.
+func (bot *AgentAPI) RequestWithContext(ctx context.Context, c Chattable) (*APIResponse, error) {
 	params, err := c.params()
 	if err != nil {
 		return nil, err
@@ -348,7 +380,7 @@ func (bot *AgentAPI) Request(c Chattable) (*APIResponse, error) {
 		// If we have files that need to be uploaded, we should delegate the
 		// request to UploadFile.
 		if hasFilesNeedingUpload(files) {
-			return bot.UploadFiles(t.method(), params, files)
+			return bot.UploadFilesWithContext(ctx, t.method(), params, files)
 		}
 
 		// However, if there are no files to be uploaded, there's likely things
@@ -357,7 +389,7 @@ func (bot *AgentAPI) Request(c Chattable) (*APIResponse, error) {
 			params[file.Name] = file.Data.SendData()
 		}
 	}
-	return bot.MakeRequest(c.method(), params)
+	return bot.MakeRequestWithContext(ctx, c.method(), params)
 }
 
 // Send will send a Chattable item to de-im and provides the
@@ -387,6 +419,53 @@ func (bot *AgentAPI) SendMediaGroup(config MediaGroupConfig) ([]Message, error)
 	return messages, err
 }
 
+// SendResult is the outcome of one item in a SendBatch call.
+type SendResult struct {
+	Index   int     // position of the item in the batch
+	Message Message // the sent message; zero if Err is set
+	Err     error
+}
+
+// SendBatchOptions controls a single SendBatch call.
+type SendBatchOptions struct {
+	// StopOnError ends the batch at the first failed item instead of
+	// sending the rest.
+	StopOnError bool
+}
+
+// SendBatch sends msgs one after another, in order, and returns one result
+// per item attempted. With opts.StopOnError the batch ends at the first
+// failure and the returned error is that item's; otherwise every item is
+// tried and the error joins all item failures. Cancelling ctx stops the
+// batch before the next item.
+func (bot *AgentAPI) SendBatch(ctx context.Context, msgs []Chattable, opts ...SendBatchOptions) ([]SendResult, error) {
+	var opt SendBatchOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	results := make([]SendResult, 0, len(msgs))
+	var errs []error
+	for i, c := range msgs {
+		if err := ctx.Err(); err != nil {
+			return results, errors.Join(append(errs, err)...)
+		}
+		result := SendResult{Index: i}
+		resp, err := bot.RequestWithContext(ctx, c)
+		if err == nil {
+			err = json.Unmarshal(resp.Result, &result.Message)
+		}
+		if err != nil {
+			result.Err = err
+			errs = append(errs, fmt.Errorf("send batch item %d: %w", i, err))
+		}
+		results = append(results, result)
+		if err != nil && opt.StopOnError {
+			break
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
 // GetUserProfilePhotos gets a user's profile photos.
 //
 // It requires UserID.
@@ -0,0 +1,73 @@
+package zapry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func newAPIErrorTestBot(t *testing.T, body string) *AgentAPI {
+	return newTestBot(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+}
+
+func TestRequest_APIErrorForbidden(t *testing.T) {
+	bot := newAPIErrorTestBot(t, `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`)
+
+	_, err := bot.Send(NewMessage("chat-1", "hi"))
+	apiErr, ok := IsAPIError(err)
+	if !ok {
+		t.Fatalf("expected *APIError, got %T: %v", err, err)
+	}
+	if apiErr.Code != 403 || apiErr.Description != "Forbidden: bot was blocked by the user" {
+		t.Fatalf("unexpected error fields: %+v", apiErr)
+	}
+	if apiErr.ResponseParameters != (ResponseParameters{}) {
+		t.Fatalf("expected empty parameters, got %+v", apiErr.ResponseParameters)
+	}
+	if err.Error() != apiErr.Description {
+		t.Fatalf("expected message %q, got %q", apiErr.Description, err.Error())
+	}
+}
+
+func TestRequest_APIErrorTooManyRequests(t *testing.T) {
+	bot := newAPIErrorTestBot(t, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 7",`+
+		`"parameters":{"retry_after":7,"migrate_to_chat_id":"chat-2"}}`)
+
+	_, err := bot.Request(NewMessage("chat-1", "hi"))
+	apiErr, ok := IsAPIError(err)
+	if !ok {
+		t.Fatalf("expected *APIError, got %T: %v", err, err)
+	}
+	if apiErr.Code != 429 || apiErr.RetryAfter != 7 || apiErr.MigrateToChatID != "chat-2" {
+		t.Fatalf("unexpected error fields: %+v", apiErr)
+	}
+}
+
+func TestError_AliasKeepsLegacyFields(t *testing.T) {
+	bot := newAPIErrorTestBot(t, `{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":3}}`)
+
+	_, err := bot.Request(NewMessage("chat-1", "hi"))
+	var legacy *Error
+	if !errors.As(err, &legacy) {
+		t.Fatalf("expected *Error, got %T", err)
+	}
+	if legacy.Message != "Too Many Requests" || legacy.RetryAfter != 3 || legacy.Code != 429 {
+		t.Fatalf("legacy fields not populated: %+v", legacy)
+	}
+}
+
+func TestIsAPIError(t *testing.T) {
+	wrapped := fmt.Errorf("send: %w", &APIError{Code: 400, Description: "Bad Request"})
+	if apiErr, ok := IsAPIError(wrapped); !ok || apiErr.Code != 400 {
+		t.Fatalf("expected wrapped APIError, got %v, %v", apiErr, ok)
+	}
+	if apiErr, ok := IsAPIError(errors.New("network down")); ok || apiErr != nil {
+		t.Fatalf("expected no APIError, got %v", apiErr)
+	}
+	if _, ok := IsAPIError(nil); ok {
+		t.Fatal("expected nil error not to be an APIError")
+	}
+}
@@ -0,0 +1,56 @@
+package zapry
+
+import (
+	"testing"
+)
+
+func TestSetMyCommands_ScopeAndLanguage(t *testing.T) {
+	cfg := NewSetMyCommandsWithScopeAndLanguage(
+		NewBotCommandScopeChatAdministrators("123"),
+		"zh",
+		BotCommand{Command: "ban", Description: "封禁用户"},
+	)
+	params, err := cfg.params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEq(t, params["scope"], `{"type":"chat_administrators","chat_id":"123"}`)
+	assertEq(t, params["language_code"], "zh")
+	assertEq(t, params["commands"], `[{"command":"ban","description":"封禁用户"}]`)
+}
+
+func TestSetMyCommands_LanguageOnly(t *testing.T) {
+	cfg := NewSetMyCommandsWithLanguage("en", BotCommand{Command: "help", Description: "Show help"})
+	params, err := cfg.params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := params["scope"]; ok {
+		t.Fatal("scope should be omitted when not set")
+	}
+	assertEq(t, params["language_code"], "en")
+}
+
+func TestGetMyCommands_ScopeAndLanguage(t *testing.T) {
+	params, err := NewGetMyCommandsWithScopeAndLanguage(NewBotCommandScopeAllPrivateChats(), "ru").params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEq(t, params["scope"], `{"type":"all_private_chats"}`)
+	assertEq(t, params["language_code"], "ru")
+
+	params, err = NewGetMyCommandsWithLanguage("de").params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertLen(t, params, 1)
+	assertEq(t, params["language_code"], "de")
+}
+
+func TestBotCommandScopeChatMember(t *testing.T) {
+	params, err := NewSetMyCommandsWithScope(NewBotCommandScopeChatMember("g1", "u2")).params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEq(t, params["scope"], `{"type":"chat_member","chat_id":"g1","user_id":"u2"}`)
+}
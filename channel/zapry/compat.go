@@ -1,6 +1,7 @@
 package zapry
 
 import (
+	"fmt"
 	"log"
 	"strings"
 )
@@ -118,12 +119,63 @@ func normalizeChat(chat *Chat, from *User) {
 	}
 }
 
-// NormalizeSendParams is kept as a send-side compatibility hook.
-//
-// Zapry now supports Markdown-related params (e.g. parse_mode), so this is
-// intentionally a no-op.
+// NormalizeSendParams is the send-side compatibility hook, called by
+// MakeRequest/UploadFiles when Zapry compat mode is on (see SetZapryCompat).
+// It applies NormalizeOutgoingParams and logs every change it made.
 func NormalizeSendParams(params Params) {
-	_ = params
+	for _, change := range NormalizeOutgoingParams(params) {
+		log.Printf("[Compat] Outgoing %s", change)
+	}
+}
+
+// NormalizeOutgoingParams maps Telegram-style send params to the Zapry
+// dialect in place, so the same handler code works on both platforms.
+// It returns a human-readable description of each change (empty if none).
+//
+// Transforms applied:
+//   - parse_mode case variants ("markdown", "html", ...) → canonical
+//     "Markdown" / "MarkdownV2" / "HTML"; an unknown or blank mode is removed
+//   - empty reply_markup payloads ("null", "{}") → removed
+func NormalizeOutgoingParams(params Params) []string {
+	if params == nil {
+		return nil
+	}
+	var changes []string
+
+	if mode, ok := params["parse_mode"]; ok {
+		switch canonical := canonicalParseMode(mode); {
+		case canonical == "":
+			delete(params, "parse_mode")
+			changes = append(changes, fmt.Sprintf("parse_mode: removed unsupported %q", mode))
+		case canonical != mode:
+			params["parse_mode"] = canonical
+			changes = append(changes, fmt.Sprintf("parse_mode: %s → %s", mode, canonical))
+		}
+	}
+
+	if markup, ok := params["reply_markup"]; ok {
+		switch strings.TrimSpace(markup) {
+		case "", "null", "{}":
+			delete(params, "reply_markup")
+			changes = append(changes, fmt.Sprintf("reply_markup: removed empty %q", markup))
+		}
+	}
+
+	return changes
+}
+
+// canonicalParseMode returns the canonical spelling of a parse mode, or ""
+// if it is not one Zapry understands.
+func canonicalParseMode(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "markdown":
+		return ModeMarkdown
+	case "markdownv2":
+		return ModeMarkdownV2
+	case "html":
+		return ModeHTML
+	}
+	return ""
 }
 
 // isNumericID checks if a string looks like a numeric ID.
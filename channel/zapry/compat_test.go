@@ -0,0 +1,60 @@
+package zapry
+
+import (
+	"testing"
+)
+
+func TestNormalizeOutgoingParams_MessageWithMarkup(t *testing.T) {
+	msg := NewMessage("123", "*hi*")
+	msg.ParseMode = "markdown"
+	msg.ReplyMarkup = NewInlineKeyboardMarkup(
+		NewInlineKeyboardRow(NewInlineKeyboardButtonData("OK", "ok")),
+	)
+
+	telegram, err := msg.params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	zapry, err := msg.params()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := NormalizeOutgoingParams(zapry)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change, got %v", changes)
+	}
+
+	assertEq(t, telegram["parse_mode"], "markdown")
+	assertEq(t, zapry["parse_mode"], ModeMarkdown)
+	assertEq(t, zapry["reply_markup"], telegram["reply_markup"])
+	assertEq(t, zapry["text"], telegram["text"])
+	assertLen(t, zapry, len(telegram))
+}
+
+func TestNormalizeOutgoingParams_StripsUnsupported(t *testing.T) {
+	params := Params{
+		"chat_id":      "123",
+		"parse_mode":   "BBCode",
+		"reply_markup": "null",
+	}
+	changes := NormalizeOutgoingParams(params)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %v", changes)
+	}
+	if _, ok := params["parse_mode"]; ok {
+		t.Fatal("unsupported parse_mode should be removed")
+	}
+	if _, ok := params["reply_markup"]; ok {
+		t.Fatal("empty reply_markup should be removed")
+	}
+	assertLen(t, params, 1)
+}
+
+func TestNormalizeOutgoingParams_CanonicalUntouched(t *testing.T) {
+	params := Params{"parse_mode": ModeHTML}
+	if changes := NormalizeOutgoingParams(params); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+	assertEq(t, params["parse_mode"], ModeHTML)
+}
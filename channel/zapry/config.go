@@ -29,6 +29,10 @@ type AgentConfig struct {
 	WebhookPort int
 	// WebhookSecret is the secret token for webhook verification
 	WebhookSecret string
+	// WebhookInlineReplies handles each webhook request synchronously so a
+	// reply set via MiddlewareContext.RespondInline is returned in the HTTP
+	// response body (one round-trip instead of two).
+	WebhookInlineReplies bool
 	// Debug enables verbose logging
 	Debug bool
 	// LogFile path for file logging (empty = stdout only)
@@ -115,6 +119,8 @@ func NewAgentConfigFromEnv() (*AgentConfig, error) {
 		WebhookSecret: getEnv("WEBHOOK_SECRET_TOKEN", ""),
 		Debug:         toBool(getEnv("DEBUG", "false")),
 		LogFile:       getEnv("LOG_FILE", ""),
+
+		WebhookInlineReplies: toBool(getEnv("WEBHOOK_INLINE_REPLIES", "false")),
 	}, nil
 }
 
@@ -2224,7 +2224,8 @@ func (config DeleteChatStickerSetConfig) params() (Params, error) {
 
 // MediaGroupConfig allows you to send a group of media.
 //
-// Media consist of InputMedia items (InputMediaPhoto, InputMediaVideo).
+// Media consist of InputMedia items (InputMediaPhoto, InputMediaVideo,
+// InputMediaAudio, InputMediaDocument). See Validate for the grouping rules.
 type MediaGroupConfig struct {
 	ChatID          string
 	ChannelUsername string
@@ -2238,7 +2239,84 @@ func (config MediaGroupConfig) method() string {
 	return "sendMediaGroup"
 }
 
+// Media group size limits enforced by the platform.
+const (
+	MinMediaGroupSize = 2
+	MaxMediaGroupSize = 10
+)
+
+// Validate checks the media group against the platform rules: it must hold
+// between MinMediaGroupSize and MaxMediaGroupSize items, photos and videos
+// may be mixed freely, but audio and documents can only be grouped with
+// items of the same type. Animations cannot be sent in a media group.
+//
+// Errors wrap ErrInvalidMediaGroup.
+func (config MediaGroupConfig) Validate() error {
+	n := len(config.Media)
+	if n < MinMediaGroupSize || n > MaxMediaGroupSize {
+		return fmt.Errorf("%w: has %d items, must have %d-%d",
+			ErrInvalidMediaGroup, n, MinMediaGroupSize, MaxMediaGroupSize)
+	}
+
+	first := ""
+	for i, item := range config.Media {
+		kind := mediaGroupItemType(item)
+		switch kind {
+		case "photo", "video", "audio", "document":
+		case "animation":
+			return fmt.Errorf("%w: item %d is an animation, which cannot be grouped",
+				ErrInvalidMediaGroup, i)
+		default:
+			return fmt.Errorf("%w: item %d has unsupported type %T",
+				ErrInvalidMediaGroup, i, item)
+		}
+
+		if i == 0 {
+			first = kind
+			continue
+		}
+		if mediaGroupClass(kind) != mediaGroupClass(first) {
+			return fmt.Errorf("%w: item %d (%s) cannot be mixed with %s",
+				ErrInvalidMediaGroup, i, kind, first)
+		}
+	}
+
+	return nil
+}
+
+// mediaGroupItemType returns the media type of an InputMedia item, or "" if
+// item is not one.
+func mediaGroupItemType(item interface{}) string {
+	switch item.(type) {
+	case InputMediaPhoto:
+		return "photo"
+	case InputMediaVideo:
+		return "video"
+	case InputMediaAnimation:
+		return "animation"
+	case InputMediaAudio:
+		return "audio"
+	case InputMediaDocument:
+		return "document"
+	}
+
+	return ""
+}
+
+// mediaGroupClass groups media types that may share a media group.
+func mediaGroupClass(kind string) string {
+	if kind == "photo" || kind == "video" {
+		return "visual"
+	}
+
+	return kind
+}
+
 func (config MediaGroupConfig) params() (Params, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
 	params := make(Params)
 
 	params.AddFirstValid("chat_id", config.ChatID, config.ChannelUsername)
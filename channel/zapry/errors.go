@@ -11,4 +11,7 @@ var (
 	ErrInvalidAPIBaseURL  = errors.New("invalid API base url")
 	ErrInvalidWebhookURL  = errors.New("invalid webhook url")
 	ErrWebhookURLRequired = errors.New("webhook url is required for webhook mode")
+
+	// Media group validation errors.
+	ErrInvalidMediaGroup = errors.New("invalid media group")
 )
@@ -906,6 +906,12 @@ func NewBotCommandScopeChatMember(chatID string, userID string) BotCommandScope
 	}
 }
 
+// NewGetMyCommandsWithLanguage allows you to get the registered commands for a
+// given language code.
+func NewGetMyCommandsWithLanguage(languageCode string) GetMyCommandsConfig {
+	return GetMyCommandsConfig{LanguageCode: languageCode}
+}
+
 // NewGetMyCommandsWithScope allows you to set the registered commands for a
 // given scope.
 func NewGetMyCommandsWithScope(scope BotCommandScope) GetMyCommandsConfig {
@@ -923,6 +929,12 @@ func NewSetMyCommands(commands ...BotCommand) SetMyCommandsConfig {
 	return SetMyCommandsConfig{Commands: commands}
 }
 
+// NewSetMyCommandsWithLanguage allows you to set the registered commands shown
+// to users with the given language code (e.g. "en", "zh").
+func NewSetMyCommandsWithLanguage(languageCode string, commands ...BotCommand) SetMyCommandsConfig {
+	return SetMyCommandsConfig{Commands: commands, LanguageCode: languageCode}
+}
+
 // NewSetMyCommandsWithScope allows you to set the registered commands for a given scope.
 func NewSetMyCommandsWithScope(scope BotCommandScope, commands ...BotCommand) SetMyCommandsConfig {
 	return SetMyCommandsConfig{Commands: commands, Scope: &scope}
@@ -0,0 +1,107 @@
+package zapry
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func mediaGroupPhotos(n int) []interface{} {
+	media := make([]interface{}, n)
+	for i := range media {
+		media[i] = NewInputMediaPhoto(FileID("photo"))
+	}
+	return media
+}
+
+func TestMediaGroupValidate_TooFew(t *testing.T) {
+	for _, n := range []int{0, 1} {
+		err := NewMediaGroup("chat-1", mediaGroupPhotos(n)).Validate()
+		if !errors.Is(err, ErrInvalidMediaGroup) {
+			t.Fatalf("%d items: expected ErrInvalidMediaGroup, got %v", n, err)
+		}
+	}
+}
+
+func TestMediaGroupValidate_TooMany(t *testing.T) {
+	err := NewMediaGroup("chat-1", mediaGroupPhotos(MaxMediaGroupSize+1)).Validate()
+	if !errors.Is(err, ErrInvalidMediaGroup) {
+		t.Fatalf("expected ErrInvalidMediaGroup, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "11 items") {
+		t.Fatalf("expected item count in error, got %v", err)
+	}
+	if err := NewMediaGroup("chat-1", mediaGroupPhotos(MaxMediaGroupSize)).Validate(); err != nil {
+		t.Fatalf("expected %d items to be valid, got %v", MaxMediaGroupSize, err)
+	}
+}
+
+func TestMediaGroupValidate_IllegalMix(t *testing.T) {
+	cases := map[string][]interface{}{
+		"photo+document": {
+			NewInputMediaPhoto(FileID("p")),
+			NewInputMediaDocument(FileID("d")),
+		},
+		"audio+video": {
+			NewInputMediaAudio(FileID("a")),
+			NewInputMediaVideo(FileID("v")),
+		},
+		"document+audio": {
+			NewInputMediaDocument(FileID("d")),
+			NewInputMediaAudio(FileID("a")),
+		},
+		"animation": {
+			NewInputMediaPhoto(FileID("p")),
+			NewInputMediaAnimation(FileID("g")),
+		},
+		"unsupported": {
+			NewInputMediaPhoto(FileID("p")),
+			"not media",
+		},
+	}
+	for name, media := range cases {
+		if err := NewMediaGroup("chat-1", media).Validate(); !errors.Is(err, ErrInvalidMediaGroup) {
+			t.Errorf("%s: expected ErrInvalidMediaGroup, got %v", name, err)
+		}
+	}
+}
+
+func TestMediaGroupValidate_AllowedMixes(t *testing.T) {
+	cases := map[string][]interface{}{
+		"photo+video": {
+			NewInputMediaPhoto(FileID("p")),
+			NewInputMediaVideo(FileID("v")),
+		},
+		"documents": {
+			NewInputMediaDocument(FileID("d1")),
+			NewInputMediaDocument(FileID("d2")),
+		},
+		"audios": {
+			NewInputMediaAudio(FileID("a1")),
+			NewInputMediaAudio(FileID("a2")),
+		},
+	}
+	for name, media := range cases {
+		if err := NewMediaGroup("chat-1", media).Validate(); err != nil {
+			t.Errorf("%s: unexpected error %v", name, err)
+		}
+	}
+}
+
+func TestSendMediaGroup_InvalidGroupNotSent(t *testing.T) {
+	var calls atomic.Int32
+	bot := newTestBot(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	})
+
+	_, err := bot.SendMediaGroup(NewMediaGroup("chat-1", mediaGroupPhotos(1)))
+	if !errors.Is(err, ErrInvalidMediaGroup) {
+		t.Fatalf("expected ErrInvalidMediaGroup, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("expected no request to reach the server, got %d", calls.Load())
+	}
+}
@@ -1,5 +1,7 @@
 package zapry
 
+import "errors"
+
 // ──────────────────────────────────────────────
 // Middleware — Onion-model middleware pipeline
 // ──────────────────────────────────────────────
@@ -32,6 +34,63 @@ type MiddlewareContext struct {
 	Extra map[string]interface{}
 	// Handled is set to true when the core handler has been reached.
 	Handled bool
+
+	aborted bool
+	err     error
+	inline  Chattable
+}
+
+// CommandArgs returns the arguments captured by a prefix or regex command
+// route, or "" if the update was not dispatched through one.
+func (c *MiddlewareContext) CommandArgs() string {
+	args, _ := c.Extra["command_args"].(string)
+	return args
+}
+
+// Params returns the values captured by a callback route's {name}
+// placeholders or named groups, or nil if there were none.
+func (c *MiddlewareContext) Params() map[string]string {
+	params, _ := c.Extra["params"].(map[string]string)
+	return params
+}
+
+// RespondInline sets reply as the response to this update. In webhook mode with
+// AgentConfig.WebhookInlineReplies it is written into the webhook HTTP
+// response instead of being sent as a separate API request; otherwise it is
+// sent normally after the pipeline finishes. Only one inline response is
+// allowed per update, and it cannot upload files.
+func (c *MiddlewareContext) RespondInline(reply Chattable) error {
+	if c.inline != nil {
+		return errors.New("inline response already set for this update")
+	}
+	if f, ok := reply.(Fileable); ok && hasFilesNeedingUpload(f.files()) {
+		return errors.New("inline response cannot upload files")
+	}
+	c.inline = reply
+	return nil
+}
+
+// InlineResponse returns the reply set by RespondInline, if any.
+func (c *MiddlewareContext) InlineResponse() Chattable {
+	return c.inline
+}
+
+// Abort stops the pipeline: any next() called afterwards is a no-op, so
+// neither inner middlewares nor the router run. A non-nil err is reported to
+// the agent's OnError hook.
+func (c *MiddlewareContext) Abort(err error) {
+	c.aborted = true
+	c.err = err
+}
+
+// Aborted reports whether Abort has been called.
+func (c *MiddlewareContext) Aborted() bool {
+	return c.aborted
+}
+
+// Err returns the error passed to Abort, if any.
+func (c *MiddlewareContext) Err() error {
+	return c.err
 }
 
 // MiddlewarePipeline builds and executes an onion-model call chain.
@@ -65,13 +124,19 @@ func (p *MiddlewarePipeline) Execute(ctx *MiddlewareContext, coreHandler func())
 		return
 	}
 
-	// Build chain from inside out
-	chain := coreHandler
+	// Build chain from inside out; every layer is skipped once ctx is aborted.
+	chain := func() {
+		if !ctx.Aborted() {
+			coreHandler()
+		}
+	}
 	for i := len(p.middlewares) - 1; i >= 0; i-- {
 		mw := p.middlewares[i]
 		next := chain
 		chain = func() {
-			mw(ctx, next)
+			if !ctx.Aborted() {
+				mw(ctx, next)
+			}
 		}
 	}
 
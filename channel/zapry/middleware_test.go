@@ -1,6 +1,13 @@
 package zapry
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 )
 
@@ -137,3 +144,140 @@ func TestMiddlewarePipeline_Len(t *testing.T) {
 		t.Fatal("expected 1")
 	}
 }
+
+func TestMiddlewarePipeline_AbortStopsChain(t *testing.T) {
+	var order []string
+	p := NewMiddlewarePipeline()
+	p.Use(func(ctx *MiddlewareContext, next NextFunc) {
+		order = append(order, "auth")
+		ctx.Abort(errors.New("unauthorized"))
+		next()
+		order = append(order, "auth-after")
+	})
+	p.Use(func(ctx *MiddlewareContext, next NextFunc) {
+		order = append(order, "inner")
+		next()
+	})
+
+	ctx := &MiddlewareContext{}
+	p.Execute(ctx, func() { order = append(order, "CORE") })
+
+	expected := []string{"auth", "auth-after"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+	if !ctx.Aborted() || ctx.Err() == nil || ctx.Err().Error() != "unauthorized" {
+		t.Fatalf("expected aborted with error, got aborted=%v err=%v", ctx.Aborted(), ctx.Err())
+	}
+}
+
+func TestZapryAgent_AbortingMiddlewareFiresOnError(t *testing.T) {
+	zb := &ZapryAgent{
+		Config:   &AgentConfig{},
+		Router:   NewRouter(),
+		pipeline: NewMiddlewarePipeline(),
+	}
+	handlerCalled := false
+	zb.AddCommand("start", func(agent *AgentAPI, update Update) { handlerCalled = true })
+	zb.Use(func(ctx *MiddlewareContext, next NextFunc) {
+		ctx.Abort(errors.New("unauthorized user"))
+		next()
+	})
+	var gotErr error
+	zb.OnError(func(agent *AgentAPI, update Update, err error) { gotErr = err })
+
+	zb.handleUpdate(commandUpdate("/start"))
+
+	if handlerCalled {
+		t.Fatal("handler must not run after Abort")
+	}
+	if gotErr == nil || gotErr.Error() != "unauthorized user" {
+		t.Fatalf("expected OnError with abort error, got %v", gotErr)
+	}
+}
+
+func newInlineReplyAgent() *ZapryAgent {
+	zb := &ZapryAgent{
+		Config:   &AgentConfig{},
+		Bot:      &AgentAPI{},
+		Router:   NewRouter(),
+		pipeline: NewMiddlewarePipeline(),
+	}
+	zb.Use(func(ctx *MiddlewareContext, next NextFunc) {
+		if err := ctx.RespondInline(NewMessage(ctx.Update.Message.Chat.ID, "pong")); err != nil {
+			ctx.Abort(err)
+		}
+		if err := ctx.RespondInline(NewMessage(ctx.Update.Message.Chat.ID, "again")); err == nil {
+			ctx.Abort(errors.New("second inline response must be rejected"))
+		}
+		next()
+	})
+	return zb
+}
+
+func TestZapryAgent_ServeWebhook_InlineResponse(t *testing.T) {
+	zb := newInlineReplyAgent()
+	var gotErr error
+	zb.OnError(func(agent *AgentAPI, update Update, err error) { gotErr = err })
+
+	body, _ := json.Marshal(commandUpdate("/ping"))
+	rec := httptest.NewRecorder()
+	zb.ServeWebhook(rec, httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(body)))
+
+	if gotErr != nil {
+		t.Fatalf("unexpected error: %v", gotErr)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	values, err := url.ParseQuery(rec.Body.String())
+	if err != nil {
+		t.Fatalf("body is not form-encoded: %q", rec.Body.String())
+	}
+	if values.Get("method") != "sendMessage" || values.Get("chat_id") != "1" || values.Get("text") != "pong" {
+		t.Fatalf("unexpected inline body: %v", values)
+	}
+}
+
+func TestZapryAgent_ServeWebhook_NoInlineResponse(t *testing.T) {
+	zb := &ZapryAgent{Config: &AgentConfig{}, Bot: &AgentAPI{}, Router: NewRouter(), pipeline: NewMiddlewarePipeline()}
+	handled := false
+	zb.AddCommand("ping", func(agent *AgentAPI, update Update) { handled = true })
+
+	body, _ := json.Marshal(commandUpdate("/ping"))
+	rec := httptest.NewRecorder()
+	zb.ServeWebhook(rec, httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(body)))
+
+	if !handled || rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Fatalf("expected handled with empty 200, got handled=%v code=%d body=%q", handled, rec.Code, rec.Body.String())
+	}
+}
+
+func TestZapryAgent_InlineResponseSentWithoutWebhook(t *testing.T) {
+	var sent url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		sent, _ = url.ParseQuery(string(raw))
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer server.Close()
+
+	zb := newInlineReplyAgent()
+	zb.Bot = &AgentAPI{Client: server.Client(), apiEndpoint: server.URL + "/bot%s/%s"}
+	zb.handleUpdate(commandUpdate("/ping"))
+
+	if sent.Get("text") != "pong" {
+		t.Fatalf("expected inline response to be sent via API, got %v", sent)
+	}
+}
+
+func TestMiddlewareContext_RespondInlineRejectsUploads(t *testing.T) {
+	ctx := &MiddlewareContext{}
+	photo := NewPhoto("1", FileBytes{Name: "a.jpg", Bytes: []byte("x")})
+	if err := ctx.RespondInline(photo); err == nil {
+		t.Fatal("expected error for file upload")
+	}
+	if ctx.InlineResponse() != nil {
+		t.Fatal("rejected response must not be stored")
+	}
+}
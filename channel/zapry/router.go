@@ -11,10 +11,25 @@ import (
 // It receives the low-level AgentAPI and the incoming Update.
 type HandlerFunc func(agent *AgentAPI, update Update)
 
+// ContextHandlerFunc is a handler that receives the dispatch context, so it
+// can read route captures such as ctx.CommandArgs().
+type ContextHandlerFunc func(ctx *MiddlewareContext)
+
+// withContext adapts a HandlerFunc to a ContextHandlerFunc.
+func (h HandlerFunc) withContext() ContextHandlerFunc {
+	return func(ctx *MiddlewareContext) { h(ctx.Agent, ctx.Update) }
+}
+
 // callbackRoute pairs a regex pattern with a handler.
 type callbackRoute struct {
 	pattern *regexp.Regexp
-	handler HandlerFunc
+	handler ContextHandlerFunc
+}
+
+// commandRoute pairs a regex on the command name with a handler.
+type commandRoute struct {
+	pattern *regexp.Regexp
+	handler ContextHandlerFunc
 }
 
 // messageRoute pairs a filter string with a handler.
@@ -26,37 +41,97 @@ type messageRoute struct {
 // Router dispatches incoming Updates to registered handlers.
 //
 // Dispatch priority:
-//  1. Command handlers (exact match on command name)
+//  1. Command handlers (exact match on command name, then regex/prefix
+//     patterns in registration order)
 //  2. Callback query handlers (regex match on callback data)
 //  3. Message handlers (filter match on chat type)
+//  4. Chat member updates (my_chat_member, then chat_member)
 type Router struct {
-	commands  map[string]HandlerFunc
-	callbacks []callbackRoute
-	messages  []messageRoute
-	debug     bool
+	commands        map[string]ContextHandlerFunc
+	commandPatterns []commandRoute
+	callbacks       []callbackRoute
+	messages        []messageRoute
+	myChatMember    HandlerFunc
+	chatMember      HandlerFunc
+	debug           bool
 }
 
 // NewRouter creates an empty Router.
 func NewRouter() *Router {
 	return &Router{
-		commands:  make(map[string]HandlerFunc),
+		commands:  make(map[string]ContextHandlerFunc),
 		callbacks: make([]callbackRoute, 0),
 		messages:  make([]messageRoute, 0),
 	}
 }
 
 // AddCommand registers a handler for a bot command (e.g. "start" for /start).
+//
+// A trailing "*" registers a prefix match instead: "order_*" handles
+// /order_12345. Use AddCommandContext to read the matched suffix ("12345").
 func (r *Router) AddCommand(name string, handler HandlerFunc) {
+	r.AddCommandContext(name, handler.withContext())
+}
+
+// AddCommandContext is AddCommand for a ContextHandlerFunc. For prefix
+// commands the matched suffix is available as ctx.CommandArgs().
+func (r *Router) AddCommandContext(name string, handler ContextHandlerFunc) {
+	name = strings.TrimPrefix(name, "/")
+	if prefix, ok := strings.CutSuffix(name, "*"); ok {
+		r.addCommandPattern(regexp.MustCompile("^"+regexp.QuoteMeta(prefix)+"(.*)$"), handler)
+		return
+	}
 	r.commands[name] = handler
 	if r.debug {
 		log.Printf("[Router] Registered command: /%s", name)
 	}
 }
 
+// AddCommandRegex registers a handler for commands whose name (without the
+// leading "/") matches the regex pattern. Exact AddCommand matches win;
+// patterns are tried in registration order. Use AddCommandRegexContext to
+// read the captured arguments.
+// Example: AddCommandRegex(`^order_(\d+)$`, handler)
+func (r *Router) AddCommandRegex(pattern string, handler HandlerFunc) {
+	r.AddCommandRegexContext(pattern, handler.withContext())
+}
+
+// AddCommandRegexContext is AddCommandRegex for a ContextHandlerFunc. The
+// first capture group (or the whole command name if there is none) is
+// available as ctx.CommandArgs().
+func (r *Router) AddCommandRegexContext(pattern string, handler ContextHandlerFunc) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		log.Printf("[Router] WARNING: invalid command pattern %q: %v", pattern, err)
+		return
+	}
+	r.addCommandPattern(re, handler)
+}
+
+func (r *Router) addCommandPattern(re *regexp.Regexp, handler ContextHandlerFunc) {
+	r.commandPatterns = append(r.commandPatterns, commandRoute{pattern: re, handler: handler})
+	if r.debug {
+		log.Printf("[Router] Registered command pattern: %s", re.String())
+	}
+}
+
 // AddCallbackQuery registers a handler for callback queries matching the regex pattern.
 // Example: AddCallbackQuery("^show_detail$", handler)
+//
+// Patterns containing {name} placeholders are matched against the whole
+// callback data; apart from optional leading "^" and trailing "$" anchors,
+// the rest of such a pattern is literal text, not regex. Use
+// AddCallbackQueryContext to read the captured values.
+// Example: AddCallbackQuery("^vote:{option}$", handler) matches "vote:yes".
 func (r *Router) AddCallbackQuery(pattern string, handler HandlerFunc) {
-	re, err := regexp.Compile(pattern)
+	r.AddCallbackQueryContext(pattern, handler.withContext())
+}
+
+// AddCallbackQueryContext is AddCallbackQuery for a ContextHandlerFunc.
+// Values of {name} placeholders and named regex groups (?P<name>...) are
+// available as ctx.Params().
+func (r *Router) AddCallbackQueryContext(pattern string, handler ContextHandlerFunc) {
+	re, err := regexp.Compile(expandCallbackParams(pattern))
 	if err != nil {
 		log.Printf("[Router] WARNING: invalid callback pattern %q: %v", pattern, err)
 		return
@@ -80,9 +155,40 @@ func (r *Router) AddMessage(filter string, handler HandlerFunc) {
 	}
 }
 
+// AddMyChatMember registers a handler for my_chat_member updates, i.e. the
+// bot itself being added to, removed from, or promoted in a chat. Registering
+// again replaces the previous handler.
+func (r *Router) AddMyChatMember(handler HandlerFunc) {
+	r.myChatMember = handler
+	if r.debug {
+		log.Printf("[Router] Registered my_chat_member handler")
+	}
+}
+
+// AddChatMember registers a handler for chat_member updates, i.e. another
+// member joining, leaving, or changing status in a chat. Registering again
+// replaces the previous handler.
+func (r *Router) AddChatMember(handler HandlerFunc) {
+	r.chatMember = handler
+	if r.debug {
+		log.Printf("[Router] Registered chat_member handler")
+	}
+}
+
 // Dispatch routes an Update to the appropriate handler.
 // Returns true if a handler was found and invoked, false otherwise.
 func (r *Router) Dispatch(agent *AgentAPI, update Update) bool {
+	return r.DispatchContext(&MiddlewareContext{Update: update, Agent: agent})
+}
+
+// DispatchContext is Dispatch for an existing context. Route captures are
+// written into ctx.Extra before the handler runs, and context handlers
+// receive ctx itself.
+func (r *Router) DispatchContext(ctx *MiddlewareContext) bool {
+	if ctx.Extra == nil {
+		ctx.Extra = make(map[string]interface{})
+	}
+	agent, update, extra := ctx.Agent, ctx.Update, ctx.Extra
 	trace := r.traceEnabled()
 
 	// 1. Command messages
@@ -92,7 +198,23 @@ func (r *Router) Dispatch(agent *AgentAPI, update Update) bool {
 			if trace {
 				log.Printf("[RouteTrace] matched command=/%s", cmd)
 			}
-			handler(agent, update)
+			handler(ctx)
+			return true
+		}
+		for _, route := range r.commandPatterns {
+			m := route.pattern.FindStringSubmatch(cmd)
+			if m == nil {
+				continue
+			}
+			args := m[0]
+			if len(m) > 1 {
+				args = m[1]
+			}
+			extra["command_args"] = args
+			if trace {
+				log.Printf("[RouteTrace] matched command pattern=%s command=/%s", route.pattern.String(), cmd)
+			}
+			route.handler(ctx)
 			return true
 		}
 		if trace {
@@ -105,11 +227,14 @@ func (r *Router) Dispatch(agent *AgentAPI, update Update) bool {
 	if update.CallbackQuery != nil {
 		data := update.CallbackQuery.Data
 		for _, route := range r.callbacks {
-			if route.pattern.MatchString(data) {
+			if m := route.pattern.FindStringSubmatch(data); m != nil {
+				if params := namedSubmatches(route.pattern, m); params != nil {
+					extra["params"] = params
+				}
 				if trace {
 					log.Printf("[RouteTrace] matched callback pattern=%s data=%q", route.pattern.String(), summarizeRouteText(data, 120))
 				}
-				route.handler(agent, update)
+				route.handler(ctx)
 				return true
 			}
 		}
@@ -143,12 +268,70 @@ func (r *Router) Dispatch(agent *AgentAPI, update Update) bool {
 		}
 	}
 
+	// 4. Chat member updates
+	if update.MyChatMember != nil && r.myChatMember != nil {
+		if trace {
+			log.Printf("[RouteTrace] matched my_chat_member status=%s", update.MyChatMember.NewChatMember.Status)
+		}
+		r.myChatMember(agent, update)
+		return true
+	}
+	if update.ChatMember != nil && r.chatMember != nil {
+		if trace {
+			log.Printf("[RouteTrace] matched chat_member status=%s", update.ChatMember.NewChatMember.Status)
+		}
+		r.chatMember(agent, update)
+		return true
+	}
+
 	if trace {
 		log.Printf("[RouteTrace] update dropped (no handler)")
 	}
 	return false
 }
 
+// callbackParamRe matches {name} placeholders in callback patterns.
+var callbackParamRe = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandCallbackParams turns "vote:{option}" into an anchored regex with a
+// named group per placeholder. The pattern is always anchored, so explicit
+// "^"/"$" anchors are dropped rather than quoted. Patterns without
+// placeholders are returned as-is.
+func expandCallbackParams(pattern string) string {
+	if !callbackParamRe.MatchString(pattern) {
+		return pattern
+	}
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "^"), "$")
+	locs := callbackParamRe.FindAllStringSubmatchIndex(pattern, -1)
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, loc := range locs {
+		b.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		b.WriteString("(?P<" + pattern[loc[2]:loc[3]] + ">.+?)")
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(pattern[last:]))
+	b.WriteString("$")
+	return b.String()
+}
+
+// namedSubmatches maps named groups of re to their values in m.
+// Returns nil if re has no named groups.
+func namedSubmatches(re *regexp.Regexp, m []string) map[string]string {
+	var params map[string]string
+	for i, name := range re.SubexpNames() {
+		if name == "" || i >= len(m) {
+			continue
+		}
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params[name] = m[i]
+	}
+	return params
+}
+
 // matchMessageFilter checks if a chat type matches the filter.
 func matchMessageFilter(filter, chatType string) bool {
 	switch filter {
@@ -0,0 +1,210 @@
+package zapry
+
+import (
+	"testing"
+)
+
+func commandUpdate(text string) Update {
+	length := len(text)
+	for i, c := range text {
+		if c == ' ' {
+			length = i
+			break
+		}
+	}
+	return Update{Message: &Message{
+		Text:     text,
+		Chat:     &Chat{ID: "1", Type: "private"},
+		Entities: []MessageEntity{{Type: "bot_command", Offset: 0, Length: length}},
+	}}
+}
+
+func TestRouter_ExactCommandBeatsRegex(t *testing.T) {
+	r := NewRouter()
+	var got string
+	r.AddCommandRegex(`^order_(\d+)$`, func(agent *AgentAPI, update Update) { got = "regex" })
+	r.AddCommand("order_1", func(agent *AgentAPI, update Update) { got = "exact" })
+
+	if !r.Dispatch(nil, commandUpdate("/order_1")) {
+		t.Fatal("expected command to be handled")
+	}
+	assertEq(t, got, "exact")
+
+	if !r.Dispatch(nil, commandUpdate("/order_2")) {
+		t.Fatal("expected regex command to be handled")
+	}
+	assertEq(t, got, "regex")
+}
+
+func TestRouter_CommandRegexCapturesArgs(t *testing.T) {
+	r := NewRouter()
+	var got string
+	r.AddCommandRegexContext(`^order_(\d+)$`, func(ctx *MiddlewareContext) { got = ctx.CommandArgs() })
+
+	if !r.Dispatch(nil, commandUpdate("/order_12345")) {
+		t.Fatal("expected command to be handled")
+	}
+	assertEq(t, got, "12345")
+}
+
+func TestRouter_CommandPrefix(t *testing.T) {
+	r := NewRouter()
+	var order []string
+	var args string
+	r.AddCommandContext("order_*", func(ctx *MiddlewareContext) {
+		order = append(order, "prefix")
+		args = ctx.CommandArgs()
+	})
+	r.AddCommandRegex(`^order_`, func(agent *AgentAPI, update Update) { order = append(order, "regex") })
+
+	r.Dispatch(nil, commandUpdate("/order_abc extra words"))
+	if len(order) != 1 || order[0] != "prefix" {
+		t.Fatalf("expected first-registered pattern to win, got %v", order)
+	}
+	assertEq(t, args, "abc")
+
+	if r.Dispatch(nil, commandUpdate("/refund_1")) {
+		t.Fatal("unmatched command should not be handled")
+	}
+}
+
+func TestRouter_DispatchContextExposesArgsToMiddleware(t *testing.T) {
+	r := NewRouter()
+	r.AddCommand("order_*", func(agent *AgentAPI, update Update) {})
+
+	ctx := &MiddlewareContext{Update: commandUpdate("/order_77")}
+	if !r.DispatchContext(ctx) {
+		t.Fatal("expected command to be handled")
+	}
+	assertEq(t, ctx.CommandArgs(), "77")
+}
+
+func TestZapryAgent_CommandArgsReachHandler(t *testing.T) {
+	for _, withMiddleware := range []bool{false, true} {
+		zb := &ZapryAgent{Config: &AgentConfig{}, Bot: &AgentAPI{}, Router: NewRouter(), pipeline: NewMiddlewarePipeline()}
+		if withMiddleware {
+			zb.Use(func(ctx *MiddlewareContext, next NextFunc) { next() })
+		}
+		var got string
+		zb.AddCommandContext("order_*", func(ctx *MiddlewareContext) { got = ctx.CommandArgs() })
+
+		zb.processUpdate(commandUpdate("/order_12345"))
+
+		if got != "12345" {
+			t.Fatalf("withMiddleware=%v: expected handler to see args 12345, got %q", withMiddleware, got)
+		}
+	}
+}
+
+func callbackUpdate(data string) Update {
+	return Update{CallbackQuery: &CallbackQuery{ID: "cb1", Data: data}}
+}
+
+func TestRouter_CallbackParams(t *testing.T) {
+	r := NewRouter()
+	var got map[string]string
+	r.AddCallbackQueryContext("vote:{option}", func(ctx *MiddlewareContext) { got = ctx.Params() })
+
+	if !r.Dispatch(nil, callbackUpdate("vote:yes")) {
+		t.Fatal("expected callback to be handled")
+	}
+	assertEq(t, got["option"], "yes")
+}
+
+func TestRouter_CallbackParamsAnchoredPattern(t *testing.T) {
+	r := NewRouter()
+	var got map[string]string
+	r.AddCallbackQueryContext("^vote:{option}$", func(ctx *MiddlewareContext) { got = ctx.Params() })
+
+	if !r.Dispatch(nil, callbackUpdate("vote:no")) {
+		t.Fatal("anchored placeholder pattern should match")
+	}
+	assertEq(t, got["option"], "no")
+	if r.Dispatch(nil, callbackUpdate("xvote:no")) {
+		t.Fatal("placeholder pattern should stay anchored")
+	}
+}
+
+func TestZapryAgent_CallbackParamsReachHandler(t *testing.T) {
+	zb := &ZapryAgent{Config: &AgentConfig{}, Bot: &AgentAPI{}, Router: NewRouter(), pipeline: NewMiddlewarePipeline()}
+	var got map[string]string
+	zb.AddCallbackQueryContext("item:{id}:{action}", func(ctx *MiddlewareContext) { got = ctx.Params() })
+
+	zb.processUpdate(callbackUpdate("item:42:buy"))
+
+	if got["id"] != "42" || got["action"] != "buy" {
+		t.Fatalf("expected handler to see params, got %v", got)
+	}
+}
+
+func TestRouter_CallbackParamsNoMatch(t *testing.T) {
+	r := NewRouter()
+	r.AddCallbackQuery("vote:{option}", func(agent *AgentAPI, update Update) {})
+
+	ctx := &MiddlewareContext{Update: callbackUpdate("poll:yes")}
+	if r.DispatchContext(ctx) {
+		t.Fatal("non-matching callback should not be handled")
+	}
+	if _, ok := ctx.Extra["params"]; ok {
+		t.Fatal("params should not be set when nothing matched")
+	}
+}
+
+func TestRouter_CallbackPlainPattern(t *testing.T) {
+	r := NewRouter()
+	called := false
+	r.AddCallbackQuery("^show_detail$", func(agent *AgentAPI, update Update) { called = true })
+
+	ctx := &MiddlewareContext{Update: callbackUpdate("show_detail")}
+	if !r.DispatchContext(ctx) || !called {
+		t.Fatal("plain regex pattern should still match")
+	}
+	if _, ok := ctx.Extra["params"]; ok {
+		t.Fatal("plain patterns should not set params")
+	}
+}
+
+func chatMemberUpdated(userID, oldStatus, newStatus string) *ChatMemberUpdated {
+	return &ChatMemberUpdated{
+		Chat:          Chat{ID: "g1", Type: "group"},
+		OldChatMember: ChatMember{User: &User{ID: userID}, Status: oldStatus},
+		NewChatMember: ChatMember{User: &User{ID: userID}, Status: newStatus},
+	}
+}
+
+func TestRouter_ChatMemberUpdates(t *testing.T) {
+	r := NewRouter()
+	var events []string
+	r.AddMyChatMember(func(agent *AgentAPI, update Update) {
+		events = append(events, "bot:"+update.MyChatMember.NewChatMember.Status)
+	})
+	r.AddChatMember(func(agent *AgentAPI, update Update) {
+		events = append(events, "member:"+update.ChatMember.NewChatMember.Status)
+	})
+
+	if !r.Dispatch(nil, Update{MyChatMember: chatMemberUpdated("bot", "left", "member")}) {
+		t.Fatal("expected my_chat_member to be handled")
+	}
+	if !r.Dispatch(nil, Update{ChatMember: chatMemberUpdated("u1", "left", "member")}) {
+		t.Fatal("expected chat_member join to be handled")
+	}
+	if !r.Dispatch(nil, Update{ChatMember: chatMemberUpdated("u1", "member", "left")}) {
+		t.Fatal("expected chat_member leave to be handled")
+	}
+	if len(events) != 3 || events[0] != "bot:member" || events[1] != "member:member" || events[2] != "member:left" {
+		t.Fatalf("unexpected routing: %v", events)
+	}
+}
+
+func TestRouter_ChatMemberWithoutHandler(t *testing.T) {
+	r := NewRouter()
+	called := false
+	r.AddMyChatMember(func(agent *AgentAPI, update Update) { called = true })
+
+	if r.Dispatch(nil, Update{ChatMember: chatMemberUpdated("u1", "left", "member")}) {
+		t.Fatal("chat_member should not be handled without a handler")
+	}
+	if called {
+		t.Fatal("my_chat_member handler must not receive chat_member updates")
+	}
+}
@@ -0,0 +1,91 @@
+package zapry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+// newBatchTestBot fails the request numbered failAt (1-based); 0 = never.
+func newBatchTestBot(t *testing.T, failAt int32) (*AgentAPI, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	bot := newTestBot(t, func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n == failAt {
+			w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`))
+			return
+		}
+		fmt.Fprintf(w, `{"ok":true,"result":{"message_id":"m%d"}}`, n)
+	})
+	return bot, &calls
+}
+
+func batchMessages() []Chattable {
+	return []Chattable{
+		NewMessage("chat-1", "one"),
+		NewMessage("chat-1", "two"),
+		NewMessage("chat-1", "three"),
+	}
+}
+
+func TestSendBatch_AllSucceed(t *testing.T) {
+	bot, calls := newBatchTestBot(t, 0)
+	results, err := bot.SendBatch(context.Background(), batchMessages())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 3 || calls.Load() != 3 {
+		t.Fatalf("expected 3 results and 3 calls, got %d / %d", len(results), calls.Load())
+	}
+	for i, r := range results {
+		if r.Index != i || r.Err != nil || r.Message.MessageID != fmt.Sprintf("m%d", i+1) {
+			t.Fatalf("result %d: %+v", i, r)
+		}
+	}
+}
+
+func TestSendBatch_MidBatchFailureContinues(t *testing.T) {
+	bot, calls := newBatchTestBot(t, 2)
+	results, err := bot.SendBatch(context.Background(), batchMessages())
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 400 {
+		t.Fatalf("expected joined API error, got %v", err)
+	}
+	if len(results) != 3 || calls.Load() != 3 {
+		t.Fatalf("expected every item to be attempted, got %d results / %d calls", len(results), calls.Load())
+	}
+	if results[0].Err != nil || results[1].Err == nil || results[2].Err != nil {
+		t.Fatalf("expected only item 1 to fail: %+v", results)
+	}
+	if results[2].Message.MessageID != "m3" {
+		t.Fatalf("expected item 2 to be sent after the failure, got %+v", results[2])
+	}
+}
+
+func TestSendBatch_MidBatchFailureStops(t *testing.T) {
+	bot, calls := newBatchTestBot(t, 2)
+	results, err := bot.SendBatch(context.Background(), batchMessages(), SendBatchOptions{StopOnError: true})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(results) != 2 || calls.Load() != 2 {
+		t.Fatalf("expected batch to stop after item 1, got %d results / %d calls", len(results), calls.Load())
+	}
+	if results[0].Err != nil || results[1].Err == nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+}
+
+func TestSendBatch_CancelledContext(t *testing.T) {
+	bot, calls := newBatchTestBot(t, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := bot.SendBatch(ctx, batchMessages())
+	if !errors.Is(err, context.Canceled) || len(results) != 0 || calls.Load() != 0 {
+		t.Fatalf("expected nothing sent, got %d results / %d calls / %v", len(results), calls.Load(), err)
+	}
+}
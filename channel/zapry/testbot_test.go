@@ -0,0 +1,16 @@
+package zapry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestBot returns an AgentAPI whose requests are served by handler on a
+// local httptest server that is closed when the test ends.
+func newTestBot(t *testing.T, handler http.HandlerFunc) *AgentAPI {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return &AgentAPI{Client: server.Client(), apiEndpoint: server.URL + "/bot%s/%s"}
+}
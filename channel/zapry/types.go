@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"strings"
 	"time"
+	"unicode/utf16"
 )
 
 // APIResponse is a response from the de-im API with the result
@@ -19,16 +20,57 @@ type APIResponse struct {
 	Parameters  *ResponseParameters `json:"parameters,omitempty"`
 }
 
-// Error is an error containing extra information returned by the de-im API.
-type Error struct {
-	Code    int
+// APIError is returned by Request and the methods built on it when the API
+// answers with ok=false. Branch on Code to tell, for example, a user who
+// blocked the bot (403) from a malformed request (400).
+type APIError struct {
+	// Code is the error_code field of the response.
+	Code int
+	// Description is the human-readable description of the error.
+	Description string
+	// Message holds the same text as Description.
+	//
+	// Deprecated: use Description. Kept for code written against Error.
 	Message string
+	// ResponseParameters carries retry_after and migrate_to_chat_id when
+	// present; its fields are promoted (e.g. err.RetryAfter).
 	ResponseParameters
 }
 
-// Error message string.
-func (e Error) Error() string {
-	return e.Message
+// Error returns the API's description of the error.
+func (e APIError) Error() string {
+	if e.Description == "" {
+		return e.Message
+	}
+	return e.Description
+}
+
+// Error is the former name of APIError; existing code using Code, Message
+// or the promoted ResponseParameters fields keeps compiling.
+//
+// Deprecated: use APIError.
+type Error = APIError
+
+// IsAPIError reports whether err is or wraps an *APIError and returns it.
+func IsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// newAPIError builds the error for a non-OK APIResponse.
+func newAPIError(resp *APIResponse) *APIError {
+	apiErr := &APIError{
+		Code:        resp.ErrorCode,
+		Description: resp.Description,
+		Message:     resp.Description,
+	}
+	if resp.Parameters != nil {
+		apiErr.ResponseParameters = *resp.Parameters
+	}
+	return apiErr
 }
 
 // Update is an update response, from GetUpdates.
@@ -705,6 +747,77 @@ func (m *Message) CommandArguments() string {
 	return m.Text[entity.Length+1:]
 }
 
+// CommandArgsFields returns CommandArguments split on whitespace, e.g.
+// "/roll@dicebot 2 d6" yields ["2", "d6"]. It returns nil if the Message
+// was not a command or has no arguments.
+func (m *Message) CommandArgsFields() []string {
+	args := strings.Fields(m.CommandArguments())
+	if len(args) == 0 {
+		return nil
+	}
+	return args
+}
+
+// Mentions returns the users mentioned in the message, in order of
+// appearance: "mention" entities yield the username without the leading
+// "@", and "text_mention" entities yield the user's UserName, or ID if it
+// has none. Caption entities are used when the message has no text.
+func (m *Message) Mentions() []string {
+	text, entities := m.textEntities()
+	var mentions []string
+	for _, e := range entities {
+		switch {
+		case e.IsMention():
+			if name := strings.TrimPrefix(EntityText(text, e), "@"); name != "" {
+				mentions = append(mentions, name)
+			}
+		case e.IsTextMention() && e.User != nil:
+			if e.User.UserName != "" {
+				mentions = append(mentions, e.User.UserName)
+			} else {
+				mentions = append(mentions, e.User.ID)
+			}
+		}
+	}
+	return mentions
+}
+
+// URLs returns the links in the message, in order of appearance: the text
+// of "url" entities and the target of "text_link" entities. Caption
+// entities are used when the message has no text.
+func (m *Message) URLs() []string {
+	text, entities := m.textEntities()
+	var urls []string
+	for _, e := range entities {
+		switch {
+		case e.IsURL():
+			urls = append(urls, EntityText(text, e))
+		case e.IsTextLink() && e.URL != "":
+			urls = append(urls, e.URL)
+		}
+	}
+	return urls
+}
+
+// textEntities returns Text and Entities, or Caption and CaptionEntities
+// for media messages without text.
+func (m *Message) textEntities() (string, []MessageEntity) {
+	if m.Text == "" && m.Caption != "" {
+		return m.Caption, m.CaptionEntities
+	}
+	return m.Text, m.Entities
+}
+
+// EntityText returns the part of text covered by e. Entity offsets and
+// lengths are in UTF-16 code units; out-of-range entities yield "".
+func EntityText(text string, e MessageEntity) string {
+	units := utf16.Encode([]rune(text))
+	if e.Offset < 0 || e.Length <= 0 || e.Offset+e.Length > len(units) {
+		return ""
+	}
+	return string(utf16.Decode(units[e.Offset : e.Offset+e.Length]))
+}
+
 // MessageID represents a unique message identifier.
 type MessageID struct {
 	MessageID string `json:"message_id"`
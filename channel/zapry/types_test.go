@@ -141,6 +141,80 @@ func TestMessageCommandArgumentsForNonCommand(t *testing.T) {
 	}
 }
 
+func TestMessageCommandArgsFieldsNoArgs(t *testing.T) {
+	message := Message{Text: "/command"}
+	message.Entities = []MessageEntity{{Type: "bot_command", Offset: 0, Length: 8}}
+	if args := message.CommandArgsFields(); args != nil {
+		t.Fatalf("expected nil, got %q", args)
+	}
+}
+
+func TestMessageCommandArgsFieldsSingleArg(t *testing.T) {
+	message := Message{Text: "/command  arg1 "}
+	message.Entities = []MessageEntity{{Type: "bot_command", Offset: 0, Length: 8}}
+	args := message.CommandArgsFields()
+	if len(args) != 1 || args[0] != "arg1" {
+		t.Fatalf("expected [arg1], got %q", args)
+	}
+}
+
+func TestMessageCommandArgsFieldsWithAtMention(t *testing.T) {
+	message := Message{Text: "/command@testbot arg1 arg2"}
+	message.Entities = []MessageEntity{{Type: "bot_command", Offset: 0, Length: 16}}
+	if message.Command() != "command" || message.CommandArguments() != "arg1 arg2" {
+		t.Fatalf("unexpected command %q / args %q", message.Command(), message.CommandArguments())
+	}
+	args := message.CommandArgsFields()
+	if len(args) != 2 || args[0] != "arg1" || args[1] != "arg2" {
+		t.Fatalf("expected [arg1 arg2], got %q", args)
+	}
+}
+
+func TestMessageCommandArgsFieldsForNonCommand(t *testing.T) {
+	message := Message{Text: "test text"}
+	if args := message.CommandArgsFields(); args != nil {
+		t.Fatalf("expected nil, got %q", args)
+	}
+}
+
+func TestMessageMentionsAndURLsMixedEntities(t *testing.T) {
+	message := Message{Text: "👋 @alice, see https://x.io or docs, ping Bob #go @bob_bot"}
+	message.Entities = []MessageEntity{
+		{Type: "mention", Offset: 3, Length: 6},
+		{Type: "url", Offset: 15, Length: 12},
+		{Type: "text_link", Offset: 31, Length: 4, URL: "https://docs.example.com"},
+		{Type: "text_mention", Offset: 42, Length: 3, User: &User{ID: "42", FirstName: "Bob"}},
+		{Type: "hashtag", Offset: 46, Length: 3},
+		{Type: "mention", Offset: 50, Length: 8},
+	}
+
+	mentions := message.Mentions()
+	if len(mentions) != 3 || mentions[0] != "alice" || mentions[1] != "42" || mentions[2] != "bob_bot" {
+		t.Fatalf("unexpected mentions: %q", mentions)
+	}
+	urls := message.URLs()
+	if len(urls) != 2 || urls[0] != "https://x.io" || urls[1] != "https://docs.example.com" {
+		t.Fatalf("unexpected urls: %q", urls)
+	}
+}
+
+func TestMessageMentionsFromCaption(t *testing.T) {
+	message := Message{Caption: "hi @carol"}
+	message.CaptionEntities = []MessageEntity{{Type: "mention", Offset: 3, Length: 6}}
+	if mentions := message.Mentions(); len(mentions) != 1 || mentions[0] != "carol" {
+		t.Fatalf("unexpected mentions: %q", mentions)
+	}
+	if (&Message{Text: "no entities"}).Mentions() != nil {
+		t.Fatal("expected nil mentions without entities")
+	}
+}
+
+func TestEntityTextOutOfRange(t *testing.T) {
+	if got := EntityText("short", MessageEntity{Offset: 3, Length: 10}); got != "" {
+		t.Fatalf("expected empty text for out-of-range entity, got %q", got)
+	}
+}
+
 func TestMessageEntityParseURLGood(t *testing.T) {
 	entity := MessageEntity{URL: "https://www.google.com"}
 
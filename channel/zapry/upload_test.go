@@ -0,0 +1,71 @@
+package zapry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// slowReader yields one byte per tick and never ends.
+type slowReader struct{ tick time.Duration }
+
+func (r slowReader) Read(p []byte) (int, error) {
+	time.Sleep(r.tick)
+	if len(p) == 0 {
+		return 0, nil
+	}
+	p[0] = 'x'
+	return 1, nil
+}
+
+func newUploadTestBot(t *testing.T) *AgentAPI {
+	return newTestBot(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	})
+}
+
+func TestRequestWithContext_CancelAbortsUpload(t *testing.T) {
+	bot := newUploadTestBot(t)
+	photo := NewPhoto("chat-1", FileReader{Name: "big.jpg", Reader: slowReader{tick: 5 * time.Millisecond}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := bot.RequestWithContext(ctx, photo)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("upload not aborted promptly: %v", elapsed)
+	}
+}
+
+func TestUploadTimeout_AbortsUpload(t *testing.T) {
+	bot := newUploadTestBot(t)
+	bot.UploadTimeout = 50 * time.Millisecond
+	photo := NewPhoto("chat-1", FileReader{Name: "big.jpg", Reader: slowReader{tick: 5 * time.Millisecond}})
+
+	start := time.Now()
+	_, err := bot.Request(photo)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("upload not aborted promptly: %v", elapsed)
+	}
+}
+
+func TestRequestWithContext_UploadSucceeds(t *testing.T) {
+	bot := newUploadTestBot(t)
+	photo := NewPhoto("chat-1", FileBytes{Name: "small.jpg", Bytes: []byte("img")})
+
+	resp, err := bot.RequestWithContext(context.Background(), photo)
+	if err != nil || !resp.Ok {
+		t.Fatalf("expected ok upload, got %+v / %v", resp, err)
+	}
+}
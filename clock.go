@@ -0,0 +1,24 @@
+package agentsdk
+
+import "time"
+
+// Clock supplies the current time. Types that stamp or compare times accept
+// an optional Clock so tests can inject a deterministic one; nil means the
+// system clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a plain function to Clock.
+type ClockFunc func() time.Time
+
+// Now calls f.
+func (f ClockFunc) Now() time.Time { return f() }
+
+// nowFrom returns c.Now(), or time.Now() when c is nil.
+func nowFrom(c Clock) time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
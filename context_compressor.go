@@ -24,6 +24,12 @@ type CompressorConfig struct {
 	TokenThreshold   int              // compress only when estimated tokens exceed this, default 6000
 	SummaryVersion   string           // cache version tag, change to invalidate, default "v1"
 	EstimateTokensFn EstimateTokensFn // pluggable token estimator, nil = default
+	TokenEstimator   TokenEstimator   // shared estimator, used when EstimateTokensFn is nil
+
+	// FallbackOnError truncates history to the last TruncateWindow messages
+	// when SummarizeFn fails, instead of returning the full history.
+	FallbackOnError bool
+	TruncateWindow  int // messages kept on fallback, 0 = WindowSize
 }
 
 // DefaultCompressorConfig returns production defaults.
@@ -56,10 +62,28 @@ func NewContextCompressor(fn SummarizeFn, config ...CompressorConfig) *ContextCo
 // Compress compresses conversation history if estimated tokens exceed threshold.
 // Returns: [summary_system_msg] + recent WindowSize messages.
 // Summary is cached in WorkingMemory; cache is invalidated when SummaryVersion changes.
-// If summarization fails, returns original history unchanged.
+//
+// If summarization fails and FallbackOnError is set, the failure is logged and
+// the last TruncateWindow messages are returned with a nil error. Without
+// FallbackOnError the original history is returned together with the error;
+// earlier versions returned a nil error in that case.
 func (c *ContextCompressor) Compress(
 	history []map[string]interface{},
 	working *WorkingMemory,
+) ([]map[string]interface{}, error) {
+	out, err := c.compress(history, working)
+	if err != nil && c.config.FallbackOnError {
+		logWarnf("[ContextCompressor] Truncated to %d messages: %v", len(out), err)
+		return out, nil
+	}
+	return out, err
+}
+
+// compress is Compress but always reports summarize errors, so Enhance can
+// tell a truncation fallback from a summary.
+func (c *ContextCompressor) compress(
+	history []map[string]interface{},
+	working *WorkingMemory,
 ) ([]map[string]interface{}, error) {
 	if len(history) == 0 {
 		return history, nil
@@ -88,8 +112,11 @@ func (c *ContextCompressor) Compress(
 	// Summarize
 	summary, err := c.summarizeFn(oldMessages)
 	if err != nil {
-		// Fallback: return original
-		return history, nil
+		err = fmt.Errorf("compressor: summarize: %w", err)
+		if c.config.FallbackOnError {
+			return c.truncate(history), err
+		}
+		return history, err
 	}
 
 	// Cache
@@ -116,10 +143,25 @@ func (c *ContextCompressor) buildCompressedHistory(summary string, history []map
 	return result
 }
 
+// truncate keeps the last TruncateWindow (default WindowSize) messages.
+func (c *ContextCompressor) truncate(history []map[string]interface{}) []map[string]interface{} {
+	n := c.config.TruncateWindow
+	if n <= 0 {
+		n = c.config.WindowSize
+	}
+	if n <= 0 || n >= len(history) {
+		return history
+	}
+	return history[len(history)-n:]
+}
+
 func (c *ContextCompressor) estimateTokens(history []map[string]interface{}) int {
 	if c.config.EstimateTokensFn != nil {
 		return c.config.EstimateTokensFn(history)
 	}
+	if c.config.TokenEstimator != nil {
+		return EstimateMessagesTokens(c.config.TokenEstimator, history)
+	}
 	return defaultEstimateTokens(history)
 }
 
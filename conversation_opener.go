@@ -1,6 +1,10 @@
 package agentsdk
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // ──────────────────────────────────────────────
 // Conversation Opener Generator — strategy hints for natural openings
@@ -28,7 +32,14 @@ func DefaultOpenerConfig() OpenerConfig {
 
 // OpenerGenerator creates opening strategy hints based on conversation state.
 type OpenerGenerator struct {
-	config OpenerConfig
+	config     OpenerConfig
+	situations []customSituation
+}
+
+type customSituation struct {
+	name         string
+	match        func(*ConversationState) bool
+	hintTemplate string
 }
 
 // NewOpenerGenerator creates an opener generator.
@@ -40,6 +51,27 @@ func NewOpenerGenerator(config ...OpenerConfig) *OpenerGenerator {
 	return &OpenerGenerator{config: cfg}
 }
 
+// RegisterSituation adds a custom situation. Custom situations are evaluated
+// in registration order, before the built-in ones, so they can override them.
+//
+// hintTemplate may reference state fields by their JSON names, e.g.
+// {days_since_last}, {turn_index}, {total_sessions}, {time_of_day},
+// {user_msg_length}, {local_time}.
+//
+// Example:
+//
+//	g.RegisterSituation("birthday", isBirthday, "今天是用户生日，自然地送上祝福。")
+func (g *OpenerGenerator) RegisterSituation(name string, match func(*ConversationState) bool, hintTemplate string) {
+	if match == nil {
+		return
+	}
+	g.situations = append(g.situations, customSituation{
+		name:         name,
+		match:        match,
+		hintTemplate: hintTemplate,
+	})
+}
+
 // Generate produces an OpenerStrategy based on ConversationState.
 // sessionOpenerCount is how many times opener has been injected in this session.
 // If frequency limit is reached, returns Situation="normal" with empty Hint.
@@ -49,6 +81,15 @@ func (g *OpenerGenerator) Generate(state *ConversationState, sessionOpenerCount
 		return &OpenerStrategy{Situation: "normal", Hint: ""}
 	}
 
+	for _, cs := range g.situations {
+		if cs.match(state) {
+			return &OpenerStrategy{
+				Situation: cs.name,
+				Hint:      renderOpenerTemplate(cs.hintTemplate, state),
+			}
+		}
+	}
+
 	// Priority order: followup > first_meeting > long_absence > late_night > normal
 	if state.IsFollowUp {
 		return &OpenerStrategy{
@@ -81,6 +122,17 @@ func (g *OpenerGenerator) Generate(state *ConversationState, sessionOpenerCount
 	return &OpenerStrategy{Situation: "normal", Hint: ""}
 }
 
+func renderOpenerTemplate(tmpl string, state *ConversationState) string {
+	return strings.NewReplacer(
+		"{days_since_last}", strconv.Itoa(state.DaysSinceLast),
+		"{turn_index}", strconv.Itoa(state.TurnIndex),
+		"{total_sessions}", strconv.Itoa(state.TotalSessions),
+		"{time_of_day}", state.TimeOfDay,
+		"{user_msg_length}", state.UserMsgLength,
+		"{local_time}", state.LocalTime,
+	).Replace(tmpl)
+}
+
 // FormatForPrompt returns the hint as a prompt segment (empty if no hint).
 func (s *OpenerStrategy) FormatForPrompt() string {
 	if s.Hint == "" {
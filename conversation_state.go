@@ -3,6 +3,7 @@ package agentsdk
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -27,13 +28,16 @@ type ConversationState struct {
 }
 
 const (
-	stateMetaKey         = "sdk.conversation_meta"
-	stateTurnKey         = "sdk.session.turn_index"
-	stateLastMsgAtKey    = "sdk.session.last_msg_at"
-	stateSessionStartKey = "sdk.session.start_at"
+	stateLastSessionAtKey = "sdk.last_session_at"
+	stateTotalSessionsKey = "sdk.total_sessions"
+	stateMetaKey          = "sdk.conversation_meta" // legacy JSON form, read-only fallback
+	stateTurnKey          = "sdk.session.turn_index"
+	stateLastMsgAtKey     = "sdk.session.last_msg_at"
+	stateSessionStartKey  = "sdk.session.start_at"
 )
 
-// conversationMeta is persisted in MemoryStore.
+// conversationMeta is persisted in MemoryStore as the sdk.last_session_at
+// and sdk.total_sessions keys, so it survives restarts with the store.
 type conversationMeta struct {
 	TotalSessions int    `json:"total_sessions"`
 	LastAt        string `json:"last_at"` // RFC3339
@@ -131,8 +135,8 @@ func (t *ConversationStateTracker) Track(session *MemorySession, userInput strin
 	}
 }
 
-// TouchSession increments TotalSessions and updates LastAt.
-// Call this once per session (e.g. after first message).
+// TouchSession increments TotalSessions and updates LastAt in the session's
+// store. Call this once per session (e.g. after first message).
 func (t *ConversationStateTracker) TouchSession(session *MemorySession, now time.Time) {
 	meta := t.loadMeta(session)
 	meta.TotalSessions++
@@ -140,18 +144,34 @@ func (t *ConversationStateTracker) TouchSession(session *MemorySession, now time
 	t.saveMeta(session, &meta)
 }
 
+// loadMeta reads the persisted keys, falling back to the legacy JSON blob
+// written by earlier versions when neither key exists yet.
 func (t *ConversationStateTracker) loadMeta(session *MemorySession) conversationMeta {
 	var meta conversationMeta
-	raw, err := session.store.Get(session.Namespace, stateMetaKey)
-	if err == nil && raw != "" {
-		json.Unmarshal([]byte(raw), &meta)
+	kv, err := session.store.MGet(session.Namespace, []string{stateLastSessionAtKey, stateTotalSessionsKey})
+	if err != nil {
+		return meta
+	}
+	lastAt, hasLast := kv[stateLastSessionAtKey]
+	total, hasTotal := kv[stateTotalSessionsKey]
+	if !hasLast && !hasTotal {
+		if raw, err := session.store.Get(session.Namespace, stateMetaKey); err == nil && raw != "" {
+			json.Unmarshal([]byte(raw), &meta)
+		}
+		return meta
 	}
+	meta.LastAt = lastAt
+	meta.TotalSessions, _ = strconv.Atoi(total)
 	return meta
 }
 
 func (t *ConversationStateTracker) saveMeta(session *MemorySession, meta *conversationMeta) {
-	data, _ := json.Marshal(meta)
-	session.store.Set(session.Namespace, stateMetaKey, string(data))
+	if err := session.store.MSet(session.Namespace, map[string]string{
+		stateLastSessionAtKey: meta.LastAt,
+		stateTotalSessionsKey: strconv.Itoa(meta.TotalSessions),
+	}); err != nil {
+		logWarnf("[ConversationState] save session meta failed: %v", err)
+	}
 }
 
 // FormatForPrompt returns a strategy prompt segment for LLM injection.
@@ -3,6 +3,7 @@ package agentsdk
 import (
 	"fmt"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -24,6 +25,7 @@ type weightedKeyword struct {
 
 // EmotionalToneDetector detects user emotional tone via weighted keyword scoring.
 // Bilingual (Chinese + English), with differentiated weights to reduce false positives.
+// A keyword preceded by a nearby negation ("不着急", "not in a hurry") does not count.
 type EmotionalToneDetector struct {
 	patterns map[string][]weightedKeyword
 }
@@ -87,7 +89,7 @@ func (d *EmotionalToneDetector) Detect(userInput string, state *ConversationStat
 	// Keyword scoring
 	for tone, keywords := range d.patterns {
 		for _, kw := range keywords {
-			if strings.Contains(lower, strings.ToLower(kw.keyword)) {
+			if containsUnnegated(lower, strings.ToLower(kw.keyword)) {
 				scores[tone] += kw.weight
 			}
 		}
@@ -165,6 +167,86 @@ func (t *EmotionalTone) FormatForPrompt() string {
 	return fmt.Sprintf("[用户情绪] %s", hint)
 }
 
+// English negation looks back a few words and stops at punctuation, so
+// "no, this is terrible" still scores "terrible".
+const enNegationWindow = 3
+
+// Chinese negation is matched as whole words directly before the keyword,
+// optionally separated by one adverb or particle ("不太着急", "没那么难过",
+// "别着急"). Longer negators come first so "没有" wins over "没".
+var (
+	zhNegators   = []string{"没有", "不太", "不", "没", "别"}
+	zhNegAdverbs = []string{"那么", "这么", "怎么", "是很", "太", "很", "是", "再", "用", "着", "会", "算", "大"}
+	// zhNegatorCompounds contain 不/没/别 without negating what follows.
+	zhNegatorCompounds = []string{"差不多", "不得不", "特别", "别人", "分别", "区别", "个别", "告别", "级别", "没错"}
+)
+
+// containsUnnegated reports whether kw occurs in text at least once
+// without a negation word shortly before it.
+func containsUnnegated(text, kw string) bool {
+	if kw == "" {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(kw)
+	english := r < utf8.RuneSelf
+	for start := 0; ; {
+		i := strings.Index(text[start:], kw)
+		if i < 0 {
+			return false
+		}
+		pos := start + i
+		if english && !isEnglishNegated(text[:pos]) || !english && !isChineseNegated(text[:pos]) {
+			return true
+		}
+		start = pos + len(kw)
+	}
+}
+
+// isChineseNegated reports whether before ends with a negator word, either
+// directly or followed by one adverb. Compounds such as 特别 or 差不多 are
+// masked first so their 不/没/别 is not mistaken for a negator.
+func isChineseNegated(before string) bool {
+	for _, c := range zhNegatorCompounds {
+		before = strings.ReplaceAll(before, c, strings.Repeat("X", utf8.RuneCountInString(c)))
+	}
+	if endsWithAny(before, zhNegators) {
+		return true
+	}
+	for _, adv := range zhNegAdverbs {
+		if strings.HasSuffix(before, adv) && endsWithAny(strings.TrimSuffix(before, adv), zhNegators) {
+			return true
+		}
+	}
+	return false
+}
+
+func endsWithAny(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEnglishNegated checks the last few words of before for not/no/never
+// or an n't contraction.
+func isEnglishNegated(before string) bool {
+	if i := strings.LastIndexFunc(before, func(r rune) bool {
+		return r != '\'' && unicode.IsPunct(r)
+	}); i >= 0 {
+		before = before[i+1:]
+	}
+	words := strings.Fields(before)
+	for n := 0; n < enNegationWindow && n < len(words); n++ {
+		w := words[len(words)-1-n]
+		if w == "not" || w == "no" || w == "never" || strings.HasSuffix(w, "n't") {
+			return true
+		}
+	}
+	return false
+}
+
 func findMaxTone(scores map[string]float64) string {
 	maxTone := "neutral"
 	maxScore := 0.0
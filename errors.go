@@ -9,8 +9,12 @@ var (
 	ErrToolMissingRequiredArg   = errors.New("agentsdk: tool missing required argument")
 	ErrToolTimeout              = errors.New("agentsdk: tool execution timeout")
 	ErrToolCancelled            = errors.New("agentsdk: tool execution canceled")
+	ErrToolPanic                = errors.New("agentsdk: tool handler panicked")
 	ErrLLMFunctionNotConfigured = errors.New("agentsdk: llm function is nil")
 
+	// Memory related errors.
+	ErrInvalidMessageRole = errors.New("agentsdk: invalid message role")
+
 	// Auto conversation lifecycle errors.
 	ErrAutoConversationShuttingDown = errors.New("agentsdk: auto conversation runtime is shutting down")
 )
@@ -1,6 +1,8 @@
 package agentsdk
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -91,6 +93,7 @@ type OnChangeFn func(userID string, changes map[string]string)
 //	}
 type FeedbackDetector struct {
 	patterns  map[string]map[string][]string
+	regexes   map[string]map[string][]*regexp.Regexp
 	maxLength int
 	onChange  OnChangeFn
 }
@@ -132,6 +135,30 @@ func (d *FeedbackDetector) AddPattern(prefKey, prefValue string, keywords []stri
 	d.patterns[prefKey][prefValue] = append(d.patterns[prefKey][prefValue], keywords...)
 }
 
+// AddRegexPattern registers a regular expression for a specific preference
+// key/value. Regexes are matched alongside keyword patterns and are useful
+// for punctuation or spacing variants ("太长了!!!", "太 长 了").
+//
+// Returns an error if expr does not compile.
+//
+// Example:
+//
+//	err := detector.AddRegexPattern("style", "concise", `太\s*长\s*了`)
+func (d *FeedbackDetector) AddRegexPattern(prefKey, prefValue, expr string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid feedback regex %q: %w", expr, err)
+	}
+	if d.regexes == nil {
+		d.regexes = make(map[string]map[string][]*regexp.Regexp)
+	}
+	if d.regexes[prefKey] == nil {
+		d.regexes[prefKey] = make(map[string][]*regexp.Regexp)
+	}
+	d.regexes[prefKey][prefValue] = append(d.regexes[prefKey][prefValue], re)
+	return nil
+}
+
 // Detect checks a message for feedback signals.
 //
 // Parameters:
@@ -155,20 +182,31 @@ func (d *FeedbackDetector) Detect(message string, currentPreferences map[string]
 		current = make(map[string]string)
 	}
 
-	for prefKey, valueMap := range d.patterns {
-		for prefValue, keywords := range valueMap {
-			for _, kw := range keywords {
-				if strings.Contains(msg, kw) {
-					oldVal := current[prefKey]
-					if oldVal != prefValue {
-						result.Matched = true
-						result.Changes[prefKey] = prefValue
-						result.Triggers[prefKey] = kw
-					}
-					break
-				}
+	prefKeys := make(map[string]struct{}, len(d.patterns)+len(d.regexes))
+	for prefKey := range d.patterns {
+		prefKeys[prefKey] = struct{}{}
+	}
+	for prefKey := range d.regexes {
+		prefKeys[prefKey] = struct{}{}
+	}
+
+	for prefKey := range prefKeys {
+		for prefValue, keywords := range d.patterns[prefKey] {
+			if trigger, ok := matchKeywords(msg, keywords); ok && current[prefKey] != prefValue {
+				result.Matched = true
+				result.Changes[prefKey] = prefValue
+				result.Triggers[prefKey] = trigger
+				break
 			}
-			if _, found := result.Changes[prefKey]; found {
+		}
+		if _, found := result.Changes[prefKey]; found {
+			continue
+		}
+		for prefValue, regexes := range d.regexes[prefKey] {
+			if trigger, ok := matchRegexes(msg, regexes); ok && current[prefKey] != prefValue {
+				result.Matched = true
+				result.Changes[prefKey] = prefValue
+				result.Triggers[prefKey] = trigger
 				break
 			}
 		}
@@ -177,6 +215,24 @@ func (d *FeedbackDetector) Detect(message string, currentPreferences map[string]
 	return result
 }
 
+func matchKeywords(msg string, keywords []string) (string, bool) {
+	for _, kw := range keywords {
+		if strings.Contains(msg, kw) {
+			return kw, true
+		}
+	}
+	return "", false
+}
+
+func matchRegexes(msg string, regexes []*regexp.Regexp) (string, bool) {
+	for _, re := range regexes {
+		if loc := re.FindStringIndex(msg); loc != nil {
+			return msg[loc[0]:loc[1]], true
+		}
+	}
+	return "", false
+}
+
 // DetectAndAdapt detects feedback, updates the preferences map in-place,
 // and invokes the onChange callback if set.
 //
@@ -187,6 +243,15 @@ func (d *FeedbackDetector) Detect(message string, currentPreferences map[string]
 //
 // Returns FeedbackResult.
 func (d *FeedbackDetector) DetectAndAdapt(userID, message string, preferences map[string]string) FeedbackResult {
+	result := d.adapt(userID, message, preferences)
+	if result.Matched && d.onChange != nil {
+		d.onChange(userID, result.Changes)
+	}
+	return result
+}
+
+// adapt is DetectAndAdapt without the onChange callback.
+func (d *FeedbackDetector) adapt(userID, message string, preferences map[string]string) FeedbackResult {
 	result := d.Detect(message, preferences)
 	if result.Matched {
 		for k, v := range result.Changes {
@@ -198,12 +263,52 @@ func (d *FeedbackDetector) DetectAndAdapt(userID, message string, preferences ma
 			logInfof("[FeedbackDetector] Preference adapted | user=%s | %s -> %s | keyword=%s",
 				userID, prefKey, result.Changes[prefKey], kw)
 		}
+	}
+	return result
+}
 
-		if d.onChange != nil {
-			d.onChange(userID, result.Changes)
+// DetectAndAdaptSession is like DetectAndAdapt but persists preferences in the
+// session's long-term memory under the "preferences" key, so learned style
+// survives across sessions. Unrelated preference keys are preserved.
+//
+// The read-merge-save runs under the session lock, so it does not race with
+// ExtractIfNeeded or UpdateLongTerm. onChange is called after the lock is
+// released.
+//
+// Returns FeedbackResult and any error from loading or saving long-term memory.
+func (d *FeedbackDetector) DetectAndAdaptSession(session *MemorySession, userID, message string) (FeedbackResult, error) {
+	if session == nil {
+		return FeedbackResult{}, fmt.Errorf("memory session is nil")
+	}
+
+	var result FeedbackResult
+	err := session.modifyLongTerm(func(current map[string]interface{}) map[string]interface{} {
+		preferences := make(map[string]string)
+		stored, _ := current["preferences"].(map[string]interface{})
+		for k, v := range stored {
+			if s, ok := v.(string); ok {
+				preferences[k] = s
+			}
+		}
+
+		result = d.adapt(userID, message, preferences)
+		if !result.Matched {
+			return nil
 		}
+
+		updates := map[string]interface{}{"updated_at": preferences["updated_at"]}
+		for k, v := range result.Changes {
+			updates[k] = v
+		}
+		return DeepMerge(current, map[string]interface{}{"preferences": updates})
+	})
+	if err != nil {
+		return result, err
 	}
-	return result
+	if result.Matched && d.onChange != nil {
+		d.onChange(userID, result.Changes)
+	}
+	return result, nil
 }
 
 // ──────────────────────────────────────────────
@@ -1,7 +1,11 @@
 package agentsdk
 
 import (
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // ══════════════════════════════════════════════
@@ -153,6 +157,34 @@ func TestFeedbackDetector_AddPattern(t *testing.T) {
 	}
 }
 
+func TestFeedbackDetector_AddRegexPattern(t *testing.T) {
+	d := NewFeedbackDetector(map[string]map[string][]string{}, 50, nil)
+	if err := d.AddRegexPattern("style", "concise", `太\s*长\s*了[!！]*`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"太长了!!!", "太 长 了！！", "真的太长了"} {
+		r := d.Detect(msg, nil)
+		if !r.Matched || r.Changes["style"] != "concise" {
+			t.Fatalf("expected regex to match %q, got %+v", msg, r)
+		}
+	}
+	if r := d.Detect("太长了!!!", map[string]string{"style": "concise"}); r.Matched {
+		t.Fatal("regex match should be deduplicated against current preference")
+	}
+	long := "太长了" + strings.Repeat("啊", 60)
+	if r := d.Detect(long, nil); r.Matched {
+		t.Fatal("regex should honor maxLength")
+	}
+}
+
+func TestFeedbackDetector_AddRegexPatternInvalid(t *testing.T) {
+	d := NewFeedbackDetector(nil, 50, nil)
+	if err := d.AddRegexPattern("style", "concise", `太长(`); err == nil {
+		t.Fatal("expected error for invalid regex")
+	}
+}
+
 func TestFeedbackDetector_SetPatternsReplaces(t *testing.T) {
 	d := NewFeedbackDetector(nil, 50, nil)
 	d.SetPatterns(map[string]map[string][]string{
@@ -225,6 +257,91 @@ func TestFeedbackDetector_OnChangeCallback(t *testing.T) {
 	}
 }
 
+func TestFeedbackDetector_DetectAndAdaptSession_Persists(t *testing.T) {
+	store := NewInMemoryMemoryStore()
+	session := NewMemorySession("agent", "u1", store)
+	if _, err := session.UpdateLongTerm(map[string]interface{}{
+		"preferences": map[string]interface{}{"language": "zh", "tone": "formal"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var changed map[string]string
+	d := NewFeedbackDetector(nil, 50, func(userID string, changes map[string]string) {
+		changed = changes
+	})
+	result, err := d.DetectAndAdaptSession(session, "u1", "说人话")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Matched || changed["tone"] != "casual" {
+		t.Fatalf("expected tone=casual change, got %+v / %v", result, changed)
+	}
+
+	// Reload from the store with a fresh session
+	reloaded := NewMemorySession("agent", "u1", store)
+	lt, err := reloaded.LongTerm.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefs, _ := lt["preferences"].(map[string]interface{})
+	if prefs["tone"] != "casual" {
+		t.Fatalf("expected persisted tone=casual, got %v", prefs["tone"])
+	}
+	if prefs["language"] != "zh" {
+		t.Fatalf("unrelated preference should be kept, got %v", prefs["language"])
+	}
+
+	// Same feedback again is deduplicated against the persisted value
+	again, err := d.DetectAndAdaptSession(reloaded, "u1", "说人话")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Matched {
+		t.Fatal("should not match when preference already persisted")
+	}
+}
+
+func TestFeedbackDetector_DetectAndAdaptSession_ConcurrentUpdates(t *testing.T) {
+	session := NewMemorySession("agent", "u1", NewInMemoryMemoryStore())
+	// A slow onChange widens any window between loading and saving preferences.
+	d := NewFeedbackDetector(nil, 50, func(string, map[string]string) {
+		time.Sleep(time.Millisecond)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := session.UpdateLongTerm(map[string]interface{}{fmt.Sprintf("fact_%d", i): i}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func(msg string) {
+			defer wg.Done()
+			if _, err := d.DetectAndAdaptSession(session, "u1", msg); err != nil {
+				t.Error(err)
+			}
+		}([]string{"说人话", "正式一些"}[i%2])
+	}
+	wg.Wait()
+
+	lt, err := session.LongTerm.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		if _, ok := lt[fmt.Sprintf("fact_%d", i)]; !ok {
+			t.Fatalf("fact_%d lost to a concurrent preference save", i)
+		}
+	}
+	prefs, _ := lt["preferences"].(map[string]interface{})
+	if prefs["tone"] == nil {
+		t.Fatal("expected tone preference to be saved")
+	}
+}
+
 // ══════════════════════════════════════════════
 // BuildPreferencePrompt tests
 // ══════════════════════════════════════════════
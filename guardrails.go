@@ -30,11 +30,68 @@ func (e *OutputGuardrailTriggered) Error() string {
 	return fmt.Sprintf("Output guardrail triggered: %s — %s", e.GuardrailName, e.Reason)
 }
 
+// ConversationGuardrailTriggered is returned when a conversation guardrail blocks.
+type ConversationGuardrailTriggered struct {
+	GuardrailName string
+	Reason        string
+}
+
+func (e *ConversationGuardrailTriggered) Error() string {
+	return fmt.Sprintf("Conversation guardrail triggered: %s — %s", e.GuardrailName, e.Reason)
+}
+
+// OutputRewriteError is returned when an output rewriter fails.
+type OutputRewriteError struct {
+	RewriterName string
+	Err          error
+}
+
+func (e *OutputRewriteError) Error() string {
+	return fmt.Sprintf("Output rewriter failed: %s — %v", e.RewriterName, e.Err)
+}
+
+func (e *OutputRewriteError) Unwrap() error { return e.Err }
+
+// GuardrailBlockInfo describes the guardrail that stopped an AgentLoop run.
+type GuardrailBlockInfo struct {
+	Name   string `json:"name"`
+	Stage  string `json:"stage"` // "input" | "output" | "conversation"
+	Reason string `json:"reason"`
+}
+
+// guardrailBlockInfo converts a *GuardrailTriggered error into block info.
+func guardrailBlockInfo(err error) *GuardrailBlockInfo {
+	switch e := err.(type) {
+	case *InputGuardrailTriggered:
+		return &GuardrailBlockInfo{Name: e.GuardrailName, Stage: "input", Reason: e.Reason}
+	case *OutputGuardrailTriggered:
+		return &GuardrailBlockInfo{Name: e.GuardrailName, Stage: "output", Reason: e.Reason}
+	case *ConversationGuardrailTriggered:
+		return &GuardrailBlockInfo{Name: e.GuardrailName, Stage: "conversation", Reason: e.Reason}
+	}
+	return &GuardrailBlockInfo{Reason: err.Error()}
+}
+
+// OutputRewriterFunc transforms output text (redact PII, trim profanity, enforce length, ...).
+type OutputRewriterFunc func(text string) (string, error)
+
+type outputRewriterDef struct {
+	name string
+	fn   OutputRewriterFunc
+}
+
 // GuardrailContext is passed to guardrail functions.
 type GuardrailContext struct {
 	Text     string
 	Messages []map[string]interface{}
 	Extra    map[string]interface{}
+	// History is the full message list, set for conversation guardrails so
+	// they can catch patterns spread across several messages.
+	History []map[string]interface{}
+	// Ctx is the request context passed to Check*WithContext (Background for
+	// the non-context variants), so plain GuardrailFuncs calling external
+	// services can honour deadlines and cancellation.
+	Ctx context.Context
 }
 
 // GuardrailResultData holds the result of a single guardrail check.
@@ -70,10 +127,12 @@ type guardrailDef struct {
 //	})
 //	err := mgr.CheckInput("test input", nil, nil)
 type GuardrailManager struct {
-	inputGuards  []guardrailDef
-	outputGuards []guardrailDef
-	sequential   bool
-	mu           sync.RWMutex
+	inputGuards     []guardrailDef
+	outputGuards    []guardrailDef
+	convGuards      []guardrailDef
+	outputRewriters []outputRewriterDef
+	sequential      bool
+	mu              sync.RWMutex
 }
 
 // NewGuardrailManager creates a new guardrail manager.
@@ -110,6 +169,24 @@ func (g *GuardrailManager) AddOutputV2(name string, fn GuardrailFuncV2) {
 	g.outputGuards = append(g.outputGuards, guardrailDef{name: name, fnV2: fn})
 }
 
+// AddConversation registers a conversation guardrail. It receives the full
+// message list in GuardrailContext.History and is run by AgentLoop once per
+// turn, before the LLM call.
+func (g *GuardrailManager) AddConversation(name string, fn GuardrailFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.convGuards = append(g.convGuards, guardrailDef{name: name, fn: fn})
+}
+
+// AddOutputRewriter registers an output rewriter. Unlike guardrails, rewriters
+// never block: they run in registration order after all output guards pass,
+// each receiving the previous rewriter's output.
+func (g *GuardrailManager) AddOutputRewriter(name string, fn OutputRewriterFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.outputRewriters = append(g.outputRewriters, outputRewriterDef{name: name, fn: fn})
+}
+
 // InputCount returns the number of input guardrails.
 func (g *GuardrailManager) InputCount() int {
 	g.mu.RLock()
@@ -124,6 +201,69 @@ func (g *GuardrailManager) OutputCount() int {
 	return len(g.outputGuards)
 }
 
+// ConversationCount returns the number of conversation guardrails.
+func (g *GuardrailManager) ConversationCount() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.convGuards)
+}
+
+// RewriterCount returns the number of output rewriters.
+func (g *GuardrailManager) RewriterCount() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.outputRewriters)
+}
+
+// RewriteOutput runs the output rewriter chain. Returns OutputRewriteError on
+// the first failing rewriter.
+func (g *GuardrailManager) RewriteOutput(text string) (string, error) {
+	g.mu.RLock()
+	rewriters := append([]outputRewriterDef(nil), g.outputRewriters...)
+	g.mu.RUnlock()
+
+	for _, rw := range rewriters {
+		out, err := g.execRewriter(rw, text)
+		if err != nil {
+			return "", &OutputRewriteError{RewriterName: rw.name, Err: err}
+		}
+		text = out
+	}
+	return text, nil
+}
+
+func (g *GuardrailManager) execRewriter(rw outputRewriterDef, text string) (out string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logErrorf("[Guardrail] rewriter %s panic: %v", rw.name, r)
+			err = fmt.Errorf("rewriter panic: %v", r)
+		}
+	}()
+	if rw.fn == nil {
+		return "", fmt.Errorf("rewriter function is nil")
+	}
+	return rw.fn(text)
+}
+
+// CheckConversation runs all conversation guardrails over history.
+// Returns error (ConversationGuardrailTriggered) on failure.
+func (g *GuardrailManager) CheckConversation(history []map[string]interface{}) error {
+	return g.CheckConversationWithContext(context.Background(), history)
+}
+
+// CheckConversationWithContext is CheckConversation with cancellation support.
+func (g *GuardrailManager) CheckConversationWithContext(ctx context.Context, history []map[string]interface{}) error {
+	g.mu.RLock()
+	guards := make([]guardrailDef, len(g.convGuards))
+	copy(guards, g.convGuards)
+	g.mu.RUnlock()
+	result := g.runGuards(ctx, guards, "", nil, nil, history)
+	if !result.Passed {
+		return &ConversationGuardrailTriggered{GuardrailName: result.GuardrailName, Reason: result.Reason}
+	}
+	return nil
+}
+
 // CheckInput runs all input guardrails. Returns error (InputGuardrailTriggered) on failure.
 func (g *GuardrailManager) CheckInput(text string, messages []map[string]interface{}, extra map[string]interface{}) error {
 	result := g.checkInputSafeWithContext(context.Background(), text, messages, extra)
@@ -185,7 +325,7 @@ func (g *GuardrailManager) checkInputSafeWithContext(ctx context.Context, text s
 	guards := make([]guardrailDef, len(g.inputGuards))
 	copy(guards, g.inputGuards)
 	g.mu.RUnlock()
-	return g.runGuards(ctx, guards, text, messages, extra)
+	return g.runGuards(ctx, guards, text, messages, extra, nil)
 }
 
 func (g *GuardrailManager) checkOutputSafeWithContext(ctx context.Context, text string, messages []map[string]interface{}, extra map[string]interface{}) *GuardrailResultData {
@@ -193,10 +333,10 @@ func (g *GuardrailManager) checkOutputSafeWithContext(ctx context.Context, text
 	guards := make([]guardrailDef, len(g.outputGuards))
 	copy(guards, g.outputGuards)
 	g.mu.RUnlock()
-	return g.runGuards(ctx, guards, text, messages, extra)
+	return g.runGuards(ctx, guards, text, messages, extra, nil)
 }
 
-func (g *GuardrailManager) runGuards(runCtx context.Context, guards []guardrailDef, text string, messages []map[string]interface{}, extra map[string]interface{}) *GuardrailResultData {
+func (g *GuardrailManager) runGuards(runCtx context.Context, guards []guardrailDef, text string, messages []map[string]interface{}, extra map[string]interface{}, history []map[string]interface{}) *GuardrailResultData {
 	if runCtx == nil {
 		runCtx = context.Background()
 	}
@@ -208,7 +348,7 @@ func (g *GuardrailManager) runGuards(runCtx context.Context, guards []guardrailD
 		extra = make(map[string]interface{})
 	}
 
-	gCtx := &GuardrailContext{Text: text, Messages: messages, Extra: extra}
+	gCtx := &GuardrailContext{Text: text, Messages: messages, Extra: extra, History: history, Ctx: runCtx}
 
 	if g.sequential {
 		for _, gd := range guards {
@@ -296,17 +436,14 @@ func (g *GuardrailManager) execOne(runCtx context.Context, gd guardrailDef, gCtx
 
 func cloneGuardrailContext(in *GuardrailContext) *GuardrailContext {
 	if in == nil {
-		return &GuardrailContext{Extra: make(map[string]interface{})}
+		return &GuardrailContext{Extra: make(map[string]interface{}), Ctx: context.Background()}
 	}
 	out := &GuardrailContext{
 		Text: in.Text,
+		Ctx:  in.Ctx,
 	}
-	if len(in.Messages) > 0 {
-		out.Messages = make([]map[string]interface{}, len(in.Messages))
-		for i, msg := range in.Messages {
-			out.Messages[i] = cloneStringAnyMap(msg)
-		}
-	}
+	out.Messages = cloneMessageList(in.Messages)
+	out.History = cloneMessageList(in.History)
 	if in.Extra != nil {
 		out.Extra = cloneStringAnyMap(in.Extra)
 	} else {
@@ -315,6 +452,17 @@ func cloneGuardrailContext(in *GuardrailContext) *GuardrailContext {
 	return out
 }
 
+func cloneMessageList(in []map[string]interface{}) []map[string]interface{} {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make([]map[string]interface{}, len(in))
+	for i, msg := range in {
+		out[i] = cloneStringAnyMap(msg)
+	}
+	return out
+}
+
 func cloneStringAnyMap(src map[string]interface{}) map[string]interface{} {
 	if len(src) == 0 {
 		return make(map[string]interface{})
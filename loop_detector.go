@@ -2,8 +2,8 @@ package agentsdk
 
 import (
 	"crypto/sha256"
-	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // ──────────────────────────────────────────────
@@ -16,6 +16,7 @@ type LoopDetectorConfig struct {
 	MaxRepeatCalls      int // consecutive same tool+args limit, default 3
 	MaxSameToolInWindow int // same tool count limit within window, default 5
 	WindowSize          int // sliding window size, default 10
+	PingPongLength      int // alternating history entries before ping_pong triggers, default 3 (<0 disables)
 }
 
 // DefaultLoopDetectorConfig returns sensible defaults.
@@ -25,13 +26,22 @@ func DefaultLoopDetectorConfig() LoopDetectorConfig {
 		MaxRepeatCalls:      3,
 		MaxSameToolInWindow: 5,
 		WindowSize:          10,
+		PingPongLength:      3,
 	}
 }
 
 // LoopWarning describes a detected loop pattern.
 type LoopWarning struct {
-	Type    string // "repeat" / "flood" / "ping_pong"
-	Message string
+	Type    string `json:"type"` // "repeat" / "flood" / "ping_pong"
+	Tool    string `json:"tool"`
+	Message string `json:"message"`
+}
+
+// LoopStats is a snapshot of the detector's recent history.
+type LoopStats struct {
+	WindowSize int            `json:"window_size"`
+	Recent     []string       `json:"recent"`      // tool names in the window, oldest first
+	ToolCounts map[string]int `json:"tool_counts"` // calls per tool in the window
 }
 
 type toolCallEntry struct {
@@ -77,6 +87,7 @@ func (d *LoopDetector) Check(name string, args map[string]interface{}) *LoopWarn
 		if repeatCount >= d.config.MaxRepeatCalls {
 			return &LoopWarning{
 				Type:    "repeat",
+				Tool:    name,
 				Message: fmt.Sprintf("Tool %q called %d times with identical arguments", name, repeatCount+1),
 			}
 		}
@@ -97,22 +108,29 @@ func (d *LoopDetector) Check(name string, args map[string]interface{}) *LoopWarn
 		if sameCount >= d.config.MaxSameToolInWindow {
 			return &LoopWarning{
 				Type:    "flood",
+				Tool:    name,
 				Message: fmt.Sprintf("Tool %q called %d times in last %d calls", name, sameCount+1, d.config.WindowSize),
 			}
 		}
 	}
 
-	// 3. pingPong: A/B/A/B alternating pattern (check last 3 entries)
-	if len(d.history) >= 3 {
-		h := d.history
-		n := len(h)
-		if h[n-1].Name != h[n-2].Name &&
-			h[n-1].Name == h[n-3].Name &&
-			h[n-2].Name == name &&
-			name != h[n-1].Name {
+	// 3. pingPong: A/B/A/B alternating pattern over the last PingPongLength entries
+	if n := d.pingPongLength(); n > 0 && len(d.history) >= n {
+		tail := append(append([]string{}, d.historyNames(len(d.history)-n)...), name)
+		a, b := tail[0], tail[1]
+		alternating := a != b
+		for i := 2; alternating && i < len(tail); i++ {
+			want := a
+			if i%2 == 1 {
+				want = b
+			}
+			alternating = tail[i] == want
+		}
+		if alternating {
 			return &LoopWarning{
 				Type:    "ping_pong",
-				Message: fmt.Sprintf("Ping-pong pattern detected: %s / %s alternating", h[n-1].Name, name),
+				Tool:    name,
+				Message: fmt.Sprintf("Ping-pong pattern detected: %s / %s alternating", tail[len(tail)-2], name),
 			}
 		}
 	}
@@ -136,16 +154,73 @@ func (d *LoopDetector) Record(name string, args map[string]interface{}) {
 	}
 }
 
+// Stats returns call counts for the current sliding window.
+//
+// It reflects only calls fed to this detector via Check/Record. AgentLoop
+// runs on a Clone, so the detector assigned to AgentLoop.LoopDetector stays
+// empty; read AgentLoopResult.LoopStats for a run's statistics instead.
+func (d *LoopDetector) Stats() LoopStats {
+	start := len(d.history) - d.config.WindowSize
+	if start < 0 || d.config.WindowSize <= 0 {
+		start = 0
+	}
+	stats := LoopStats{
+		WindowSize: d.config.WindowSize,
+		Recent:     d.historyNames(start),
+		ToolCounts: make(map[string]int),
+	}
+	for _, name := range stats.Recent {
+		stats.ToolCounts[name]++
+	}
+	return stats
+}
+
 // Reset clears the history.
 func (d *LoopDetector) Reset() {
 	d.history = nil
 }
 
+// Clone returns a detector with the same config and an empty history.
+// AgentLoop uses it to give every run its own history.
+func (d *LoopDetector) Clone() *LoopDetector {
+	return &LoopDetector{config: d.config}
+}
+
+func (d *LoopDetector) pingPongLength() int {
+	switch {
+	case d.config.PingPongLength < 0:
+		return 0
+	case d.config.PingPongLength == 0:
+		return 3
+	case d.config.PingPongLength < 2:
+		return 2
+	}
+	return d.config.PingPongLength
+}
+
+func (d *LoopDetector) historyNames(from int) []string {
+	names := make([]string, 0, len(d.history)-from)
+	for _, e := range d.history[from:] {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+// contentHash hashes assistant content ignoring case and whitespace
+// differences; "" for blank content.
+func contentHash(content string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(content)), " ")
+	if normalized == "" {
+		return ""
+	}
+	h := sha256.Sum256([]byte(normalized))
+	return fmt.Sprintf("%x", h[:8])
+}
+
 func hashArgs(args map[string]interface{}) string {
 	if args == nil || len(args) == 0 {
 		return "empty"
 	}
-	data, _ := json.Marshal(args)
-	h := sha256.Sum256(data)
+	h := sha256.Sum256([]byte(CanonicalJSON(args)))
 	return fmt.Sprintf("%x", h[:8])
 }
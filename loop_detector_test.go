@@ -97,6 +97,78 @@ func TestLoopDetector_PingPong(t *testing.T) {
 	}
 }
 
+func TestLoopDetector_CustomThresholds(t *testing.T) {
+	t.Run("repeat", func(t *testing.T) {
+		d := NewLoopDetector(LoopDetectorConfig{Enabled: true, MaxRepeatCalls: 1, WindowSize: 10})
+		args := map[string]interface{}{"q": "x"}
+		d.Record("search", args)
+		w := d.Check("search", args)
+		if w == nil || w.Type != "repeat" || w.Tool != "search" {
+			t.Fatalf("expected repeat on search after 1 call, got %+v", w)
+		}
+	})
+
+	t.Run("flood", func(t *testing.T) {
+		d := NewLoopDetector(LoopDetectorConfig{Enabled: true, MaxSameToolInWindow: 2, WindowSize: 3})
+		d.Record("fetch", map[string]interface{}{"id": 1})
+		d.Record("other", nil)
+		d.Record("other", map[string]interface{}{"id": 2})
+		d.Record("fetch", map[string]interface{}{"id": 3})
+		// window of 3 holds only one "fetch"
+		if w := d.Check("fetch", map[string]interface{}{"id": 4}); w != nil {
+			t.Fatalf("expected no warning with 1 fetch in window, got %+v", w)
+		}
+		d.Record("fetch", map[string]interface{}{"id": 5})
+		w := d.Check("fetch", map[string]interface{}{"id": 6})
+		if w == nil || w.Type != "flood" {
+			t.Fatalf("expected flood, got %+v", w)
+		}
+	})
+
+	t.Run("ping_pong", func(t *testing.T) {
+		d := NewLoopDetector(LoopDetectorConfig{Enabled: true, WindowSize: 10, PingPongLength: 5})
+		for i, name := range []string{"toolA", "toolB", "toolA"} {
+			d.Record(name, map[string]interface{}{"i": i})
+		}
+		if w := d.Check("toolB", nil); w != nil {
+			t.Fatalf("expected no ping_pong with length 5 after 3 entries, got %+v", w)
+		}
+		d.Record("toolB", nil)
+		d.Record("toolA", nil)
+		w := d.Check("toolB", map[string]interface{}{"i": 9})
+		if w == nil || w.Type != "ping_pong" {
+			t.Fatalf("expected ping_pong, got %+v", w)
+		}
+	})
+
+	t.Run("ping_pong disabled", func(t *testing.T) {
+		d := NewLoopDetector(LoopDetectorConfig{Enabled: true, WindowSize: 10, PingPongLength: -1})
+		for _, name := range []string{"toolA", "toolB", "toolA"} {
+			d.Record(name, nil)
+		}
+		if w := d.Check("toolB", nil); w != nil {
+			t.Fatalf("expected ping_pong disabled, got %+v", w)
+		}
+	})
+}
+
+func TestLoopDetector_Stats(t *testing.T) {
+	d := NewLoopDetector(LoopDetectorConfig{Enabled: true, WindowSize: 3})
+	for _, name := range []string{"a", "b", "a", "c", "a"} {
+		d.Record(name, nil)
+	}
+	stats := d.Stats()
+	if stats.WindowSize != 3 {
+		t.Fatalf("expected window 3, got %d", stats.WindowSize)
+	}
+	if len(stats.Recent) != 3 || stats.Recent[0] != "a" || stats.Recent[2] != "a" {
+		t.Fatalf("unexpected recent: %v", stats.Recent)
+	}
+	if stats.ToolCounts["a"] != 2 || stats.ToolCounts["c"] != 1 || stats.ToolCounts["b"] != 0 {
+		t.Fatalf("unexpected counts: %v", stats.ToolCounts)
+	}
+}
+
 func TestAgentLoop_LoopDetected_StopsEarly(t *testing.T) {
 	callCount := 0
 	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
@@ -146,6 +218,134 @@ func TestAgentLoop_LoopDetected_StopsEarly(t *testing.T) {
 	if toolExecCount > 3 {
 		t.Fatalf("expected <= 3 tool executions, got %d", toolExecCount)
 	}
+	if result.LoopInfo == nil || result.LoopInfo.Type != "repeat" || result.LoopInfo.Tool != "search" {
+		t.Fatalf("expected LoopInfo repeat on search, got %+v", result.LoopInfo)
+	}
 
 	_ = json.Marshal // keep import
 }
+
+func newRepeatingSearchLoop(maxTurns int, execCount *int, switchAfterWarning bool) *AgentLoop {
+	warned := false
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		last := msgs[len(msgs)-1]
+		if c, _ := last["content"].(string); last["role"] == "tool" && c == "try another query" {
+			warned = true
+		}
+		if warned && switchAfterWarning {
+			return makeFinalResp("found it another way"), nil
+		}
+		return makeToolCallResp([]struct{ Name, Args string }{{"search", `{"query":"same"}`}}, ""), nil
+	}
+	reg := NewToolRegistry()
+	reg.Register(&Tool{
+		Name:       "search",
+		Parameters: []ToolParam{{Name: "query", Type: "string", Required: true}},
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			*execCount++
+			return "no results", nil
+		},
+	})
+	loop := NewAgentLoop(llm, reg, "sys", maxTurns, nil)
+	loop.LoopDetector = NewLoopDetector(LoopDetectorConfig{Enabled: true, MaxRepeatCalls: 3, WindowSize: 10})
+	return loop
+}
+
+func TestAgentLoop_OnLoopDetected_SubstituteAndStop(t *testing.T) {
+	execCount := 0
+	loop := newRepeatingSearchLoop(20, &execCount, false)
+	var got *LoopWarning
+	loop.OnLoopDetected = func(w *LoopWarning) (string, bool) {
+		got = w
+		return "Sorry, I couldn't find that.", true
+	}
+
+	result := loop.Run("find 张三", nil, "")
+	if result.StoppedReason != "loop_detected" {
+		t.Fatalf("expected loop_detected, got %s", result.StoppedReason)
+	}
+	if result.FinalOutput != "Sorry, I couldn't find that." {
+		t.Fatalf("expected substituted output, got %q", result.FinalOutput)
+	}
+	if got == nil || got.Type != "repeat" || got.Tool != "search" {
+		t.Fatalf("unexpected warning passed to callback: %+v", got)
+	}
+}
+
+func TestAgentLoop_OnLoopDetected_GuideAndContinue(t *testing.T) {
+	execCount := 0
+	loop := newRepeatingSearchLoop(20, &execCount, true)
+	calls := 0
+	loop.OnLoopDetected = func(w *LoopWarning) (string, bool) {
+		calls++
+		return "try another query", false
+	}
+
+	result := loop.Run("find 张三", nil, "")
+	if result.StoppedReason != "completed" || result.FinalOutput != "found it another way" {
+		t.Fatalf("expected completed after guidance, got %s / %q", result.StoppedReason, result.FinalOutput)
+	}
+	if calls != 1 || execCount != 3 {
+		t.Fatalf("expected 1 callback and 3 executions, got %d / %d", calls, execCount)
+	}
+	skipped := result.Turns[len(result.Turns)-2].ToolCalls[0]
+	if skipped.Error == "" || skipped.Result != "" {
+		t.Fatalf("expected skipped call to be recorded with the warning, got %+v", skipped)
+	}
+}
+
+func newNarratingLoop(maxTurns int, narrations []string, execCount *int) *AgentLoop {
+	turn := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		content := narrations[turn%len(narrations)]
+		turn++
+		// Different args each turn so the tool-call LoopDetector wouldn't fire.
+		return makeToolCallResp([]struct{ Name, Args string }{{"flaky", fmt.Sprintf(`{"n":%d}`, turn)}}, content), nil
+	}
+	reg := NewToolRegistry()
+	reg.Register(&Tool{
+		Name: "flaky",
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			*execCount++
+			return nil, fmt.Errorf("service unavailable")
+		},
+	})
+	return NewAgentLoop(llm, reg, "sys", maxTurns, nil)
+}
+
+func TestAgentLoop_ContentLoop_Stops(t *testing.T) {
+	execCount := 0
+	loop := newNarratingLoop(10, []string{"Let me try that again.", "let me  try that AGAIN."}, &execCount)
+	loop.ContentLoopThreshold = 2
+
+	result := loop.Run("do it", nil, "")
+	if result.StoppedReason != "content_loop" {
+		t.Fatalf("expected content_loop, got %s", result.StoppedReason)
+	}
+	if result.TotalTurns != 2 || execCount != 1 {
+		t.Fatalf("expected stop on 2nd turn before executing its tools, got turns=%d execs=%d", result.TotalTurns, execCount)
+	}
+	if result.FinalOutput != "let me  try that AGAIN." {
+		t.Fatalf("expected repeated content as final output, got %q", result.FinalOutput)
+	}
+}
+
+func TestAgentLoop_ContentLoop_OffOrVaried(t *testing.T) {
+	execCount := 0
+	off := newNarratingLoop(4, []string{"Retrying."}, &execCount)
+	if r := off.Run("do it", nil, ""); r.StoppedReason != "max_turns" {
+		t.Fatalf("expected max_turns with detection off, got %s", r.StoppedReason)
+	}
+
+	varied := newNarratingLoop(4, []string{"Trying A.", "Trying B."}, &execCount)
+	varied.ContentLoopThreshold = 2
+	if r := varied.Run("do it", nil, ""); r.StoppedReason != "max_turns" {
+		t.Fatalf("expected max_turns with varied content, got %s", r.StoppedReason)
+	}
+
+	blank := newNarratingLoop(4, []string{""}, &execCount)
+	blank.ContentLoopThreshold = 2
+	if r := blank.Run("do it", nil, ""); r.StoppedReason != "max_turns" {
+		t.Fatalf("blank content must not count as a loop, got %s", r.StoppedReason)
+	}
+}
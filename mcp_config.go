@@ -1,6 +1,13 @@
 package agentsdk
 
-import "path"
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"path"
+	"time"
+)
 
 // ──────────────────────────────────────────────
 // MCP Client — Configuration types
@@ -12,17 +19,39 @@ type MCPServerConfig struct {
 	Transport string // "stdio" | "http"
 
 	// Stdio configuration (Milestone 2)
-	Command string
-	Args    []string
-	Env     map[string]string
+	Command  string
+	Args     []string
+	Env      map[string]string
+	Dir      string // working directory for the child; empty = inherit
+	CleanEnv bool   // if true, the child sees only Env, not the parent environment
 
 	// HTTP configuration
-	URL     string
-	Headers map[string]string
+	URL        string
+	Headers    map[string]string
+	HTTPClient *http.Client // optional: custom client (pooling, proxy, mTLS); Timeout is then taken from the client
+	// AuthTokenFn optionally supplies a short-lived bearer token, sent as
+	// "Authorization: Bearer <token>". It is refreshed once on a 401 response.
+	AuthTokenFn func(ctx context.Context) (string, error)
 
 	// General
 	Timeout    int // seconds, default 30
 	MaxRetries int // retry count for retryable errors, default 3 (only 5xx/network/timeout, not 4xx)
+	// RetryBackoff shapes the delay between retries; the zero value keeps the
+	// default 100ms * 2^(attempt-1) with no cap and no jitter.
+	RetryBackoff MCPRetryBackoff
+
+	// LogTraffic logs every raw JSON-RPC request and response of this
+	// server's transport (bodies truncated to 1KB), for debugging.
+	LogTraffic bool
+
+	// IncludeNonTextSummary emits placeholders like "[image: image/png, 2048B]"
+	// for image/audio/resource blocks instead of dropping them from the result text.
+	IncludeNonTextSummary bool
+
+	// InlineParamSummary appends "(params: path[string, required], ...)" to each
+	// tool's Description for LLMs that benefit from an inline hint.
+	// RawJSONSchema is left untouched.
+	InlineParamSummary bool
 
 	// Tool filtering (matches original MCP tool name, NOT the injected sdk name).
 	// Supports wildcards via path.Match: read_*, list_*, dangerous_*
@@ -31,6 +60,45 @@ type MCPServerConfig struct {
 	MaxTools     int      // max tools to inject; 0 = no limit
 }
 
+// MCPRetryBackoff configures exponential retry backoff: Base * 2^(attempt-1),
+// randomized by ±Jitter and then capped at Max.
+type MCPRetryBackoff struct {
+	Base   time.Duration  // first retry delay, default 100ms
+	Max    time.Duration  // upper bound on any delay; 0 = no cap
+	Jitter float64        // 0..1 fraction of the delay to randomize by; 0 = none
+	Rand   func() float64 // optional [0,1) source for jitter, default math/rand
+}
+
+// delay returns the wait before the given retry attempt (1-based).
+func (b MCPRetryBackoff) delay(attempt int) time.Duration {
+	base := b.Base
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	d := base
+	for i := 1; i < attempt; i++ {
+		if (b.Max > 0 && d >= b.Max) || d > math.MaxInt64/2 {
+			break
+		}
+		d *= 2
+	}
+	if b.Jitter > 0 {
+		j := b.Jitter
+		if j > 1 {
+			j = 1
+		}
+		r := b.Rand
+		if r == nil {
+			r = rand.Float64
+		}
+		d = time.Duration(float64(d) * (1 - j + 2*j*r()))
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	return d
+}
+
 // MCPManagerConfig provides manager-level configuration.
 type MCPManagerConfig struct {
 	ToolPrefix string // naming template, default "mcp.{server}.{tool}"
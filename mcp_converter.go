@@ -2,7 +2,10 @@ package agentsdk
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -14,24 +17,160 @@ import (
 type mcpCallResult struct {
 	Text string         // concatenated text content for AgentLoop
 	Raw  *MCPToolResult // original structure for tracing (when TraceArgs=true)
+	// ErrorData is the JSON object parsed from the text of an IsError result,
+	// nil if the server returned a plain-text error.
+	ErrorData map[string]interface{}
+}
+
+// MCPToolError is returned by injected MCP tool handlers for a result with
+// isError set, so the agent loop records it as a failure. Data holds the JSON object parsed
+// from the error text, or nil if the server returned plain text.
+type MCPToolError struct {
+	Server string
+	Tool   string
+	Text   string // error text, with a trailing "[code=... retryable=...]" line when Data has them
+	Data   map[string]interface{}
+}
+
+func (e *MCPToolError) Error() string {
+	return e.Text
+}
+
+// Retryable returns the "retryable" flag of a structured error (top-level or
+// under "error"); ok is false when the server did not say.
+func (e *MCPToolError) Retryable() (retryable, ok bool) {
+	data := e.Data
+	if nested, isMap := data["error"].(map[string]interface{}); isMap {
+		data = nested
+	}
+	retryable, ok = data["retryable"].(bool)
+	return retryable, ok
 }
 
 // mcpResultToCallResult normalizes an MCPToolResult into text + raw.
-func mcpResultToCallResult(result *MCPToolResult) *mcpCallResult {
+// Non-text blocks are dropped unless includeNonText is set, in which case a
+// placeholder such as "[image: image/png, 2048B]" is emitted in their place.
+// Raw always keeps the original blocks.
+func mcpResultToCallResult(result *MCPToolResult, includeNonText bool) *mcpCallResult {
 	var sb strings.Builder
 	for _, c := range result.Content {
-		if c.Type == "text" && c.Text != "" {
-			if sb.Len() > 0 {
-				sb.WriteString("\n")
-			}
-			sb.WriteString(c.Text)
+		part := ""
+		switch {
+		case c.Type == "text":
+			part = c.Text
+		case includeNonText:
+			part = mcpContentPlaceholder(c)
+		}
+		if part == "" {
+			continue
 		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(part)
 	}
 	text := sb.String()
+	var errData map[string]interface{}
 	if result.IsError {
-		text = "Error: " + text
+		errData = parseMCPErrorData(text)
+		text = "Error: " + text + mcpErrorHint(errData)
+	}
+	return &mcpCallResult{Text: text, Raw: result, ErrorData: errData}
+}
+
+// parseMCPErrorData parses a JSON object error payload, returning nil for
+// plain-text errors.
+func parseMCPErrorData(text string) map[string]interface{} {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "{") {
+		return nil
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &data); err != nil {
+		return nil
 	}
-	return &mcpCallResult{Text: text, Raw: result}
+	return data
+}
+
+// mcpErrorHint renders the "code" and "retryable" fields of a structured
+// error (top-level or under "error") as a trailing line, so the model can
+// decide whether to retry. Returns "" when neither is present.
+func mcpErrorHint(data map[string]interface{}) string {
+	if data == nil {
+		return ""
+	}
+	if nested, ok := data["error"].(map[string]interface{}); ok {
+		data = nested
+	}
+	var parts []string
+	if code, ok := data["code"]; ok && code != nil {
+		parts = append(parts, fmt.Sprintf("code=%v", code))
+	}
+	if retryable, ok := data["retryable"].(bool); ok {
+		parts = append(parts, fmt.Sprintf("retryable=%t", retryable))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "\n[" + strings.Join(parts, " ") + "]"
+}
+
+// MCPResultToMultimodal converts an MCPToolResult into OpenAI-style content
+// parts for multimodal LLMs: text blocks become {"type":"text"}, images become
+// {"type":"image_url"} data URLs, audio becomes {"type":"input_audio"}, and
+// anything else falls back to a text placeholder.
+//
+// Injected MCP tools return text only; use this with MCPClient.CallTool when
+// the model should see images or audio directly.
+func MCPResultToMultimodal(result *MCPToolResult) []map[string]interface{} {
+	parts := make([]map[string]interface{}, 0, len(result.Content))
+	for _, c := range result.Content {
+		switch {
+		case c.Type == "text":
+			if c.Text != "" {
+				parts = append(parts, map[string]interface{}{"type": "text", "text": c.Text})
+			}
+		case c.Type == "image" && c.Data != "":
+			parts = append(parts, map[string]interface{}{
+				"type":      "image_url",
+				"image_url": map[string]interface{}{"url": "data:" + c.MimeType + ";base64," + c.Data},
+			})
+		case c.Type == "audio" && c.Data != "":
+			format := strings.TrimPrefix(c.MimeType, "audio/")
+			parts = append(parts, map[string]interface{}{
+				"type":        "input_audio",
+				"input_audio": map[string]interface{}{"data": c.Data, "format": format},
+			})
+		default:
+			parts = append(parts, map[string]interface{}{"type": "text", "text": mcpContentPlaceholder(c)})
+		}
+	}
+	return parts
+}
+
+// mcpContentPlaceholder describes a non-text block, e.g. "[image: image/png, 2048B]".
+func mcpContentPlaceholder(c MCPContent) string {
+	mime, data := c.MimeType, c.Data
+	if c.Resource != nil {
+		if c.Resource.Text != "" {
+			return c.Resource.Text
+		}
+		mime, data = c.Resource.MimeType, c.Resource.Blob
+		if mime == "" {
+			mime = c.Resource.URI
+		}
+	}
+	if mime == "" {
+		mime = "unknown"
+	}
+	return fmt.Sprintf("[%s: %s, %dB]", c.Type, mime, base64DecodedLen(data))
+}
+
+func base64DecodedLen(data string) int {
+	if decoded, err := base64.StdEncoding.DecodeString(data); err == nil {
+		return len(decoded)
+	}
+	return len(data) * 3 / 4
 }
 
 // mcpToolName generates the injected SDK tool name: mcp.{server}.{tool}.
@@ -114,13 +253,24 @@ func ConvertMCPTools(
 			if ctx != nil && ctx.Ctx != nil {
 				callCtx = ctx.Ctx
 			}
+			if ctx != nil && ctx.Span != nil {
+				callCtx = withToolSpan(callCtx, ctx.Span)
+			}
 			return callFn(callCtx, originalName, args)
 		}
 
+		params := extractToolParams(mt.InputSchema)
+		description := fmt.Sprintf("[MCP:%s] %s", serverName, mt.Description)
+		if config != nil && config.InlineParamSummary {
+			if summary := mcpParamsSummary(params); summary != "" {
+				description += " " + summary
+			}
+		}
+
 		tool := &Tool{
 			Name:          sdkName,
-			Description:   fmt.Sprintf("[MCP:%s] %s", serverName, mt.Description),
-			Parameters:    extractToolParams(mt.InputSchema),
+			Description:   description,
+			Parameters:    params,
 			RawJSONSchema: mt.InputSchema,
 			Handler:       handler,
 		}
@@ -133,3 +283,31 @@ func ConvertMCPTools(
 
 	return tools
 }
+
+// mcpParamsSummary renders a compact hint such as
+// "(params: path[string, required], encoding[string])".
+// Required params come first, then alphabetical, so the output is stable.
+func mcpParamsSummary(params []ToolParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	sorted := append([]ToolParam(nil), params...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Required != sorted[j].Required {
+			return sorted[i].Required
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	parts := make([]string, len(sorted))
+	for i, p := range sorted {
+		typ := p.Type
+		if typ == "" {
+			typ = "any"
+		}
+		if p.Required {
+			typ += ", required"
+		}
+		parts[i] = p.Name + "[" + typ + "]"
+	}
+	return "(params: " + strings.Join(parts, ", ") + ")"
+}
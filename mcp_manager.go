@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
 	"strings"
 	"sync"
 	"time"
@@ -60,9 +59,27 @@ func (m *MCPManager) AddServer(ctx context.Context, config MCPServerConfig) erro
 	var transport MCPTransport
 	switch config.Transport {
 	case "http":
-		transport = NewHTTPTransport(config.URL, config.Headers, timeout)
+		var ht *HTTPTransport
+		if config.HTTPClient != nil {
+			ht = NewHTTPTransportWithClient(config.URL, config.Headers, config.HTTPClient)
+		} else {
+			ht = NewHTTPTransport(config.URL, config.Headers, timeout)
+		}
+		if config.AuthTokenFn != nil {
+			ht.WithAuthTokenFn(config.AuthTokenFn)
+		}
+		if config.LogTraffic {
+			ht.WithTrafficLogging(0)
+		}
+		transport = ht
 	case "stdio":
-		transport = NewStdioTransport(config.Command, config.Args, config.Env, timeout)
+		st := NewStdioTransport(config.Command, config.Args, config.Env, timeout)
+		st.Dir = config.Dir
+		st.CleanEnv = config.CleanEnv
+		if config.LogTraffic {
+			st.WithTrafficLogging(0)
+		}
+		transport = st
 	default:
 		// Allow custom transports passed via AddServerWithTransport
 		return fmt.Errorf("mcp: unsupported transport: %q", config.Transport)
@@ -178,7 +195,8 @@ func (m *MCPManager) RemoveTools(registry *ToolRegistry) {
 // ── Tool Invocation ──
 
 // CallTool routes a call by SDK tool name to the correct server.
-// This is typically called by the injected Tool's Handler closure.
+// A result with isError set is returned as an "Error: ..." string with a nil
+// error; only the injected tool handlers surface it as *MCPToolError.
 func (m *MCPManager) CallTool(ctx context.Context, sdkToolName string, args map[string]interface{}) (interface{}, error) {
 	m.mu.RLock()
 	serverName, ok := m.toolMap[sdkToolName]
@@ -197,7 +215,12 @@ func (m *MCPManager) CallTool(ctx context.Context, sdkToolName string, args map[
 	prefix := "mcp." + serverName + "."
 	originalName := strings.TrimPrefix(sdkToolName, prefix)
 
-	return m.callToolDirect(ctx, serverName, originalName, args, maxRetries)
+	out, err := m.callToolDirect(ctx, serverName, originalName, args, maxRetries)
+	var toolErr *MCPToolError
+	if errors.As(err, &toolErr) {
+		return "Error: " + toolErr.Text, nil
+	}
+	return out, err
 }
 
 // callToolDirect calls a specific server's tool with retry logic for retryable errors.
@@ -212,11 +235,15 @@ func (m *MCPManager) callToolDirect(ctx context.Context, serverName, toolName st
 	var lastErr error
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
-			backoff := time.Duration(math.Pow(2, float64(attempt-1))) * 100 * time.Millisecond
+			if span := toolSpanFromContext(ctx); span != nil {
+				span.AddEvent("mcp_retry", map[string]interface{}{"attempt": attempt, "error": lastErr.Error()})
+			}
+			timer := time.NewTimer(conn.config.RetryBackoff.delay(attempt))
 			select {
 			case <-ctx.Done():
+				timer.Stop()
 				return nil, ctx.Err()
-			case <-time.After(backoff):
+			case <-timer.C:
 			}
 		}
 
@@ -230,7 +257,15 @@ func (m *MCPManager) callToolDirect(ctx context.Context, serverName, toolName st
 			return nil, err
 		}
 
-		cr := mcpResultToCallResult(result)
+		cr := mcpResultToCallResult(result, conn.config.IncludeNonTextSummary)
+		if result.IsError {
+			return nil, &MCPToolError{
+				Server: serverName,
+				Tool:   toolName,
+				Text:   strings.TrimPrefix(cr.Text, "Error: "),
+				Data:   cr.ErrorData,
+			}
+		}
 		return cr.Text, nil
 	}
 
@@ -337,3 +372,22 @@ func (m *MCPManager) ServerNames() []string {
 	}
 	return names
 }
+
+// ToolRouting returns a copy of the SDK tool name → server name routing map.
+func (m *MCPManager) ToolRouting() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	routing := make(map[string]string, len(m.toolMap))
+	for tool, server := range m.toolMap {
+		routing[tool] = server
+	}
+	return routing
+}
+
+// InjectedToolNames returns the names of the tools currently injected into a
+// ToolRegistry by InjectTools.
+func (m *MCPManager) InjectedToolNames() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.injectedTools...)
+}
@@ -60,14 +60,66 @@ type MCPToolResult struct {
 
 // MCPContent is a single content block in an MCP tool result.
 type MCPContent struct {
-	Type string `json:"type"` // "text", "image", etc.
-	Text string `json:"text,omitempty"`
+	Type     string              `json:"type"` // "text", "image", "audio", "resource"
+	Text     string              `json:"text,omitempty"`
+	Data     string              `json:"data,omitempty"`     // base64 payload for image/audio
+	MimeType string              `json:"mimeType,omitempty"` // e.g. "image/png"
+	Resource *MCPResourceContent `json:"resource,omitempty"` // embedded resource
+}
+
+// MCPResourceContent is an embedded resource in an MCP content block.
+type MCPResourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"` // base64
 }
 
 // MCPInitResult is the response from MCP initialize.
 type MCPInitResult struct {
-	ProtocolVersion string        `json:"protocolVersion"`
-	ServerInfo      MCPServerInfo `json:"serverInfo"`
+	ProtocolVersion string                `json:"protocolVersion"`
+	Capabilities    MCPServerCapabilities `json:"capabilities"`
+	ServerInfo      MCPServerInfo         `json:"serverInfo"`
+}
+
+// MCPCapability is a single declared capability. A non-nil *MCPCapability
+// means the feature is supported; the flags refine it.
+type MCPCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+	Subscribe   bool `json:"subscribe,omitempty"`
+}
+
+// MCPServerCapabilities lists the features a server declared in initialize.
+type MCPServerCapabilities struct {
+	Tools     *MCPCapability `json:"tools,omitempty"`
+	Resources *MCPCapability `json:"resources,omitempty"`
+	Prompts   *MCPCapability `json:"prompts,omitempty"`
+	Logging   *MCPCapability `json:"logging,omitempty"`
+}
+
+// MCPClientCapabilities lists the features the client declares in
+// initialize. Only declare what the host application actually serves.
+type MCPClientCapabilities struct {
+	Roots    *MCPCapability `json:"roots,omitempty"`
+	Sampling *MCPCapability `json:"sampling,omitempty"`
+}
+
+// MCPProtocolVersion is the protocol revision the client requests.
+const MCPProtocolVersion = "2024-11-05"
+
+// MCPSupportedProtocolVersions lists the revisions the client accepts from a server.
+var MCPSupportedProtocolVersions = []string{"2024-11-05", "2025-03-26", "2025-06-18"}
+
+// MCPVersionError is returned by Initialize when the server answers with a
+// protocol revision the client does not support.
+type MCPVersionError struct {
+	Requested string
+	Server    string
+	Supported []string
+}
+
+func (e *MCPVersionError) Error() string {
+	return fmt.Sprintf("mcp: unsupported protocol version %q (requested %q, supported %v)", e.Server, e.Requested, e.Supported)
 }
 
 // MCPServerInfo describes the MCP server identity.
@@ -80,8 +132,12 @@ type MCPServerInfo struct {
 
 // MCPClient wraps a transport and provides typed MCP protocol methods.
 type MCPClient struct {
+	// Capabilities is declared to the server during Initialize.
+	Capabilities MCPClientCapabilities
+
 	transport MCPTransport
 	nextID    atomic.Int64
+	init      *MCPInitResult // negotiated result, nil until Initialize succeeds
 }
 
 // NewMCPClient creates a new MCP client over the given transport.
@@ -120,23 +176,73 @@ func (c *MCPClient) call(ctx context.Context, method string, params interface{},
 	return nil
 }
 
-// Initialize performs the MCP handshake.
+// Initialize performs the MCP handshake, declaring the client's protocol
+// version and Capabilities. It returns *MCPVersionError if the server
+// answers with an unsupported revision. A server that omits the version is
+// accepted for compatibility with older implementations.
 func (c *MCPClient) Initialize(ctx context.Context) (*MCPInitResult, error) {
 	params := map[string]interface{}{
-		"protocolVersion": "2024-11-05",
-		"capabilities":    map[string]interface{}{},
+		"protocolVersion": MCPProtocolVersion,
+		"capabilities":    c.Capabilities,
 		"clientInfo": map[string]string{
 			"name":    "zapry-agents-sdk-go",
-			"version": "1.0.0",
+			"version": Version,
 		},
 	}
 	var result MCPInitResult
 	if err := c.call(ctx, "initialize", params, &result); err != nil {
 		return nil, err
 	}
+	if result.ProtocolVersion != "" && !mcpVersionSupported(result.ProtocolVersion) {
+		return nil, &MCPVersionError{
+			Requested: MCPProtocolVersion,
+			Server:    result.ProtocolVersion,
+			Supported: MCPSupportedProtocolVersions,
+		}
+	}
+	c.init = &result
 	return &result, nil
 }
 
+func mcpVersionSupported(v string) bool {
+	for _, s := range MCPSupportedProtocolVersions {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerCapabilities returns the capabilities negotiated in Initialize,
+// or nil if the client has not been initialized.
+func (c *MCPClient) ServerCapabilities() *MCPServerCapabilities {
+	if c.init == nil {
+		return nil
+	}
+	return &c.init.Capabilities
+}
+
+// Supports reports whether the server declared the named capability
+// ("tools", "resources", "prompts", "logging"). Use it to pre-check
+// optional features before calling them.
+func (c *MCPClient) Supports(capability string) bool {
+	caps := c.ServerCapabilities()
+	if caps == nil {
+		return false
+	}
+	switch capability {
+	case "tools":
+		return caps.Tools != nil
+	case "resources":
+		return caps.Resources != nil
+	case "prompts":
+		return caps.Prompts != nil
+	case "logging":
+		return caps.Logging != nil
+	}
+	return false
+}
+
 // ListTools discovers available tools from the MCP server.
 // Handles both {tools:[...]} (standard) and bare [...] response formats.
 func (c *MCPClient) ListTools(ctx context.Context) ([]MCPToolDef, error) {
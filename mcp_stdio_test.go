@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -35,6 +37,8 @@ func init() {
 		runStdioCrashServer()
 	case "large_response":
 		runStdioLargeResponseServer()
+	case "out_of_order":
+		runStdioOutOfOrderServer()
 	}
 	os.Exit(0)
 }
@@ -84,6 +88,12 @@ func runStdioEchoServer() {
 			if m, ok := params.Arguments["msg"].(string); ok {
 				msg = m
 			}
+			if name, ok := params.Arguments["env"].(string); ok {
+				msg = os.Getenv(name)
+			}
+			if params.Arguments["cwd"] == true {
+				msg, _ = os.Getwd()
+			}
 			result := MCPToolResult{Content: []MCPContent{{Type: "text", Text: msg}}}
 			rb, _ := json.Marshal(result)
 			resp := jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(rb)}
@@ -162,6 +172,41 @@ func runStdioLargeResponseServer() {
 	}
 }
 
+// out_of_order: answers initialize at once, but holds tools/call requests
+// until two have arrived, then sends a notification followed by both
+// responses in reverse order.
+func runStdioOutOfOrderServer() {
+	scanner := bufio.NewScanner(os.Stdin)
+	var held []jsonRPCRequest
+	for scanner.Scan() {
+		var req jsonRPCRequest
+		json.Unmarshal([]byte(scanner.Text()), &req)
+		if req.Method == "initialize" {
+			rb, _ := json.Marshal(MCPInitResult{ProtocolVersion: "2024-11-05", ServerInfo: MCPServerInfo{Name: "ooo", Version: "1.0"}})
+			b, _ := json.Marshal(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(rb)})
+			fmt.Println(string(b))
+			continue
+		}
+		held = append(held, req)
+		if len(held) < 2 {
+			continue
+		}
+		fmt.Println(`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progress":1}}`)
+		for i := len(held) - 1; i >= 0; i-- {
+			var params struct {
+				Arguments map[string]interface{} `json:"arguments"`
+			}
+			pb, _ := json.Marshal(held[i].Params)
+			json.Unmarshal(pb, &params)
+			msg, _ := params.Arguments["msg"].(string)
+			rb, _ := json.Marshal(MCPToolResult{Content: []MCPContent{{Type: "text", Text: msg}}})
+			b, _ := json.Marshal(jsonRPCResponse{JSONRPC: "2.0", ID: held[i].ID, Result: json.RawMessage(rb)})
+			fmt.Println(string(b))
+		}
+		held = nil
+	}
+}
+
 // testBinary returns the path to the current test binary.
 func testBinary(t *testing.T) string {
 	t.Helper()
@@ -189,6 +234,9 @@ func TestStdioTransport_Call(t *testing.T) {
 	}
 
 	transport := newStdioTestTransport(t, "echo")
+	var sent, received []string
+	transport.OnRequest = func(line []byte) { sent = append(sent, string(line)) }
+	transport.OnResponse = func(line []byte) { received = append(received, string(line)) }
 	ctx := context.Background()
 
 	if err := transport.Start(ctx); err != nil {
@@ -224,6 +272,17 @@ func TestStdioTransport_Call(t *testing.T) {
 	if len(result.Content) != 1 || result.Content[0].Text != "hello world" {
 		t.Fatalf("unexpected result: %+v", result)
 	}
+
+	// Traffic hooks saw every request line and its response line.
+	if len(sent) == 0 || len(sent) != len(received) {
+		t.Fatalf("expected paired traffic, got %d sent / %d received", len(sent), len(received))
+	}
+	if last := sent[len(sent)-1]; !strings.Contains(last, `"tools/call"`) || !strings.Contains(last, "hello world") {
+		t.Fatalf("unexpected last request line: %s", last)
+	}
+	if last := received[len(received)-1]; !strings.Contains(last, "hello world") {
+		t.Fatalf("unexpected last response line: %s", last)
+	}
 }
 
 func TestStdioTransport_ProcessExit(t *testing.T) {
@@ -365,3 +424,100 @@ func TestStdioTransport_CancelNoLeak(t *testing.T) {
 		t.Fatalf("unexpected result: %+v", result)
 	}
 }
+
+func TestStdioTransport_CleanEnvAndDir(t *testing.T) {
+	t.Setenv("MCP_STDIO_PARENT_ONLY", "leaked")
+	dir := t.TempDir()
+
+	transport := NewStdioTransport(testBinary(t), []string{"-test.run=^$"},
+		map[string]string{"MCP_STDIO_TEST_MODE": "echo", "MCP_STDIO_CHILD": "set"}, 10*time.Second)
+	transport.Dir = dir
+	transport.CleanEnv = true
+
+	ctx := context.Background()
+	if err := transport.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer transport.Close()
+
+	client := NewMCPClient(transport)
+	if _, err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	call := func(args map[string]interface{}) string {
+		t.Helper()
+		result, err := client.CallTool(ctx, "echo", args)
+		if err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+		if len(result.Content) != 1 {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+		return result.Content[0].Text
+	}
+
+	if got := call(map[string]interface{}{"env": "MCP_STDIO_CHILD"}); got != "set" {
+		t.Fatalf("provided env should reach the child, got %q", got)
+	}
+	if got := call(map[string]interface{}{"env": "MCP_STDIO_PARENT_ONLY"}); got != "" {
+		t.Fatalf("parent env should not leak with CleanEnv, got %q", got)
+	}
+
+	wantDir, _ := filepath.EvalSymlinks(dir)
+	gotDir, _ := filepath.EvalSymlinks(call(map[string]interface{}{"cwd": true}))
+	if gotDir != wantDir {
+		t.Fatalf("expected cwd %q, got %q", wantDir, gotDir)
+	}
+}
+
+func TestStdioTransport_OutOfOrderResponses(t *testing.T) {
+	transport := newStdioTestTransport(t, "out_of_order")
+	ctx := context.Background()
+
+	if err := transport.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer transport.Close()
+
+	client := NewMCPClient(transport)
+	if _, err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	msgs := []string{"first", "second"}
+	got := make([]string, len(msgs))
+	errs := make([]error, len(msgs))
+	var wg sync.WaitGroup
+	for i, msg := range msgs {
+		wg.Add(1)
+		go func(i int, msg string) {
+			defer wg.Done()
+			result, err := client.CallTool(ctx, "echo", map[string]interface{}{"msg": msg})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			got[i] = result.Content[0].Text
+		}(i, msg)
+	}
+	wg.Wait()
+
+	for i, msg := range msgs {
+		if errs[i] != nil {
+			t.Fatalf("call %d failed: %v", i, errs[i])
+		}
+		if got[i] != msg {
+			t.Fatalf("call %d: expected %q, got %q (responses mixed up)", i, msg, got[i])
+		}
+	}
+
+	select {
+	case line := <-transport.Notifications():
+		if !strings.Contains(string(line), "notifications/progress") {
+			t.Fatalf("unexpected notification: %s", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the interleaved notification on Notifications()")
+	}
+}
@@ -2,10 +2,16 @@ package agentsdk
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -209,6 +215,80 @@ func TestMCPClient_Initialize(t *testing.T) {
 	}
 }
 
+// newInitOnlyTransport answers initialize with the given version and
+// capabilities, and records the params the client sent.
+func newInitOnlyTransport(version string, caps MCPServerCapabilities, sent *map[string]interface{}) *InProcessTransport {
+	return NewInProcessTransport(func(request []byte) ([]byte, error) {
+		var req struct {
+			ID     int64                  `json:"id"`
+			Params map[string]interface{} `json:"params"`
+		}
+		json.Unmarshal(request, &req)
+		*sent = req.Params
+		rb, _ := json.Marshal(MCPInitResult{
+			ProtocolVersion: version,
+			Capabilities:    caps,
+			ServerInfo:      MCPServerInfo{Name: "neg", Version: "1.0"},
+		})
+		return json.Marshal(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(rb)})
+	})
+}
+
+func TestMCPClient_Initialize_Negotiation(t *testing.T) {
+	var sent map[string]interface{}
+	caps := MCPServerCapabilities{Tools: &MCPCapability{ListChanged: true}, Prompts: &MCPCapability{}}
+	client := NewMCPClient(newInitOnlyTransport("2024-11-05", caps, &sent))
+	client.Capabilities.Roots = &MCPCapability{}
+
+	if client.Supports("tools") {
+		t.Fatal("capabilities should be unknown before Initialize")
+	}
+	if _, err := client.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent["protocolVersion"] != MCPProtocolVersion {
+		t.Fatalf("expected protocolVersion %s, got %v", MCPProtocolVersion, sent["protocolVersion"])
+	}
+	declared, _ := sent["capabilities"].(map[string]interface{})
+	if _, ok := declared["roots"]; !ok {
+		t.Fatalf("client capabilities should be declared, got %v", sent["capabilities"])
+	}
+	if _, ok := declared["sampling"]; ok {
+		t.Fatal("undeclared sampling capability should be omitted")
+	}
+	if info, _ := sent["clientInfo"].(map[string]interface{}); info["name"] != "zapry-agents-sdk-go" {
+		t.Fatalf("unexpected clientInfo: %v", sent["clientInfo"])
+	}
+
+	if !client.Supports("tools") || !client.Supports("prompts") {
+		t.Fatal("declared server capabilities should be supported")
+	}
+	if client.Supports("resources") {
+		t.Fatal("undeclared resources capability should not be supported")
+	}
+	if !client.ServerCapabilities().Tools.ListChanged {
+		t.Fatal("capability sub-flags should be kept")
+	}
+}
+
+func TestMCPClient_Initialize_UnsupportedVersion(t *testing.T) {
+	var sent map[string]interface{}
+	client := NewMCPClient(newInitOnlyTransport("1999-01-01", MCPServerCapabilities{}, &sent))
+
+	_, err := client.Initialize(context.Background())
+	var verErr *MCPVersionError
+	if !errors.As(err, &verErr) {
+		t.Fatalf("expected MCPVersionError, got %v", err)
+	}
+	if verErr.Server != "1999-01-01" || verErr.Requested != MCPProtocolVersion {
+		t.Fatalf("unexpected version error: %+v", verErr)
+	}
+	if client.ServerCapabilities() != nil {
+		t.Fatal("failed negotiation should not store capabilities")
+	}
+}
+
 func TestMCPClient_ListTools_WrappedFormat(t *testing.T) {
 	tools := standardMockTools()
 	transport := newMockMCPTransport(tools, nil)
@@ -348,6 +428,28 @@ func TestConvertMCPTools_Basic(t *testing.T) {
 	}
 }
 
+func TestConvertMCPTools_InlineParamSummary(t *testing.T) {
+	callFn := func(ctx context.Context, name string, args map[string]interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	tools := ConvertMCPTools("fs", standardMockTools(), callFn, &MCPServerConfig{InlineParamSummary: true})
+
+	if want := "[MCP:fs] Read contents of a file (params: path[string, required])"; tools[0].Description != want {
+		t.Fatalf("expected %q, got %q", want, tools[0].Description)
+	}
+	if want := "(params: content[string, required], path[string, required])"; !strings.HasSuffix(tools[2].Description, want) {
+		t.Fatalf("expected write_file summary %q, got %q", want, tools[2].Description)
+	}
+	if _, ok := tools[0].RawJSONSchema["description"]; ok {
+		t.Fatal("RawJSONSchema must not be altered")
+	}
+
+	plain := ConvertMCPTools("fs", standardMockTools(), callFn, nil)
+	if strings.Contains(plain[0].Description, "(params:") {
+		t.Fatal("summary should be opt-in")
+	}
+}
+
 func TestConvertMCPTools_RawSchemaPreserved(t *testing.T) {
 	mcpTools := standardMockTools()
 	callFn := func(ctx context.Context, name string, args map[string]interface{}) (interface{}, error) {
@@ -511,7 +613,7 @@ func TestMCPResultToCallResult(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cr := mcpResultToCallResult(tt.result)
+			cr := mcpResultToCallResult(tt.result, false)
 			if cr.Text != tt.expected {
 				t.Fatalf("expected text=%q, got %q", tt.expected, cr.Text)
 			}
@@ -522,6 +624,75 @@ func TestMCPResultToCallResult(t *testing.T) {
 	}
 }
 
+func TestMCPResultToCallResult_NonTextSummary(t *testing.T) {
+	png := base64.StdEncoding.EncodeToString(make([]byte, 2048))
+
+	mixed := &MCPToolResult{Content: []MCPContent{
+		{Type: "text", Text: "Here is the chart"},
+		{Type: "image", Data: png, MimeType: "image/png"},
+	}}
+	cr := mcpResultToCallResult(mixed, true)
+	if cr.Text != "Here is the chart\n[image: image/png, 2048B]" {
+		t.Fatalf("unexpected text: %q", cr.Text)
+	}
+	if cr.Raw != mixed || cr.Raw.Content[1].Data != png {
+		t.Fatal("raw blocks should be preserved")
+	}
+
+	pure := &MCPToolResult{Content: []MCPContent{{Type: "image", Data: png, MimeType: "image/jpeg"}}}
+	if cr := mcpResultToCallResult(pure, true); cr.Text != "[image: image/jpeg, 2048B]" {
+		t.Fatalf("unexpected pure-image text: %q", cr.Text)
+	}
+	if cr := mcpResultToCallResult(pure, false); cr.Text != "" {
+		t.Fatalf("expected image dropped without summary option, got %q", cr.Text)
+	}
+}
+
+func TestMCPResultToMultimodal(t *testing.T) {
+	result := &MCPToolResult{Content: []MCPContent{
+		{Type: "text", Text: "chart:"},
+		{Type: "image", Data: "aGVsbG8=", MimeType: "image/png"},
+		{Type: "resource", Resource: &MCPResourceContent{URI: "file:///a.bin", MimeType: "application/octet-stream", Blob: "aGVsbG8="}},
+	}}
+	parts := MCPResultToMultimodal(result)
+	if len(parts) != 3 {
+		t.Fatalf("expected 3 parts, got %d", len(parts))
+	}
+	if parts[0]["type"] != "text" || parts[0]["text"] != "chart:" {
+		t.Fatalf("unexpected text part: %v", parts[0])
+	}
+	img, _ := parts[1]["image_url"].(map[string]interface{})
+	if parts[1]["type"] != "image_url" || img["url"] != "data:image/png;base64,aGVsbG8=" {
+		t.Fatalf("unexpected image part: %v", parts[1])
+	}
+	if parts[2]["text"] != "[resource: application/octet-stream, 5B]" {
+		t.Fatalf("unexpected resource placeholder: %v", parts[2])
+	}
+}
+
+func TestMCPManager_IncludeNonTextSummary(t *testing.T) {
+	mgr := NewMCPManager()
+	transport := newMockMCPTransport(
+		[]MCPToolDef{{Name: "plot", Description: "Plot"}},
+		func(name string, args map[string]interface{}) (*MCPToolResult, error) {
+			return &MCPToolResult{Content: []MCPContent{{Type: "image", Data: "aGVsbG8=", MimeType: "image/png"}}}, nil
+		},
+	)
+	err := mgr.AddServerWithTransport(context.Background(), MCPServerConfig{
+		Name: "charts", Transport: "custom", IncludeNonTextSummary: true,
+	}, transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := mgr.CallTool(context.Background(), "mcp.charts.plot", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "[image: image/png, 5B]" {
+		t.Fatalf("unexpected output: %v", out)
+	}
+}
+
 // ══════════════════════════════════════════════
 // MCPManager tests
 // ══════════════════════════════════════════════
@@ -680,6 +851,54 @@ func TestMCPManager_MultiServer(t *testing.T) {
 	}
 }
 
+func TestMCPManager_ToolRoutingAndInjectedNames(t *testing.T) {
+	mgr := NewMCPManager()
+	addMockServer(t, mgr, "fs", standardMockTools(), standardCallHandler)
+	addMockServer(t, mgr, "db", []MCPToolDef{
+		{Name: "query", Description: "Run SQL", InputSchema: map[string]interface{}{"type": "object"}},
+	}, standardCallHandler)
+
+	routing := mgr.ToolRouting()
+	want := map[string]string{
+		"mcp.fs.read_file":  "fs",
+		"mcp.fs.list_files": "fs",
+		"mcp.fs.write_file": "fs",
+		"mcp.db.query":      "db",
+	}
+	if len(routing) != len(want) {
+		t.Fatalf("expected %d routes, got %v", len(want), routing)
+	}
+	for tool, server := range want {
+		if routing[tool] != server {
+			t.Fatalf("expected %s -> %s, got %q", tool, server, routing[tool])
+		}
+	}
+	routing["mcp.fs.read_file"] = "tampered"
+	if mgr.ToolRouting()["mcp.fs.read_file"] != "fs" {
+		t.Fatal("ToolRouting must return a copy")
+	}
+
+	if names := mgr.InjectedToolNames(); len(names) != 0 {
+		t.Fatalf("expected no injected tools before InjectTools, got %v", names)
+	}
+	registry := NewToolRegistry()
+	mgr.InjectTools(registry)
+	names := mgr.InjectedToolNames()
+	sort.Strings(names)
+	if strings.Join(names, ",") != "mcp.db.query,mcp.fs.list_files,mcp.fs.read_file,mcp.fs.write_file" {
+		t.Fatalf("unexpected injected names: %v", names)
+	}
+
+	mgr.RemoveServer("db")
+	if _, ok := mgr.ToolRouting()["mcp.db.query"]; ok {
+		t.Fatal("routing should drop tools of removed server")
+	}
+	mgr.RemoveTools(registry)
+	if names := mgr.InjectedToolNames(); len(names) != 0 {
+		t.Fatalf("expected no injected tools after RemoveTools, got %v", names)
+	}
+}
+
 func TestMCPManager_ToolNameConflict(t *testing.T) {
 	mgr := NewMCPManager()
 	// Both servers have a tool named "read_file"
@@ -911,6 +1130,17 @@ func TestMCPManager_CallTool_Retry(t *testing.T) {
 	if attempts != 3 {
 		t.Fatalf("expected 3 attempts, got %d", attempts)
 	}
+
+	// Through the injected tool, retries show up as events on the tool span.
+	attempts = 0
+	span := &TracingSpan{Name: "tool:mcp.r.flaky", Kind: SpanKindTool}
+	tool := mgr.ListTools("r")[0]
+	if _, err := tool.Handler(&ToolContext{Ctx: context.Background(), Span: span}, nil); err != nil {
+		t.Fatalf("expected success after retries, got: %v", err)
+	}
+	if len(span.Events) != 2 || span.Events[0].Name != "mcp_retry" || span.Events[1].Attributes["attempt"] != 2 {
+		t.Fatalf("expected 2 mcp_retry events, got %+v", span.Events)
+	}
 }
 
 // ══════════════════════════════════════════════
@@ -1035,3 +1265,350 @@ func TestHTTPTransport_NonOK_StatusCode(t *testing.T) {
 		t.Fatalf("error should contain status code: %s", err.Error())
 	}
 }
+
+func TestInProcessTransport_HandlerPanicRecovered(t *testing.T) {
+	transport := NewInProcessTransport(func(request []byte) ([]byte, error) {
+		panic("boom")
+	})
+	_, err := transport.Call(context.Background(), []byte(`{}`))
+	if err == nil {
+		t.Fatal("expected error from panicking handler")
+	}
+	if !strings.Contains(err.Error(), "mcp: handler panic: boom") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestInProcessTransport_ContextCancelledDuringCall(t *testing.T) {
+	exited := make(chan struct{})
+	transport := NewInProcessTransportContext(func(ctx context.Context, request []byte) ([]byte, error) {
+		defer close(exited)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := transport.Call(ctx, []byte(`{}`))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("context-aware handler should exit after cancellation")
+	}
+}
+
+type countingRoundTripper struct {
+	calls int32
+	next  http.RoundTripper
+}
+
+func (c *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return c.next.RoundTrip(req)
+}
+
+// newMockMCPHTTPServer serves the mock MCP protocol over HTTP.
+func newMockMCPHTTPServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	mock := newMockMCPTransport(
+		[]MCPToolDef{{Name: "echo", Description: "Echo"}},
+		func(name string, args map[string]interface{}) (*MCPToolResult, error) {
+			return &MCPToolResult{Content: []MCPContent{{Type: "text", Text: "ok"}}}, nil
+		},
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler != nil {
+			handler(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		resp, err := mock.Call(r.Context(), body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHTTPTransport_CustomClientUsed(t *testing.T) {
+	srv := newMockMCPHTTPServer(t, nil)
+	rt := &countingRoundTripper{next: http.DefaultTransport}
+	client := &http.Client{Transport: rt, Timeout: 5 * time.Second}
+
+	mgr := NewMCPManager()
+	err := mgr.AddServer(context.Background(), MCPServerConfig{
+		Name:       "remote",
+		Transport:  "http",
+		URL:        srv.URL,
+		HTTPClient: client,
+	})
+	if err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&rt.calls); n < 2 {
+		t.Fatalf("expected custom client to carry initialize + tools/list, got %d calls", n)
+	}
+
+	tr := NewHTTPTransportWithClient(srv.URL, nil, client)
+	if tr.client != client || tr.timeout != 5*time.Second {
+		t.Fatal("expected transport to keep the provided client and its timeout")
+	}
+}
+
+func TestHTTPTransport_AuthTokenRefreshOn401(t *testing.T) {
+	var current atomic.Value
+	current.Store("token-1")
+	var unauthorized int32
+	mockSrv := newMockMCPHTTPServer(t, nil)
+
+	srv := newMockMCPHTTPServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+current.Load().(string) {
+			atomic.AddInt32(&unauthorized, 1)
+			http.Error(w, "token expired", http.StatusUnauthorized)
+			return
+		}
+		resp, err := http.Post(mockSrv.URL, "application/json", r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		io.Copy(w, resp.Body)
+	})
+
+	var fetches int32
+	tokenFn := func(ctx context.Context) (string, error) {
+		atomic.AddInt32(&fetches, 1)
+		return current.Load().(string), nil
+	}
+
+	mgr := NewMCPManager()
+	err := mgr.AddServer(context.Background(), MCPServerConfig{
+		Name:        "secure",
+		Transport:   "http",
+		URL:         srv.URL,
+		AuthTokenFn: tokenFn,
+	})
+	if err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Fatalf("expected token fetched once and cached, got %d fetches", n)
+	}
+
+	// Rotate the token server-side: the cached one is now stale.
+	current.Store("token-2")
+	if _, err := mgr.CallTool(context.Background(), "mcp.secure.echo", nil); err != nil {
+		t.Fatalf("expected call to succeed after refresh, got %v", err)
+	}
+	if n := atomic.LoadInt32(&unauthorized); n != 1 {
+		t.Fatalf("expected exactly one 401, got %d", n)
+	}
+	if n := atomic.LoadInt32(&fetches); n != 2 {
+		t.Fatalf("expected token refreshed once, got %d fetches", n)
+	}
+}
+
+func TestHTTPTransport_AuthTokenPersistent401(t *testing.T) {
+	var requests int32
+	srv := newMockMCPHTTPServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.Error(w, "nope", http.StatusUnauthorized)
+	})
+	tr := NewHTTPTransport(srv.URL, nil, time.Second).WithAuthTokenFn(func(ctx context.Context) (string, error) {
+		return "bad", nil
+	})
+	_, err := tr.Call(context.Background(), []byte(`{}`))
+	var te *MCPTransportError
+	if !errors.As(err, &te) || te.StatusCode != 401 {
+		t.Fatalf("expected 401 transport error, got %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("expected one retry (2 requests), got %d", n)
+	}
+}
+
+func TestMCPResultToCallResult_StructuredError(t *testing.T) {
+	result := &MCPToolResult{
+		Content: []MCPContent{{Type: "text", Text: `{"error":{"code":"RATE_LIMITED","message":"slow down","retryable":true}}`}},
+		IsError: true,
+	}
+	cr := mcpResultToCallResult(result, false)
+	if cr.ErrorData == nil {
+		t.Fatal("expected ErrorData to be parsed")
+	}
+	inner, _ := cr.ErrorData["error"].(map[string]interface{})
+	if inner["code"] != "RATE_LIMITED" {
+		t.Fatalf("unexpected ErrorData: %v", cr.ErrorData)
+	}
+	if !strings.HasPrefix(cr.Text, "Error: {") || !strings.HasSuffix(cr.Text, "\n[code=RATE_LIMITED retryable=true]") {
+		t.Fatalf("unexpected text: %q", cr.Text)
+	}
+}
+
+func TestMCPResultToCallResult_PlainTextError(t *testing.T) {
+	result := &MCPToolResult{
+		Content: []MCPContent{{Type: "text", Text: "disk full"}},
+		IsError: true,
+	}
+	cr := mcpResultToCallResult(result, false)
+	if cr.ErrorData != nil {
+		t.Fatalf("expected no ErrorData, got %v", cr.ErrorData)
+	}
+	if cr.Text != "Error: disk full" {
+		t.Fatalf("unexpected text: %q", cr.Text)
+	}
+
+	ok := mcpResultToCallResult(&MCPToolResult{Content: []MCPContent{{Type: "text", Text: `{"code":1}`}}}, false)
+	if ok.ErrorData != nil || ok.Text != `{"code":1}` {
+		t.Fatalf("non-error JSON must pass through untouched: %+v", ok)
+	}
+}
+
+func TestMCPManager_ToolErrorCarriesData(t *testing.T) {
+	mgr := NewMCPManager()
+	addMockServer(t, mgr, "api", []MCPToolDef{{Name: "fetch", Description: "Fetch"}},
+		func(name string, args map[string]interface{}) (*MCPToolResult, error) {
+			return &MCPToolResult{
+				Content: []MCPContent{{Type: "text", Text: `{"error":{"code":"RATE_LIMITED","retryable":true}}`}},
+				IsError: true,
+			}, nil
+		})
+
+	out, err := mgr.CallTool(context.Background(), "mcp.api.fetch", nil)
+	if err != nil {
+		t.Fatalf("CallTool should report isError as a string result, got error %v", err)
+	}
+	if text, _ := out.(string); !strings.HasPrefix(text, "Error: ") || !strings.HasSuffix(text, "[code=RATE_LIMITED retryable=true]") {
+		t.Fatalf("unexpected CallTool result: %q", out)
+	}
+
+	_, err = mgr.callToolDirect(context.Background(), "api", "fetch", nil, 0)
+	var toolErr *MCPToolError
+	if !errors.As(err, &toolErr) {
+		t.Fatalf("expected *MCPToolError, got %T: %v", err, err)
+	}
+	if toolErr.Server != "api" || toolErr.Tool != "fetch" || toolErr.Data == nil {
+		t.Fatalf("unexpected tool error: %+v", toolErr)
+	}
+	if retryable, ok := toolErr.Retryable(); !ok || !retryable {
+		t.Fatalf("expected retryable flag from payload, got %v/%v", retryable, ok)
+	}
+	if !strings.HasSuffix(err.Error(), "[code=RATE_LIMITED retryable=true]") {
+		t.Fatalf("unexpected error text: %q", err.Error())
+	}
+
+	registry := NewToolRegistry()
+	mgr.InjectTools(registry)
+	calls := 0
+	var toolContent string
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		calls++
+		if calls == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{{"mcp.api.fetch", `{}`}}, ""), nil
+		}
+		toolContent, _ = msgs[len(msgs)-1]["content"].(string)
+		return makeFinalResp("done"), nil
+	}
+	loop := NewAgentLoop(llm, registry, "", 5, nil)
+	loop.StructuredToolErrors = true
+	result := loop.Run("fetch", nil, "")
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(toolContent), &payload); err != nil {
+		t.Fatalf("expected structured tool error, got %q", toolContent)
+	}
+	if payload["retryable"] != true {
+		t.Fatalf("expected retryable from MCP payload, got %v", payload)
+	}
+	if result.Turns[0].ToolCalls[0].Error == "" {
+		t.Fatal("expected the failed MCP call to be recorded as an error")
+	}
+}
+
+func TestMCPRetryBackoff_DefaultAndCap(t *testing.T) {
+	var def MCPRetryBackoff
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond} {
+		if got := def.delay(attempt); got != want {
+			t.Fatalf("default attempt %d: expected %v, got %v", attempt, want, got)
+		}
+	}
+
+	capped := MCPRetryBackoff{Base: 50 * time.Millisecond, Max: 300 * time.Millisecond}
+	if got := capped.delay(3); got != 200*time.Millisecond {
+		t.Fatalf("expected 200ms before cap, got %v", got)
+	}
+	for _, attempt := range []int{4, 10, 100} {
+		if got := capped.delay(attempt); got != 300*time.Millisecond {
+			t.Fatalf("attempt %d: expected cap 300ms, got %v", attempt, got)
+		}
+	}
+}
+
+func TestMCPRetryBackoff_JitterBounds(t *testing.T) {
+	for _, r := range []float64{0, 0.25, 0.5, 0.999} {
+		b := MCPRetryBackoff{Base: 100 * time.Millisecond, Jitter: 0.5, Rand: func() float64 { return r }}
+		got := b.delay(2) // nominal 200ms
+		if got < 100*time.Millisecond || got >= 300*time.Millisecond {
+			t.Fatalf("rand=%v: delay %v outside [100ms, 300ms)", r, got)
+		}
+	}
+
+	withMax := MCPRetryBackoff{Base: 100 * time.Millisecond, Max: 150 * time.Millisecond, Jitter: 1, Rand: func() float64 { return 0.99 }}
+	if got := withMax.delay(5); got != 150*time.Millisecond {
+		t.Fatalf("jittered delay must not exceed Max, got %v", got)
+	}
+}
+
+func TestHTTPTransport_TrafficHooks(t *testing.T) {
+	srv := newMockMCPHTTPServer(t, nil)
+	tr := NewHTTPTransport(srv.URL, nil, time.Second)
+	var gotReq []byte
+	var gotStatus int
+	var gotBody []byte
+	tr.OnRequest = func(payload []byte) { gotReq = payload }
+	tr.OnResponse = func(status int, body []byte) { gotStatus, gotBody = status, body }
+
+	payload := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	resp, err := tr.Call(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(gotReq) != string(payload) {
+		t.Fatalf("OnRequest got %q", gotReq)
+	}
+	if gotStatus != http.StatusOK || string(gotBody) != string(resp) || !strings.Contains(string(gotBody), `"echo"`) {
+		t.Fatalf("OnResponse got %d %q", gotStatus, gotBody)
+	}
+
+	failing := newMockMCPHTTPServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	})
+	tr = NewHTTPTransport(failing.URL, nil, time.Second)
+	tr.OnResponse = func(status int, body []byte) { gotStatus, gotBody = status, body }
+	if _, err := tr.Call(context.Background(), payload); err == nil {
+		t.Fatal("expected error")
+	}
+	if gotStatus != http.StatusBadGateway || !strings.Contains(string(gotBody), "boom") {
+		t.Fatalf("OnResponse should see error responses, got %d %q", gotStatus, gotBody)
+	}
+}
+
+func TestTruncateTraffic(t *testing.T) {
+	if got := truncateTraffic([]byte("short"), 10); got != "short" {
+		t.Fatalf("unexpected %q", got)
+	}
+	if got := truncateTraffic([]byte("0123456789abc"), 10); got != "0123456789...(13 bytes total)" {
+		t.Fatalf("unexpected %q", got)
+	}
+}
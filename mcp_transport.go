@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -55,6 +57,17 @@ type HTTPTransport struct {
 	headers map[string]string
 	timeout time.Duration
 	client  *http.Client
+
+	// OnRequest and OnResponse, if set, observe the raw JSON-RPC traffic of
+	// each Call: the request payload, then the HTTP status and response
+	// body (truncated for non-2xx responses). A failed request with no
+	// response only triggers OnRequest. See WithTrafficLogging.
+	OnRequest  func(payload []byte)
+	OnResponse func(status int, body []byte)
+
+	authTokenFn func(ctx context.Context) (string, error)
+	tokenMu     sync.Mutex
+	token       string // cached bearer token, cleared on 401
 }
 
 // NewHTTPTransport creates an HTTP transport for the given endpoint.
@@ -70,9 +83,55 @@ func NewHTTPTransport(url string, headers map[string]string, timeout time.Durati
 	}
 }
 
+// NewHTTPTransportWithClient creates an HTTP transport that sends requests
+// through the given client, so connection pooling, proxies and TLS settings
+// can be tuned (or a client shared across servers). nil uses a default client
+// with a 30s timeout.
+func NewHTTPTransportWithClient(url string, headers map[string]string, client *http.Client) *HTTPTransport {
+	if client == nil {
+		return NewHTTPTransport(url, headers, 0)
+	}
+	return &HTTPTransport{
+		url:     url,
+		headers: headers,
+		timeout: client.Timeout,
+		client:  client,
+	}
+}
+
 func (t *HTTPTransport) Start(ctx context.Context) error { return nil }
 
+// WithAuthTokenFn sets a bearer token provider. The token is fetched on first
+// use and cached; on a 401 response it is invalidated, fetched again and the
+// request is retried once.
+func (t *HTTPTransport) WithAuthTokenFn(fn func(ctx context.Context) (string, error)) *HTTPTransport {
+	t.authTokenFn = fn
+	return t
+}
+
+// WithTrafficLogging sets OnRequest/OnResponse to log each payload,
+// truncated to maxBytes (<= 0 uses 1024).
+func (t *HTTPTransport) WithTrafficLogging(maxBytes int) *HTTPTransport {
+	t.OnRequest = func(payload []byte) {
+		log.Printf("[MCP:http:%s] -> %s", t.url, truncateTraffic(payload, maxBytes))
+	}
+	t.OnResponse = func(status int, body []byte) {
+		log.Printf("[MCP:http:%s] <- %d %s", t.url, status, truncateTraffic(body, maxBytes))
+	}
+	return t
+}
+
 func (t *HTTPTransport) Call(ctx context.Context, payload []byte) ([]byte, error) {
+	data, err := t.call(ctx, payload)
+	var te *MCPTransportError
+	if t.authTokenFn != nil && errors.As(err, &te) && te.StatusCode == http.StatusUnauthorized {
+		t.invalidateToken()
+		data, err = t.call(ctx, payload)
+	}
+	return data, err
+}
+
+func (t *HTTPTransport) call(ctx context.Context, payload []byte) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewReader(payload))
 	if err != nil {
 		return nil, fmt.Errorf("mcp: http request: %w", err)
@@ -81,7 +140,17 @@ func (t *HTTPTransport) Call(ctx context.Context, payload []byte) ([]byte, error
 	for k, v := range t.headers {
 		req.Header.Set(k, v)
 	}
+	if t.authTokenFn != nil {
+		token, err := t.authToken(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("mcp: auth token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
+	if t.OnRequest != nil {
+		t.OnRequest(payload)
+	}
 	resp, err := t.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("mcp: http call: %w", err)
@@ -91,6 +160,9 @@ func (t *HTTPTransport) Call(ctx context.Context, payload []byte) ([]byte, error
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		limited := io.LimitReader(resp.Body, mcpMaxErrorBodySize)
 		body, _ := io.ReadAll(limited)
+		if t.OnResponse != nil {
+			t.OnResponse(resp.StatusCode, body)
+		}
 		preview := string(body)
 		if len(preview) > 512 {
 			preview = preview[:512] + "..."
@@ -101,7 +173,42 @@ func (t *HTTPTransport) Call(ctx context.Context, payload []byte) ([]byte, error
 		}
 	}
 
-	return io.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if t.OnResponse != nil && err == nil {
+		t.OnResponse(resp.StatusCode, body)
+	}
+	return body, err
+}
+
+// truncateTraffic renders b for logging, cut to maxBytes (<= 0 uses 1024).
+func truncateTraffic(b []byte, maxBytes int) string {
+	if maxBytes <= 0 {
+		maxBytes = 1024
+	}
+	if len(b) <= maxBytes {
+		return string(b)
+	}
+	return fmt.Sprintf("%s...(%d bytes total)", b[:maxBytes], len(b))
+}
+
+func (t *HTTPTransport) authToken(ctx context.Context) (string, error) {
+	t.tokenMu.Lock()
+	defer t.tokenMu.Unlock()
+	if t.token != "" {
+		return t.token, nil
+	}
+	token, err := t.authTokenFn(ctx)
+	if err != nil {
+		return "", err
+	}
+	t.token = token
+	return token, nil
+}
+
+func (t *HTTPTransport) invalidateToken() {
+	t.tokenMu.Lock()
+	defer t.tokenMu.Unlock()
+	t.token = ""
 }
 
 func (t *HTTPTransport) Close() error { return nil }
@@ -112,8 +219,14 @@ func (t *HTTPTransport) Close() error { return nil }
 
 // InProcessTransport implements MCPTransport by calling a handler function directly.
 // Used for deterministic testing without external processes or network.
+//
+// Each Call runs the handler in its own goroutine. A handler panic is recovered
+// and returned as an error. If ctx is cancelled, Call returns immediately, but
+// a handler that ignores cancellation keeps running in the background; use
+// NewInProcessTransportContext so the handler can observe ctx and exit.
 type InProcessTransport struct {
-	handler func(request []byte) ([]byte, error)
+	handler    func(request []byte) ([]byte, error)
+	handlerCtx func(ctx context.Context, request []byte) ([]byte, error)
 }
 
 // NewInProcessTransport creates a transport that delegates to the given handler.
@@ -121,6 +234,12 @@ func NewInProcessTransport(handler func([]byte) ([]byte, error)) *InProcessTrans
 	return &InProcessTransport{handler: handler}
 }
 
+// NewInProcessTransportContext creates a transport whose handler receives the
+// call context, so it can stop work when the caller cancels.
+func NewInProcessTransportContext(handler func(ctx context.Context, request []byte) ([]byte, error)) *InProcessTransport {
+	return &InProcessTransport{handlerCtx: handler}
+}
+
 func (t *InProcessTransport) Start(ctx context.Context) error { return nil }
 
 func (t *InProcessTransport) Call(ctx context.Context, payload []byte) ([]byte, error) {
@@ -136,7 +255,20 @@ func (t *InProcessTransport) Call(ctx context.Context, payload []byte) ([]byte,
 	}
 	ch := make(chan result, 1)
 	go func() {
-		data, err := t.handler(payload)
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- result{nil, fmt.Errorf("mcp: handler panic: %v", r)}
+			}
+		}()
+		var (
+			data []byte
+			err  error
+		)
+		if t.handlerCtx != nil {
+			data, err = t.handlerCtx(ctx, payload)
+		} else {
+			data, err = t.handler(payload)
+		}
 		ch <- result{data, err}
 	}()
 
@@ -157,25 +289,45 @@ func (t *InProcessTransport) Close() error { return nil }
 // StdioTransport implements MCPTransport by launching a child process
 // and communicating via stdin/stdout using newline-delimited JSON.
 //
-// Architecture: a single long-lived reader goroutine reads stdout lines
-// into a channel. Call() writes to stdin and reads from the channel.
+// Architecture: a single long-lived reader goroutine reads stdout lines and
+// routes each JSON-RPC response to the Call waiting for its id, so responses
+// may arrive in any order and Calls may run concurrently. Lines that are not
+// responses (notifications, server requests) go to Notifications().
 // This avoids goroutine leaks on cancel.
 //
 // stderr is consumed by a separate goroutine and logged (never parsed as JSON).
 type StdioTransport struct {
+	// Dir is the child's working directory; empty inherits the parent's.
+	Dir string
+	// CleanEnv starts the child with only the provided env instead of
+	// layering it on top of os.Environ(). Useful for sandboxed servers.
+	CleanEnv bool
+	// OnRequest and OnResponse, if set, observe each line written to the
+	// child's stdin and each line read from its stdout. OnResponse runs on
+	// the reader goroutine.
+	OnRequest  func(line []byte)
+	OnResponse func(line []byte)
+
 	command string
 	args    []string
 	env     map[string]string
 	timeout time.Duration
 
-	cmd    *exec.Cmd
-	stdin  io.WriteCloser
-	lines  chan []byte  // reader goroutine writes lines here
-	errc   chan error   // reader goroutine error
-	done   chan struct{} // closed when process exits
-	mu     sync.Mutex   // serializes writes to stdin
+	cmd           *exec.Cmd
+	stdin         io.WriteCloser
+	mu            sync.Mutex // serializes writes to stdin
+	pendingMu     sync.Mutex
+	pending       map[string]chan []byte // in-flight Calls by JSON-RPC id
+	notifications chan []byte
+	readDone      chan struct{} // closed when the reader goroutine stops
+	readErr       error         // why the reader stopped; set before readDone closes
+	done          chan struct{} // closed when process exits
 }
 
+// stdioNotificationBuffer is how many unread notifications are kept before
+// new ones are dropped.
+const stdioNotificationBuffer = 64
+
 // NewStdioTransport creates a stdio transport for the given command.
 func NewStdioTransport(command string, args []string, env map[string]string, timeout time.Duration) *StdioTransport {
 	if timeout <= 0 {
@@ -194,12 +346,15 @@ func (t *StdioTransport) Start(ctx context.Context) error {
 	t.cmd = exec.CommandContext(ctx, t.command, t.args...)
 
 	// Set environment
-	if len(t.env) > 0 {
+	if t.CleanEnv {
+		t.cmd.Env = make([]string, 0, len(t.env))
+	} else if len(t.env) > 0 {
 		t.cmd.Env = os.Environ()
-		for k, v := range t.env {
-			t.cmd.Env = append(t.cmd.Env, k+"="+v)
-		}
 	}
+	for k, v := range t.env {
+		t.cmd.Env = append(t.cmd.Env, k+"="+v)
+	}
+	t.cmd.Dir = t.Dir
 
 	var err error
 	t.stdin, err = t.cmd.StdinPipe()
@@ -221,27 +376,25 @@ func (t *StdioTransport) Start(ctx context.Context) error {
 		return fmt.Errorf("mcp: stdio start %q: %w", t.command, err)
 	}
 
-	t.lines = make(chan []byte, 16)
-	t.errc = make(chan error, 1)
+	t.pending = make(map[string]chan []byte)
+	t.notifications = make(chan []byte, stdioNotificationBuffer)
+	t.readDone = make(chan struct{})
 	t.done = make(chan struct{})
 
-	// Long-lived reader goroutine: reads stdout lines into channel.
+	// Long-lived reader goroutine: routes stdout lines to waiting Calls.
 	// Uses bufio.NewReaderSize with 1MB buffer to avoid 64K Scanner limit.
 	go func() {
 		reader := bufio.NewReaderSize(stdout, 1024*1024)
 		for {
 			line, err := reader.ReadBytes('\n')
+			if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+				t.dispatch(trimmed)
+			}
 			if err != nil {
-				if len(line) > 0 {
-					t.lines <- bytes.TrimSpace(line)
-				}
-				t.errc <- err
+				t.readErr = err
+				close(t.readDone)
 				return
 			}
-			trimmed := bytes.TrimSpace(line)
-			if len(trimmed) > 0 {
-				t.lines <- trimmed
-			}
 		}
 	}()
 
@@ -253,8 +406,10 @@ func (t *StdioTransport) Start(ctx context.Context) error {
 		}
 	}()
 
-	// Process exit watcher.
+	// Process exit watcher. Wait closes stdout, so it must not run until
+	// the reader has drained everything the child wrote.
 	go func() {
+		<-t.readDone
 		t.cmd.Wait()
 		close(t.done)
 	}()
@@ -262,11 +417,61 @@ func (t *StdioTransport) Start(ctx context.Context) error {
 	return nil
 }
 
-// Call sends a JSON-RPC request to stdin and reads one response line from stdout.
-func (t *StdioTransport) Call(ctx context.Context, payload []byte) ([]byte, error) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
+// dispatch hands a stdout line to the Call waiting for its id, or to the
+// notifications channel if it is not a response.
+func (t *StdioTransport) dispatch(line []byte) {
+	if t.OnResponse != nil {
+		t.OnResponse(line)
+	}
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal(line, &msg); err != nil {
+		log.Printf("[MCP:stdio:%s] dropping non-JSON line: %s", t.command, truncateTraffic(line, 0))
+		return
+	}
+	if key := jsonRPCIDKey(msg.ID); key != "" && msg.Method == "" {
+		t.pendingMu.Lock()
+		ch, ok := t.pending[key]
+		delete(t.pending, key)
+		t.pendingMu.Unlock()
+		if ok {
+			ch <- line
+		} else {
+			log.Printf("[MCP:stdio:%s] dropping response for unknown id %s", t.command, key)
+		}
+		return
+	}
+	select {
+	case t.notifications <- line:
+	default:
+		log.Printf("[MCP:stdio:%s] notification buffer full, dropping: %s", t.command, truncateTraffic(line, 0))
+	}
+}
+
+// jsonRPCIDKey normalizes a raw JSON-RPC id for map lookups; "" means the
+// message has no id.
+func jsonRPCIDKey(raw json.RawMessage) string {
+	key := string(bytes.TrimSpace(raw))
+	if key == "null" {
+		return ""
+	}
+	return key
+}
 
+// Notifications returns the lines from the server that are not responses to
+// a Call: notifications and server-initiated requests. The channel is
+// buffered; when nobody reads it, further lines are logged and dropped.
+// It is nil before Start.
+func (t *StdioTransport) Notifications() <-chan []byte {
+	return t.notifications
+}
+
+// Call sends a JSON-RPC request to stdin and waits for the response with the
+// same id. Calls may be issued concurrently. A payload without an id is a
+// notification: it is written and Call returns (nil, nil) immediately.
+func (t *StdioTransport) Call(ctx context.Context, payload []byte) ([]byte, error) {
 	// Check cancellation before writing
 	select {
 	case <-ctx.Done():
@@ -281,22 +486,78 @@ func (t *StdioTransport) Call(ctx context.Context, payload []byte) ([]byte, erro
 	default:
 	}
 
+	var req struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, fmt.Errorf("mcp: stdio invalid request: %w", err)
+	}
+	key := jsonRPCIDKey(req.ID)
+
+	var respc chan []byte
+	if key != "" {
+		respc = make(chan []byte, 1)
+		t.pendingMu.Lock()
+		if _, dup := t.pending[key]; dup {
+			t.pendingMu.Unlock()
+			return nil, fmt.Errorf("mcp: stdio duplicate in-flight request id %s", key)
+		}
+		t.pending[key] = respc
+		t.pendingMu.Unlock()
+		defer func() {
+			t.pendingMu.Lock()
+			delete(t.pending, key)
+			t.pendingMu.Unlock()
+		}()
+	}
+
 	// Write request (newline-delimited)
-	if _, err := t.stdin.Write(append(payload, '\n')); err != nil {
-		return nil, fmt.Errorf("mcp: stdio write: %w", err)
+	if err := t.write(payload); err != nil {
+		return nil, err
+	}
+	if respc == nil {
+		return nil, nil
 	}
 
-	// Read one response line from the channel
 	select {
-	case line := <-t.lines:
+	case line := <-respc:
 		return line, nil
-	case err := <-t.errc:
-		return nil, fmt.Errorf("mcp: stdio read: %w", err)
 	case <-ctx.Done():
 		return nil, ctx.Err()
-	case <-t.done:
-		return nil, fmt.Errorf("mcp: stdio process exited during read")
+	case <-t.readDone:
+		// The response may have been routed just before the reader stopped.
+		select {
+		case line := <-respc:
+			return line, nil
+		default:
+		}
+		return nil, fmt.Errorf("mcp: stdio read: %w", t.readErr)
+	}
+}
+
+// write sends one newline-delimited line to the child's stdin.
+func (t *StdioTransport) write(payload []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.OnRequest != nil {
+		t.OnRequest(payload)
+	}
+	if _, err := t.stdin.Write(append(payload, '\n')); err != nil {
+		return fmt.Errorf("mcp: stdio write: %w", err)
+	}
+	return nil
+}
+
+// WithTrafficLogging sets OnRequest/OnResponse to log each line,
+// truncated to maxBytes (<= 0 uses 1024).
+func (t *StdioTransport) WithTrafficLogging(maxBytes int) *StdioTransport {
+	t.OnRequest = func(line []byte) {
+		log.Printf("[MCP:stdio:%s] -> %s", t.command, truncateTraffic(line, maxBytes))
+	}
+	t.OnResponse = func(line []byte) {
+		log.Printf("[MCP:stdio:%s] <- %s", t.command, truncateTraffic(line, maxBytes))
 	}
+	return t
 }
 
 // Close shuts down the child process gracefully.
@@ -17,21 +17,31 @@ type ConversationBuffer struct {
 	namespace       string
 	triggerCount    int
 	triggerInterval time.Duration
+	clock           Clock
 }
 
 // NewConversationBuffer creates a buffer with configurable trigger conditions.
-func NewConversationBuffer(store MemoryStore, namespace string, triggerCount int, triggerInterval time.Duration) *ConversationBuffer {
+// Extraction triggers when the buffer holds triggerCount messages, or when
+// triggerInterval has elapsed since the last extraction.
+//
+// An optional Clock may be passed to replace the system clock (useful in tests).
+func NewConversationBuffer(store MemoryStore, namespace string, triggerCount int, triggerInterval time.Duration, clock ...Clock) *ConversationBuffer {
 	if triggerCount <= 0 {
 		triggerCount = 5
 	}
 	if triggerInterval <= 0 {
 		triggerInterval = 24 * time.Hour
 	}
+	var c Clock
+	if len(clock) > 0 {
+		c = clock[0]
+	}
 	return &ConversationBuffer{
 		store:           store,
 		namespace:       namespace,
 		triggerCount:    triggerCount,
 		triggerInterval: triggerInterval,
+		clock:           c,
 	}
 }
 
@@ -40,7 +50,7 @@ func (b *ConversationBuffer) Add(role, content string) error {
 	entry := map[string]string{
 		"role":      role,
 		"content":   content,
-		"timestamp": time.Now().Format(time.RFC3339),
+		"timestamp": nowFrom(b.clock).Format(time.RFC3339),
 	}
 	data, _ := json.Marshal(entry)
 	return b.store.Append(b.namespace, bufListKey, string(data))
@@ -72,7 +82,8 @@ func (b *ConversationBuffer) ShouldExtract() (bool, error) {
 		return true, nil
 	}
 	lastTS, _ := meta["last_extraction_ts"].(float64)
-	if time.Since(time.Unix(int64(lastTS), 0)) >= b.triggerInterval {
+	last := time.Unix(0, int64(lastTS*float64(time.Second)))
+	if nowFrom(b.clock).Sub(last) >= b.triggerInterval {
 		return true, nil
 	}
 	return false, nil
@@ -88,9 +99,10 @@ func (b *ConversationBuffer) GetAndClear() ([]map[string]string, error) {
 		log.Printf("[ConversationBuffer] ClearList error: %v", err)
 	}
 
+	now := nowFrom(b.clock)
 	meta, _ := json.Marshal(map[string]interface{}{
-		"last_extraction_ts": float64(time.Now().Unix()),
-		"last_extraction_at": time.Now().Format(time.RFC3339),
+		"last_extraction_ts": float64(now.UnixNano()) / float64(time.Second),
+		"last_extraction_at": now.Format(time.RFC3339),
 	})
 	b.store.Set(b.namespace, bufMetaKey, string(meta))
 
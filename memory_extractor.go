@@ -20,6 +20,30 @@ type MemoryExtractorInterface interface {
 type LLMMemoryExtractor struct {
 	LLMFn          MemoryExtractorFunc
 	PromptTemplate string
+
+	// Sections, if set, limits extraction to these top-level keys: only they
+	// are shown from the current memory, requested in the prompt, and kept
+	// in the result.
+	Sections []string
+	// FewShot examples are placed before the task in the prompt.
+	FewShot []ExampleTurn
+	// MaxInputMessages sends only the most recent N messages; 0 = all.
+	MaxInputMessages int
+}
+
+// ExampleTurn is a few-shot example for LLMMemoryExtractor: a short
+// conversation and the JSON that should be extracted from it.
+type ExampleTurn struct {
+	Conversation []map[string]string
+	Output       string
+}
+
+// ExtractorConfig configures NewLLMMemoryExtractorWithConfig.
+type ExtractorConfig struct {
+	PromptTemplate   string // default DefaultExtractionPrompt
+	Sections         []string
+	FewShot          []ExampleTurn
+	MaxInputMessages int
 }
 
 // DefaultExtractionPrompt is the built-in Chinese extraction prompt.
@@ -56,24 +80,64 @@ func NewLLMMemoryExtractor(llmFn MemoryExtractorFunc, promptTemplate string) *LL
 	return &LLMMemoryExtractor{LLMFn: llmFn, PromptTemplate: promptTemplate}
 }
 
+// NewLLMMemoryExtractorWithConfig creates an extractor with section limits,
+// few-shot examples and an input cap.
+func NewLLMMemoryExtractorWithConfig(llmFn MemoryExtractorFunc, config ExtractorConfig) *LLMMemoryExtractor {
+	e := NewLLMMemoryExtractor(llmFn, config.PromptTemplate)
+	e.Sections = config.Sections
+	e.FewShot = config.FewShot
+	e.MaxInputMessages = config.MaxInputMessages
+	return e
+}
+
 // Extract calls the LLM to extract structured memory from conversations.
 func (e *LLMMemoryExtractor) Extract(conversations []map[string]string, currentMemory map[string]interface{}) (map[string]interface{}, error) {
 	if len(conversations) == 0 {
 		return map[string]interface{}{}, nil
 	}
 
-	convText := formatConversations(conversations)
-	memJSON, _ := json.MarshalIndent(currentMemory, "", "  ")
-
-	prompt := fmt.Sprintf(e.PromptTemplate, string(memJSON), convText)
-
-	response, err := e.LLMFn(prompt)
+	response, err := e.LLMFn(e.BuildPrompt(conversations, currentMemory))
 	if err != nil {
 		log.Printf("[LLMMemoryExtractor] LLM call failed: %v", err)
 		return map[string]interface{}{}, err
 	}
 
-	return ParseJSONResponse(response), nil
+	return e.filterSections(ParseJSONResponse(response)), nil
+}
+
+// BuildPrompt renders the prompt Extract sends to the LLM.
+func (e *LLMMemoryExtractor) BuildPrompt(conversations []map[string]string, currentMemory map[string]interface{}) string {
+	if e.MaxInputMessages > 0 && len(conversations) > e.MaxInputMessages {
+		conversations = conversations[len(conversations)-e.MaxInputMessages:]
+	}
+	memJSON, _ := json.MarshalIndent(e.filterSections(currentMemory), "", "  ")
+	prompt := fmt.Sprintf(e.PromptTemplate, string(memJSON), formatConversations(conversations))
+
+	var sb strings.Builder
+	if len(e.Sections) > 0 {
+		sb.WriteString("只提取以下字段，其他字段一律不要返回：" + strings.Join(e.Sections, ", ") + "\n\n")
+	}
+	for i, ex := range e.FewShot {
+		fmt.Fprintf(&sb, "示例 %d：\n对话：\n%s\n输出：\n%s\n\n", i+1, formatConversations(ex.Conversation), strings.TrimSpace(ex.Output))
+	}
+	if sb.Len() == 0 {
+		return prompt
+	}
+	return sb.String() + prompt
+}
+
+// filterSections keeps only the configured Sections of data (all when unset).
+func (e *LLMMemoryExtractor) filterSections(data map[string]interface{}) map[string]interface{} {
+	if len(e.Sections) == 0 || data == nil {
+		return data
+	}
+	out := make(map[string]interface{}, len(e.Sections))
+	for _, k := range e.Sections {
+		if v, ok := data[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
 }
 
 func formatConversations(conversations []map[string]string) string {
@@ -2,10 +2,17 @@ package agentsdk
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
+// sdkWorkingKeyPrefix marks working-memory keys the SDK writes for its own
+// bookkeeping (e.g. "sdk.opener_count", mirrored "sdk.user.*" KVs). They are
+// never rendered into prompts.
+const sdkWorkingKeyPrefix = "sdk."
+
 // FormatMemoryForPrompt formats memory layers into text for LLM system prompt injection.
+// Working-memory keys starting with "sdk." are internal and skipped.
 // Returns empty string if no meaningful content.
 func FormatMemoryForPrompt(longTerm map[string]interface{}, working map[string]interface{}, template string) string {
 	var parts []string
@@ -18,6 +25,9 @@ func FormatMemoryForPrompt(longTerm map[string]interface{}, working map[string]i
 	if len(working) > 0 {
 		var items []string
 		for k, v := range working {
+			if strings.HasPrefix(k, sdkWorkingKeyPrefix) {
+				continue
+			}
 			if v != nil && fmt.Sprintf("%v", v) != "" {
 				items = append(items, fmt.Sprintf("- %s: %v", k, v))
 			}
@@ -96,6 +106,9 @@ func formatLongTerm(m map[string]interface{}) string {
 		lines = append(lines, "用户特点: "+s)
 	}
 
+	// Custom schema keys
+	lines = append(lines, formatExtraLongTermKeys(m)...)
+
 	// Count
 	if meta, ok := m["meta"].(map[string]interface{}); ok {
 		if count, ok := meta["conversation_count"].(float64); ok && count > 0 {
@@ -106,6 +119,59 @@ func formatLongTerm(m map[string]interface{}) string {
 	return strings.Join(lines, "\n")
 }
 
+// knownLongTermKeys are the default-schema keys formatLongTerm renders itself
+// (or deliberately skips, like preferences).
+var knownLongTermKeys = map[string]bool{
+	"basic_info": true, "personality": true, "life_context": true,
+	"interests": true, "summary": true, "preferences": true, "meta": true,
+}
+
+// formatExtraLongTermKeys renders top-level keys from custom schemas as
+// "key: value" lines, sorted by key. Empty values are skipped.
+func formatExtraLongTermKeys(m map[string]interface{}) []string {
+	var keys []string
+	for k := range m {
+		if !knownLongTermKeys[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, k := range keys {
+		if text := formatLongTermValue(m[k]); text != "" {
+			lines = append(lines, k+": "+text)
+		}
+	}
+	return lines
+}
+
+func formatLongTermValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []interface{}:
+		return strings.Join(toStringSlice(val), ", ")
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var parts []string
+		for _, k := range keys {
+			if text := formatLongTermValue(val[k]); text != "" {
+				parts = append(parts, k+"="+text)
+			}
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
 func toStringSlice(v interface{}) []string {
 	if v == nil {
 		return nil
@@ -2,6 +2,8 @@ package agentsdk
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -84,14 +86,58 @@ func (w *WorkingMemory) SetInt(key string, val int) {
 // Incr atomically increments an int value by 1 and returns the new value.
 // If the key does not exist or is not an int, it starts from 0.
 func (w *WorkingMemory) Incr(key string) int {
+	return w.IncrBy(key, 1)
+}
+
+// IncrBy atomically adds delta to an int value and returns the new value.
+// If the key does not exist or is not an int, it starts from 0.
+func (w *WorkingMemory) IncrBy(key string, delta int) int {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	v, _ := w.data[key].(int)
-	v++
+	v += delta
 	w.data[key] = v
 	return v
 }
 
+// GetFloat returns the float64 value for key, or 0 if not set or wrong type.
+// float32 and int values are widened to float64.
+func (w *WorkingMemory) GetFloat(key string) float64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	switch v := w.data[key].(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	}
+	return 0
+}
+
+// SetFloat stores a float64 value.
+func (w *WorkingMemory) SetFloat(key string, val float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.data[key] = val
+}
+
+// GetBool returns the bool value for key, or false if not set or wrong type.
+func (w *WorkingMemory) GetBool(key string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	v, _ := w.data[key].(bool)
+	return v
+}
+
+// SetBool stores a bool value.
+func (w *WorkingMemory) SetBool(key string, val bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.data[key] = val
+}
+
 // GetString returns the string value for key, or "" if not set or wrong type.
 func (w *WorkingMemory) GetString(key string) string {
 	w.mu.RLock()
@@ -131,8 +177,28 @@ func NewShortTermMemory(store MemoryStore, namespace string, maxMessages int) *S
 }
 
 // AddMessage appends a message and auto-trims.
+// role must be one of user, assistant, system or tool; anything else
+// returns an error wrapping ErrInvalidMessageRole.
 func (s *ShortTermMemory) AddMessage(role, content string) error {
-	msg := NewMemoryMessage(role, content)
+	return s.appendMessage(NewMemoryMessage(role, content))
+}
+
+// validateMessageRole rejects roles ShortTermMemory does not store.
+func validateMessageRole(role string) error {
+	switch role {
+	case "user", "assistant", "system", "tool":
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidMessageRole, role)
+	}
+}
+
+// appendMessage validates and stores msg as-is (keeping its timestamp), then
+// auto-trims.
+func (s *ShortTermMemory) appendMessage(msg MemoryMessage) error {
+	if err := validateMessageRole(msg.Role); err != nil {
+		return err
+	}
 	data, _ := json.Marshal(msg)
 	if err := s.store.Append(s.namespace, stmKey, string(data)); err != nil {
 		return err
@@ -145,18 +211,38 @@ func (s *ShortTermMemory) GetHistory(limit int) ([]MemoryMessage, error) {
 	if limit <= 0 {
 		limit = s.maxMessages
 	}
-	raw, err := s.store.GetList(s.namespace, stmKey, limit, 0)
+	return s.GetHistoryPage(0, limit)
+}
+
+// GetHistoryPage returns up to limit messages starting at offset,
+// counted from the oldest stored message (oldest first).
+// limit <= 0 returns everything from offset onward.
+func (s *ShortTermMemory) GetHistoryPage(offset, limit int) ([]MemoryMessage, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	raw, err := s.store.GetList(s.namespace, stmKey, limit, offset)
 	if err != nil {
 		return nil, err
 	}
-	msgs := make([]MemoryMessage, 0, len(raw))
-	for _, r := range raw {
-		var m MemoryMessage
-		if json.Unmarshal([]byte(r), &m) == nil {
-			msgs = append(msgs, m)
+	return decodeMemoryMessages(raw), nil
+}
+
+// SearchHistory returns stored messages whose content contains substr
+// (case-insensitive), oldest first.
+func (s *ShortTermMemory) SearchHistory(substr string) ([]MemoryMessage, error) {
+	raw, err := s.store.GetList(s.namespace, stmKey, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	needle := strings.ToLower(substr)
+	matches := make([]MemoryMessage, 0)
+	for _, m := range decodeMemoryMessages(raw) {
+		if strings.Contains(strings.ToLower(m.Content), needle) {
+			matches = append(matches, m)
 		}
 	}
-	return msgs, nil
+	return matches, nil
 }
 
 // GetHistoryMaps returns history as []map for LLM messages.
@@ -172,6 +258,27 @@ func (s *ShortTermMemory) GetHistoryMaps(limit int) ([]map[string]string, error)
 	return result, nil
 }
 
+// GetHistoryMapsFiltered is like GetHistoryMaps(0) but omits messages whose
+// role is listed in excludeRoles (e.g. "system").
+func (s *ShortTermMemory) GetHistoryMapsFiltered(excludeRoles ...string) ([]map[string]string, error) {
+	msgs, err := s.GetHistory(0)
+	if err != nil {
+		return nil, err
+	}
+	exclude := make(map[string]bool, len(excludeRoles))
+	for _, r := range excludeRoles {
+		exclude[r] = true
+	}
+	result := make([]map[string]string, 0, len(msgs))
+	for _, m := range msgs {
+		if exclude[m.Role] {
+			continue
+		}
+		result = append(result, map[string]string{"role": m.Role, "content": m.Content})
+	}
+	return result, nil
+}
+
 // Clear removes all messages.
 func (s *ShortTermMemory) Clear() error {
 	return s.store.ClearList(s.namespace, stmKey)
@@ -188,9 +295,13 @@ func (s *ShortTermMemory) Count() (int, error) {
 
 // LongTermMemory manages persistent user profile/preferences with caching.
 type LongTermMemory struct {
+	// Clock stamps meta.created_at/updated_at and ages the cache; nil = system time.
+	Clock Clock
+
 	store     MemoryStore
 	namespace string
 	cacheTTL  time.Duration
+	schemaFn  func() map[string]interface{}
 	cache     map[string]interface{}
 	cacheTS   time.Time
 	mu        sync.Mutex
@@ -207,12 +318,28 @@ func NewLongTermMemory(store MemoryStore, namespace string, cacheTTL time.Durati
 	}
 }
 
+// NewLongTermMemoryWithSchema is like NewLongTermMemory but Get on an empty
+// (or undecodable) store returns schemaFn() instead of the default profile
+// shape. schemaFn must return a fresh map on every call; nil = default.
+func NewLongTermMemoryWithSchema(store MemoryStore, namespace string, cacheTTL time.Duration, schemaFn func() map[string]interface{}) *LongTermMemory {
+	l := NewLongTermMemory(store, namespace, cacheTTL)
+	l.schemaFn = schemaFn
+	return l
+}
+
+func (l *LongTermMemory) schema() map[string]interface{} {
+	if l.schemaFn != nil {
+		return l.schemaFn()
+	}
+	return defaultSchema()
+}
+
 // Get loads the long-term memory (using cache if fresh).
 func (l *LongTermMemory) Get() (map[string]interface{}, error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if l.cache != nil && l.cacheTTL > 0 && time.Since(l.cacheTS) < l.cacheTTL {
+	if l.cacheFresh() {
 		return copyMap(l.cache), nil
 	}
 
@@ -220,22 +347,31 @@ func (l *LongTermMemory) Get() (map[string]interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	return l.fill(raw), nil
+}
+
+func (l *LongTermMemory) cacheFresh() bool {
+	return l.cache != nil && l.cacheTTL > 0 && nowFrom(l.Clock).Sub(l.cacheTS) < l.cacheTTL
+}
 
+// fill decodes raw (falling back to the schema) and refreshes the cache.
+// Caller must hold l.mu.
+func (l *LongTermMemory) fill(raw string) map[string]interface{} {
 	var data map[string]interface{}
 	if raw != "" {
 		if json.Unmarshal([]byte(raw), &data) != nil {
-			data = defaultSchema()
+			data = l.schema()
 		}
 	} else {
-		data = defaultSchema()
+		data = l.schema()
 		if meta, ok := data["meta"].(map[string]interface{}); ok {
-			meta["created_at"] = time.Now().Format(time.RFC3339)
+			meta["created_at"] = nowFrom(l.Clock).Format(time.RFC3339)
 		}
 	}
 
 	l.cache = data
-	l.cacheTS = time.Now()
-	return copyMap(data), nil
+	l.cacheTS = nowFrom(l.Clock)
+	return copyMap(data)
 }
 
 // Save overwrites the entire long-term memory.
@@ -244,7 +380,7 @@ func (l *LongTermMemory) Save(data map[string]interface{}) error {
 	defer l.mu.Unlock()
 
 	if meta, ok := data["meta"].(map[string]interface{}); ok {
-		meta["updated_at"] = time.Now().Format(time.RFC3339)
+		meta["updated_at"] = nowFrom(l.Clock).Format(time.RFC3339)
 	}
 	raw, err := json.Marshal(data)
 	if err != nil {
@@ -254,7 +390,7 @@ func (l *LongTermMemory) Save(data map[string]interface{}) error {
 		return err
 	}
 	l.cache = copyMap(data)
-	l.cacheTS = time.Now()
+	l.cacheTS = nowFrom(l.Clock)
 	return nil
 }
 
@@ -268,7 +404,7 @@ func (l *LongTermMemory) Update(updates map[string]interface{}) (map[string]inte
 	if meta, ok := merged["meta"].(map[string]interface{}); ok {
 		count, _ := meta["conversation_count"].(float64)
 		meta["conversation_count"] = count + 1
-		meta["updated_at"] = time.Now().Format(time.RFC3339)
+		meta["updated_at"] = nowFrom(l.Clock).Format(time.RFC3339)
 	}
 	if err := l.Save(merged); err != nil {
 		return nil, err
@@ -322,6 +458,17 @@ func defaultSchema() map[string]interface{} {
 	}
 }
 
+func decodeMemoryMessages(raw []string) []MemoryMessage {
+	msgs := make([]MemoryMessage, 0, len(raw))
+	for _, r := range raw {
+		var m MemoryMessage
+		if json.Unmarshal([]byte(r), &m) == nil {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
 func copyMap(m map[string]interface{}) map[string]interface{} {
 	data, _ := json.Marshal(m)
 	var cp map[string]interface{}
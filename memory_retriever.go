@@ -51,6 +51,7 @@ type MemoryRetriever struct {
 	Structured *LongTermMemory
 	Typed      *TypedMemoryStore
 	Budget     TokenBudgetConfig
+	Estimator  TokenEstimator // optional: nil = runeCount / 2.7
 }
 
 // NewMemoryRetriever creates a retriever with the given stores and budget.
@@ -64,6 +65,7 @@ func NewMemoryRetriever(opts MemoryRetrieverOptions) *MemoryRetriever {
 		Structured: opts.Structured,
 		Typed:      opts.Typed,
 		Budget:     budget,
+		Estimator:  opts.Estimator,
 	}
 }
 
@@ -73,6 +75,7 @@ type MemoryRetrieverOptions struct {
 	Structured *LongTermMemory
 	Typed      *TypedMemoryStore
 	Budget     TokenBudgetConfig
+	Estimator  TokenEstimator
 }
 
 // RetrievedMemory holds the final assembled memory text ready for prompt injection.
@@ -154,7 +157,7 @@ func (r *MemoryRetriever) Retrieve(ctx context.Context, query string, topK int)
 	hitCount := 0
 
 	for _, s := range sections {
-		est := estimateTextTokens(s.text)
+		est := r.estimateTokens(s.text)
 		if tokensUsed+est > budget && len(result) > 0 {
 			break
 		}
@@ -183,7 +186,7 @@ func (r *MemoryRetriever) TruncateHistory(history []map[string]interface{}) []ma
 	startIdx := len(history)
 	for i := len(history) - 1; i >= 0; i-- {
 		content, _ := history[i]["content"].(string)
-		est := estimateTextTokens(content)
+		est := r.estimateTokens(content)
 		if total+est > budget && startIdx < len(history) {
 			break
 		}
@@ -199,6 +202,13 @@ type scoredSection struct {
 	score float64
 }
 
+func (r *MemoryRetriever) estimateTokens(text string) int {
+	if r.Estimator != nil {
+		return r.Estimator.EstimateTokens(text)
+	}
+	return estimateTextTokens(text)
+}
+
 func estimateTextTokens(text string) int {
 	runes := utf8.RuneCountInString(text)
 	return int(float64(runes) / 2.7)
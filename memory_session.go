@@ -1,7 +1,9 @@
 package agentsdk
 
 import (
+	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -14,6 +16,12 @@ import (
 //	session.AddMessage("user", "Hello!")
 //	prompt := session.FormatForPrompt("")
 //	session.ExtractIfNeeded()
+//
+// A session is safe to share across goroutines (e.g. the agent loop and a
+// ProactiveScheduler): Load, AddMessage, UpdateLongTerm, ExtractIfNeeded,
+// Export and the Clear methods are serialized by a session lock, so a
+// message is never split between history and the extraction buffer.
+// Replacing the layer fields after first use is not synchronized.
 type MemorySession struct {
 	AgentID   string
 	UserID    string
@@ -26,6 +34,7 @@ type MemorySession struct {
 
 	store     MemoryStore
 	extractor MemoryExtractorInterface
+	mu        sync.Mutex
 }
 
 // NewMemorySession creates a session with default settings.
@@ -65,13 +74,26 @@ func NewMemorySessionWithOptions(
 	}
 }
 
+// NewMemorySessionWithSchema creates a session with default settings whose
+// long-term memory starts from schemaFn() instead of the default profile
+// shape (basic_info, personality, interests, ...).
+func NewMemorySessionWithSchema(agentID, userID string, store MemoryStore, schemaFn func() map[string]interface{}) *MemorySession {
+	s := NewMemorySession(agentID, userID, store)
+	s.LongTerm = NewLongTermMemoryWithSchema(store, s.Namespace, 5*time.Minute, schemaFn)
+	return s
+}
+
 // SetExtractor sets the memory extractor for automatic extraction.
 func (s *MemorySession) SetExtractor(ext MemoryExtractorInterface) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.extractor = ext
 }
 
 // Load loads all memory layers and returns a snapshot.
 func (s *MemorySession) Load() (*MemoryContext, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	history, err := s.ShortTerm.GetHistory(0)
 	if err != nil {
 		return nil, err
@@ -89,6 +111,8 @@ func (s *MemorySession) Load() (*MemoryContext, error) {
 
 // AddMessage adds to both short-term history and conversation buffer.
 func (s *MemorySession) AddMessage(role, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if err := s.ShortTerm.AddMessage(role, content); err != nil {
 		return err
 	}
@@ -97,40 +121,57 @@ func (s *MemorySession) AddMessage(role, content string) error {
 
 // ExtractIfNeeded checks triggers and extracts memory if needed.
 // Returns the extracted delta, or nil.
+// The session lock is released while the extractor runs, so a slow LLM
+// call does not block AddMessage.
 func (s *MemorySession) ExtractIfNeeded() map[string]interface{} {
-	if s.extractor == nil {
-		return nil
-	}
-
-	should, err := s.Buffer.ShouldExtract()
-	if err != nil || !should {
-		return nil
-	}
-
-	conversations, err := s.Buffer.GetAndClear()
-	if err != nil || len(conversations) == 0 {
+	s.mu.Lock()
+	extractor := s.extractor
+	conversations, current, ok := s.takeBufferForExtraction()
+	s.mu.Unlock()
+	if !ok {
 		return nil
 	}
 
-	current, err := s.LongTerm.Get()
-	if err != nil {
-		return nil
-	}
-
-	extracted, err := s.extractor.Extract(conversations, current)
+	extracted, err := extractor.Extract(conversations, current)
 	if err != nil {
 		logWarnf("[MemorySession] Extraction failed: %v", err)
 		return nil
 	}
 
 	if len(extracted) > 0 {
+		s.mu.Lock()
 		s.LongTerm.Update(extracted)
+		s.mu.Unlock()
 		logInfof("[MemorySession] Memory extracted | ns=%s", s.Namespace)
 	}
 
 	return extracted
 }
 
+// takeBufferForExtraction drains the buffer when extraction is due.
+// Caller must hold s.mu.
+func (s *MemorySession) takeBufferForExtraction() ([]map[string]string, map[string]interface{}, bool) {
+	if s.extractor == nil {
+		return nil, nil, false
+	}
+
+	should, err := s.Buffer.ShouldExtract()
+	if err != nil || !should {
+		return nil, nil, false
+	}
+
+	conversations, err := s.Buffer.GetAndClear()
+	if err != nil || len(conversations) == 0 {
+		return nil, nil, false
+	}
+
+	current, err := s.LongTerm.Get()
+	if err != nil {
+		return nil, nil, false
+	}
+	return conversations, current, true
+}
+
 // FormatForPrompt formats current memory for LLM prompt injection.
 func (s *MemorySession) FormatForPrompt(template string) string {
 	lt := s.LongTerm.GetCached()
@@ -143,21 +184,55 @@ func (s *MemorySession) FormatForPrompt(template string) string {
 
 // UpdateLongTerm updates long-term memory with incremental changes.
 func (s *MemorySession) UpdateLongTerm(updates map[string]interface{}) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.LongTerm.Update(updates)
 }
 
+// modifyLongTerm loads long-term memory, passes it to fn and saves the map
+// fn returns, all under the session lock. A nil result skips the save.
+func (s *MemorySession) modifyLongTerm(fn func(current map[string]interface{}) map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, err := s.LongTerm.Get()
+	if err != nil {
+		return err
+	}
+	next := fn(current)
+	if next == nil {
+		return nil
+	}
+	return s.LongTerm.Save(next)
+}
+
+// GetHistoryPage returns a page of short-term history (oldest first).
+func (s *MemorySession) GetHistoryPage(offset, limit int) ([]MemoryMessage, error) {
+	return s.ShortTerm.GetHistoryPage(offset, limit)
+}
+
+// SearchHistory returns short-term messages containing substr.
+func (s *MemorySession) SearchHistory(substr string) ([]MemoryMessage, error) {
+	return s.ShortTerm.SearchHistory(substr)
+}
+
 // ClearHistory clears short-term history only.
 func (s *MemorySession) ClearHistory() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.ShortTerm.Clear()
 }
 
 // ClearBuffer clears the conversation buffer only.
 func (s *MemorySession) ClearBuffer() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.Buffer.Clear()
 }
 
 // ClearAll clears all memory layers.
 func (s *MemorySession) ClearAll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.Working.Clear()
 	if err := s.ShortTerm.Clear(); err != nil {
 		return err
@@ -167,3 +242,126 @@ func (s *MemorySession) ClearAll() error {
 	}
 	return s.Buffer.Clear()
 }
+
+// ──────────────────────────────────────────────
+// Export / Import
+// ──────────────────────────────────────────────
+
+// SessionExportOptions controls what MemorySession.Export includes.
+type SessionExportOptions struct {
+	// IncludeWorking adds the ephemeral working memory to the export.
+	IncludeWorking bool
+}
+
+// SessionExport is the JSON document produced by MemorySession.Export.
+type SessionExport struct {
+	Version   int                    `json:"version"`
+	AgentID   string                 `json:"agent_id"`
+	UserID    string                 `json:"user_id"`
+	Namespace string                 `json:"namespace"`
+	ShortTerm []MemoryMessage        `json:"short_term"`
+	LongTerm  map[string]interface{} `json:"long_term"`
+	Working   map[string]interface{} `json:"working,omitempty"`
+}
+
+const sessionExportVersion = 1
+
+// Export serializes short-term history and the long-term profile (and
+// optionally working memory) into a single JSON document, e.g. for data
+// portability requests or debugging.
+func (s *MemorySession) Export(opts ...SessionExportOptions) ([]byte, error) {
+	var opt SessionExportOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	history, err := s.ShortTerm.GetHistoryPage(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	lt, err := s.LongTerm.Get()
+	if err != nil {
+		return nil, err
+	}
+	doc := SessionExport{
+		Version:   sessionExportVersion,
+		AgentID:   s.AgentID,
+		UserID:    s.UserID,
+		Namespace: s.Namespace,
+		ShortTerm: history,
+		LongTerm:  lt,
+	}
+	if opt.IncludeWorking {
+		doc.Working = s.Working.ToMap()
+	}
+	return json.Marshal(doc)
+}
+
+// ImportSession restores a document produced by Export into store and returns
+// a default session bound to the exported namespace. Existing short-term
+// history and long-term memory in that namespace are replaced; other
+// namespaces are untouched. To restore into a session with custom settings,
+// create it and call its Import method instead.
+func ImportSession(store MemoryStore, data []byte) (*MemorySession, error) {
+	var doc SessionExport
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid session export: %w", err)
+	}
+	if doc.AgentID == "" || doc.UserID == "" {
+		return nil, fmt.Errorf("invalid session export: missing agent_id or user_id")
+	}
+	s := NewMemorySession(doc.AgentID, doc.UserID, store)
+	if err := s.importDoc(&doc); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Import restores a document produced by Export into this session, replacing
+// its short-term history and long-term memory. The document's agent_id,
+// user_id and namespace must match the session, so an edited export cannot
+// overwrite another user's memory. History entries go through the same role
+// validation as ShortTermMemory.AddMessage.
+func (s *MemorySession) Import(data []byte) error {
+	var doc SessionExport
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("invalid session export: %w", err)
+	}
+	return s.importDoc(&doc)
+}
+
+func (s *MemorySession) importDoc(doc *SessionExport) error {
+	if doc.AgentID != s.AgentID || doc.UserID != s.UserID {
+		return fmt.Errorf("invalid session export: agent_id/user_id %q/%q do not match session %q/%q",
+			doc.AgentID, doc.UserID, s.AgentID, s.UserID)
+	}
+	if doc.Namespace != "" && doc.Namespace != s.Namespace {
+		return fmt.Errorf("invalid session export: namespace %q does not match session namespace %q", doc.Namespace, s.Namespace)
+	}
+	for i, m := range doc.ShortTerm {
+		if err := validateMessageRole(m.Role); err != nil {
+			return fmt.Errorf("invalid session export: short_term[%d]: %w", i, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ShortTerm.Clear(); err != nil {
+		return err
+	}
+	for _, m := range doc.ShortTerm {
+		if err := s.ShortTerm.appendMessage(m); err != nil {
+			return err
+		}
+	}
+	if doc.LongTerm != nil {
+		if err := s.LongTerm.Save(doc.LongTerm); err != nil {
+			return err
+		}
+	}
+	for k, v := range doc.Working {
+		s.Working.Set(k, v)
+	}
+	return nil
+}
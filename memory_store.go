@@ -2,6 +2,7 @@ package agentsdk
 
 import (
 	"sync"
+	"time"
 )
 
 // MemoryStore is the pluggable storage backend interface for the memory framework.
@@ -15,6 +16,17 @@ type MemoryStore interface {
 	Delete(namespace, key string) error
 	ListKeys(namespace string) ([]string, error)
 
+	// Batch KV operations (one round-trip on remote stores).
+	// MGet omits keys that do not exist from the returned map.
+	MGet(namespace string, keys []string) (map[string]string, error)
+	MSet(namespace string, kv map[string]string) error
+
+	// Expiring KV operations. SetWithTTL with ttl <= 0 behaves like Set;
+	// a plain Set clears any previous expiry. TTL reports the remaining
+	// lifetime, or 0 when the key has no expiry or does not exist.
+	SetWithTTL(namespace, key, value string, ttl time.Duration) error
+	TTL(namespace, key string) (time.Duration, error)
+
 	// List operations (ordered sequences for chat history, buffer)
 	Append(namespace, key, value string) error
 	GetList(namespace, key string, limit, offset int) ([]string, error)
@@ -24,58 +36,140 @@ type MemoryStore interface {
 }
 
 // InMemoryMemoryStore is a thread-safe in-memory MemoryStore for development.
-// Data is lost on restart.
+// Data is lost on restart. Expired keys are dropped lazily when read.
 type InMemoryMemoryStore struct {
-	mu    sync.RWMutex
-	kv    map[string]map[string]string
-	lists map[string]map[string][]string
+	// Clock decides when keys set via SetWithTTL expire; nil = system time.
+	Clock Clock
+
+	mu      sync.RWMutex
+	kv      map[string]map[string]string
+	expires map[string]map[string]time.Time
+	lists   map[string]map[string][]string
 }
 
 // NewInMemoryMemoryStore creates a new in-memory store.
 func NewInMemoryMemoryStore() *InMemoryMemoryStore {
 	return &InMemoryMemoryStore{
-		kv:    make(map[string]map[string]string),
-		lists: make(map[string]map[string][]string),
+		kv:      make(map[string]map[string]string),
+		expires: make(map[string]map[string]time.Time),
+		lists:   make(map[string]map[string][]string),
 	}
 }
 
 func (s *InMemoryMemoryStore) Get(namespace, key string) (string, error) {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-	if ns, ok := s.kv[namespace]; ok {
-		if v, ok := ns[key]; ok {
-			return v, nil
+	v, ok := s.kv[namespace][key]
+	expired := ok && s.expiredLocked(namespace, key, nowFrom(s.Clock))
+	s.mu.RUnlock()
+	if expired {
+		s.mu.Lock()
+		if s.expiredLocked(namespace, key, nowFrom(s.Clock)) {
+			s.deleteLocked(namespace, key)
 		}
+		s.mu.Unlock()
+		return "", nil
 	}
-	return "", nil
+	return v, nil
 }
 
 func (s *InMemoryMemoryStore) Set(namespace, key, value string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if s.kv[namespace] == nil {
-		s.kv[namespace] = make(map[string]string)
+	s.setLocked(namespace, key, value)
+	return nil
+}
+
+func (s *InMemoryMemoryStore) SetWithTTL(namespace, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setLocked(namespace, key, value)
+	if ttl > 0 {
+		if s.expires[namespace] == nil {
+			s.expires[namespace] = make(map[string]time.Time)
+		}
+		s.expires[namespace][key] = nowFrom(s.Clock).Add(ttl)
 	}
-	s.kv[namespace][key] = value
 	return nil
 }
 
+func (s *InMemoryMemoryStore) TTL(namespace, key string) (time.Duration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.kv[namespace][key]; !ok {
+		return 0, nil
+	}
+	at, ok := s.expires[namespace][key]
+	if !ok {
+		return 0, nil
+	}
+	if d := at.Sub(nowFrom(s.Clock)); d > 0 {
+		return d, nil
+	}
+	return 0, nil
+}
+
 func (s *InMemoryMemoryStore) Delete(namespace, key string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if ns, ok := s.kv[namespace]; ok {
-		delete(ns, key)
+	s.deleteLocked(namespace, key)
+	return nil
+}
+
+func (s *InMemoryMemoryStore) MGet(namespace string, keys []string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make(map[string]string, len(keys))
+	ns := s.kv[namespace]
+	now := nowFrom(s.Clock)
+	for _, k := range keys {
+		if v, ok := ns[k]; ok && !s.expiredLocked(namespace, k, now) {
+			result[k] = v
+		}
+	}
+	return result, nil
+}
+
+func (s *InMemoryMemoryStore) MSet(namespace string, kv map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range kv {
+		s.setLocked(namespace, k, v)
 	}
 	return nil
 }
 
+// setLocked stores value and clears any expiry. Caller holds s.mu.
+func (s *InMemoryMemoryStore) setLocked(namespace, key, value string) {
+	if s.kv[namespace] == nil {
+		s.kv[namespace] = make(map[string]string)
+	}
+	s.kv[namespace][key] = value
+	delete(s.expires[namespace], key)
+}
+
+// deleteLocked removes a KV entry and its expiry. Caller holds s.mu.
+func (s *InMemoryMemoryStore) deleteLocked(namespace, key string) {
+	delete(s.kv[namespace], key)
+	delete(s.expires[namespace], key)
+}
+
+// expiredLocked reports whether key has an expiry at or before now.
+// Caller holds s.mu for reading.
+func (s *InMemoryMemoryStore) expiredLocked(namespace, key string, now time.Time) bool {
+	at, ok := s.expires[namespace][key]
+	return ok && !now.Before(at)
+}
+
 func (s *InMemoryMemoryStore) ListKeys(namespace string) ([]string, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	seen := make(map[string]bool)
+	now := nowFrom(s.Clock)
 	if ns, ok := s.kv[namespace]; ok {
 		for k := range ns {
-			seen[k] = true
+			if !s.expiredLocked(namespace, k, now) {
+				seen[k] = true
+			}
 		}
 	}
 	if ns, ok := s.lists[namespace]; ok {
@@ -111,12 +111,71 @@ func (s *RedisMemoryStore) Set(namespace, key, value string) error {
 	return s.client.Set(ctx, s.fullKey(namespace, key), value, 0).Err()
 }
 
+func (s *RedisMemoryStore) SetWithTTL(namespace, key, value string, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = 0
+	}
+	ctx, cancel := s.newContext()
+	defer cancel()
+	return s.client.Set(ctx, s.fullKey(namespace, key), value, ttl).Err()
+}
+
+// TTL maps Redis' negative PTTL replies (no expiry, missing key) to 0.
+func (s *RedisMemoryStore) TTL(namespace, key string) (time.Duration, error) {
+	ctx, cancel := s.newContext()
+	defer cancel()
+	d, err := s.client.PTTL(ctx, s.fullKey(namespace, key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, nil
+	}
+	return d, nil
+}
+
 func (s *RedisMemoryStore) Delete(namespace, key string) error {
 	ctx, cancel := s.newContext()
 	defer cancel()
 	return s.client.Del(ctx, s.fullKey(namespace, key)).Err()
 }
 
+func (s *RedisMemoryStore) MGet(namespace string, keys []string) (map[string]string, error) {
+	result := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	fullKeys := make([]string, len(keys))
+	for i, k := range keys {
+		fullKeys[i] = s.fullKey(namespace, k)
+	}
+	ctx, cancel := s.newContext()
+	defer cancel()
+	values, err := s.client.MGet(ctx, fullKeys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		if str, ok := v.(string); ok {
+			result[keys[i]] = str
+		}
+	}
+	return result, nil
+}
+
+func (s *RedisMemoryStore) MSet(namespace string, kv map[string]string) error {
+	if len(kv) == 0 {
+		return nil
+	}
+	pairs := make([]interface{}, 0, len(kv)*2)
+	for k, v := range kv {
+		pairs = append(pairs, s.fullKey(namespace, k), v)
+	}
+	ctx, cancel := s.newContext()
+	defer cancel()
+	return s.client.MSet(ctx, pairs...).Err()
+}
+
 func (s *RedisMemoryStore) ListKeys(namespace string) ([]string, error) {
 	ctx, cancel := s.newContext()
 	defer cancel()
@@ -53,6 +53,40 @@ func TestRedisMemoryStore_KVOperations(t *testing.T) {
 	}
 }
 
+func TestRedisMemoryStore_SetWithTTL(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	store, err := NewRedisMemoryStore(RedisMemoryStoreOptions{Addr: mr.Addr(), KeyPrefix: "test:memory"})
+	if err != nil {
+		t.Fatalf("create redis memory store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	if err := store.SetWithTTL("ns", "counter", "1", 30*time.Second); err != nil {
+		t.Fatalf("set with ttl failed: %v", err)
+	}
+	if d, err := store.TTL("ns", "counter"); err != nil || d != 30*time.Second {
+		t.Fatalf("expected 30s ttl, got %v (%v)", d, err)
+	}
+	if err := store.Set("ns", "plain", "x"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if d, _ := store.TTL("ns", "plain"); d != 0 {
+		t.Fatalf("expected 0 ttl for persistent key, got %v", d)
+	}
+	if d, _ := store.TTL("ns", "missing"); d != 0 {
+		t.Fatalf("expected 0 ttl for missing key, got %v", d)
+	}
+
+	mr.FastForward(31 * time.Second)
+	if got, _ := store.Get("ns", "counter"); got != "" {
+		t.Fatalf("expected key to expire, got %q", got)
+	}
+}
+
 func TestRedisMemoryStore_ListOperations(t *testing.T) {
 	store := newTestRedisStore(t)
 
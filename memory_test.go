@@ -2,7 +2,13 @@ package agentsdk
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // ══════════════════════════════════════════════
@@ -75,6 +81,91 @@ func TestMemStore_NamespaceIsolation(t *testing.T) {
 	}
 }
 
+func TestMemStore_MGetMSet(t *testing.T) {
+	s := NewInMemoryMemoryStore()
+	if err := s.MSet("ns", map[string]string{"a": "1", "b": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.MGet("ns", []string{"a", "b", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["a"] != "1" || got["b"] != "2" {
+		t.Fatalf("unexpected MGet result: %v", got)
+	}
+	if _, ok := got["missing"]; ok {
+		t.Fatal("missing keys should be omitted")
+	}
+	v, _ := s.Get("ns", "a")
+	if v != "1" {
+		t.Fatalf("MSet value not visible via Get, got %q", v)
+	}
+}
+
+func TestMemStore_MGetNamespaceIsolation(t *testing.T) {
+	s := NewInMemoryMemoryStore()
+	s.MSet("a1:u1", map[string]string{"k": "v1"})
+	s.MSet("a2:u1", map[string]string{"k": "v2"})
+	got1, _ := s.MGet("a1:u1", []string{"k"})
+	got2, _ := s.MGet("a2:u1", []string{"k"})
+	got3, _ := s.MGet("a3:u1", []string{"k"})
+	if got1["k"] != "v1" || got2["k"] != "v2" || len(got3) != 0 {
+		t.Fatalf("namespace isolation failed: %v %v %v", got1, got2, got3)
+	}
+}
+
+func TestMemStore_SetWithTTLExpiresLazily(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewInMemoryMemoryStore()
+	s.Clock = ClockFunc(func() time.Time { return now })
+
+	s.SetWithTTL("ns", "opener_count", "3", time.Minute)
+	if v, _ := s.Get("ns", "opener_count"); v != "3" {
+		t.Fatalf("expected 3 before expiry, got %q", v)
+	}
+
+	now = now.Add(time.Minute)
+	if v, _ := s.Get("ns", "opener_count"); v != "" {
+		t.Fatalf("expected expired key to read as empty, got %q", v)
+	}
+	if got, _ := s.MGet("ns", []string{"opener_count"}); len(got) != 0 {
+		t.Fatalf("expired key should be omitted from MGet: %v", got)
+	}
+	if keys, _ := s.ListKeys("ns"); len(keys) != 0 {
+		t.Fatalf("expired key should be omitted from ListKeys: %v", keys)
+	}
+}
+
+func TestMemStore_TTLReporting(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewInMemoryMemoryStore()
+	s.Clock = ClockFunc(func() time.Time { return now })
+
+	s.SetWithTTL("ns", "k", "v", 10*time.Second)
+	now = now.Add(4 * time.Second)
+	if d, err := s.TTL("ns", "k"); err != nil || d != 6*time.Second {
+		t.Fatalf("expected 6s remaining, got %v (%v)", d, err)
+	}
+
+	// A plain Set clears the expiry.
+	s.Set("ns", "k", "v2")
+	if d, _ := s.TTL("ns", "k"); d != 0 {
+		t.Fatalf("expected no expiry after Set, got %v", d)
+	}
+	now = now.Add(time.Hour)
+	if v, _ := s.Get("ns", "k"); v != "v2" {
+		t.Fatalf("expected persistent value, got %q", v)
+	}
+
+	if d, _ := s.TTL("ns", "missing"); d != 0 {
+		t.Fatalf("expected 0 for missing key, got %v", d)
+	}
+	s.SetWithTTL("ns", "nottl", "v", 0)
+	if d, _ := s.TTL("ns", "nottl"); d != 0 {
+		t.Fatalf("expected ttl <= 0 to mean no expiry, got %v", d)
+	}
+}
+
 // ══════════════════════════════════════════════
 // WorkingMemory
 // ══════════════════════════════════════════════
@@ -131,6 +222,54 @@ func TestWorkingMemory_GetInt_Default(t *testing.T) {
 	}
 }
 
+func TestWorkingMemory_IncrBy(t *testing.T) {
+	wm := NewWorkingMemory()
+	if v := wm.IncrBy("score", 5); v != 5 {
+		t.Fatalf("expected 5, got %d", v)
+	}
+	if v := wm.IncrBy("score", -2); v != 3 {
+		t.Fatalf("expected 3, got %d", v)
+	}
+	wm.Set("wrong_type", "x")
+	if v := wm.IncrBy("wrong_type", 2); v != 2 {
+		t.Fatalf("expected wrong type to restart from 0, got %d", v)
+	}
+}
+
+func TestWorkingMemory_GetFloat_Default(t *testing.T) {
+	wm := NewWorkingMemory()
+	if v := wm.GetFloat("missing"); v != 0 {
+		t.Fatalf("expected 0 for missing key, got %v", v)
+	}
+	wm.Set("wrong_type", "0.8")
+	if v := wm.GetFloat("wrong_type"); v != 0 {
+		t.Fatalf("expected 0 for wrong type, got %v", v)
+	}
+	wm.SetFloat("sdk.user.emotion_confidence", 0.85)
+	if v := wm.GetFloat("sdk.user.emotion_confidence"); v != 0.85 {
+		t.Fatalf("expected 0.85, got %v", v)
+	}
+	wm.SetInt("count", 3)
+	if v := wm.GetFloat("count"); v != 3 {
+		t.Fatalf("expected int widened to 3, got %v", v)
+	}
+}
+
+func TestWorkingMemory_GetBool_Default(t *testing.T) {
+	wm := NewWorkingMemory()
+	if wm.GetBool("missing") {
+		t.Fatal("expected false for missing key")
+	}
+	wm.Set("wrong_type", "true")
+	if wm.GetBool("wrong_type") {
+		t.Fatal("expected false for wrong type")
+	}
+	wm.SetBool("greeted", true)
+	if !wm.GetBool("greeted") {
+		t.Fatal("expected true")
+	}
+}
+
 func TestWorkingMemory_GetString_Default(t *testing.T) {
 	wm := NewWorkingMemory()
 	if v := wm.GetString("missing"); v != "" {
@@ -194,6 +333,73 @@ func TestSTM_GetHistoryMaps(t *testing.T) {
 	}
 }
 
+func TestSTM_AddMessageRejectsInvalidRole(t *testing.T) {
+	s := NewInMemoryMemoryStore()
+	stm := NewShortTermMemory(s, "test:u1", 40)
+	err := stm.AddMessage("bot", "hi")
+	if !errors.Is(err, ErrInvalidMessageRole) {
+		t.Fatalf("expected ErrInvalidMessageRole, got %v", err)
+	}
+	if n, _ := stm.Count(); n != 0 {
+		t.Fatalf("invalid message must not be stored, count=%d", n)
+	}
+}
+
+func TestSTM_GetHistoryMapsFiltered(t *testing.T) {
+	s := NewInMemoryMemoryStore()
+	stm := NewShortTermMemory(s, "test:u1", 40)
+	stm.AddMessage("system", "be nice")
+	stm.AddMessage("user", "hi")
+	stm.AddMessage("assistant", "hello")
+
+	maps, _ := stm.GetHistoryMapsFiltered("system")
+	if len(maps) != 2 || maps[0]["role"] != "user" || maps[1]["role"] != "assistant" {
+		t.Fatalf("expected system message filtered out, got %v", maps)
+	}
+	if all, _ := stm.GetHistoryMapsFiltered(); len(all) != 3 {
+		t.Fatalf("expected all 3 messages without filter, got %d", len(all))
+	}
+}
+
+func TestSTM_GetHistoryPage(t *testing.T) {
+	s := NewInMemoryMemoryStore()
+	stm := NewShortTermMemory(s, "test:u1", 40)
+	for i := 0; i < 5; i++ {
+		stm.AddMessage("user", fmt.Sprintf("msg%d", i))
+	}
+
+	page, _ := stm.GetHistoryPage(1, 2)
+	if len(page) != 2 || page[0].Content != "msg1" || page[1].Content != "msg2" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	tail, _ := stm.GetHistoryPage(4, 10)
+	if len(tail) != 1 || tail[0].Content != "msg4" {
+		t.Fatalf("unexpected last page: %+v", tail)
+	}
+	if past, _ := stm.GetHistoryPage(5, 2); len(past) != 0 {
+		t.Fatalf("expected empty page past end, got %+v", past)
+	}
+	if all, _ := stm.GetHistoryPage(-1, 0); len(all) != 5 {
+		t.Fatalf("expected all 5 messages, got %d", len(all))
+	}
+}
+
+func TestSTM_SearchHistory(t *testing.T) {
+	s := NewInMemoryMemoryStore()
+	stm := NewShortTermMemory(s, "test:u1", 40)
+	stm.AddMessage("user", "I love Tarot cards")
+	stm.AddMessage("assistant", "Great!")
+	stm.AddMessage("user", "draw a tarot for me")
+
+	found, _ := stm.SearchHistory("tarot")
+	if len(found) != 2 || found[0].Role != "user" || found[1].Content != "draw a tarot for me" {
+		t.Fatalf("unexpected search result: %+v", found)
+	}
+	if none, _ := stm.SearchHistory("weather"); len(none) != 0 {
+		t.Fatalf("expected no matches, got %+v", none)
+	}
+}
+
 // ══════════════════════════════════════════════
 // LongTermMemory
 // ══════════════════════════════════════════════
@@ -250,6 +456,43 @@ func TestLTM_UpdateIncrementsCount(t *testing.T) {
 	}
 }
 
+func TestLTM_UpdateUsesInjectedClock(t *testing.T) {
+	fixed := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	ltm := NewLongTermMemory(NewInMemoryMemoryStore(), "test:u1", 0)
+	ltm.Clock = ClockFunc(func() time.Time { return fixed })
+
+	data, _ := ltm.Get()
+	if meta := data["meta"].(map[string]interface{}); meta["created_at"] != "2024-03-01T09:30:00Z" {
+		t.Fatalf("expected injected created_at, got %v", meta["created_at"])
+	}
+
+	fixed = fixed.Add(time.Hour)
+	result, err := ltm.Update(map[string]interface{}{"summary": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta := result["meta"].(map[string]interface{}); meta["updated_at"] != "2024-03-01T10:30:00Z" {
+		t.Fatalf("expected injected updated_at, got %v", meta["updated_at"])
+	}
+}
+
+func TestLTM_CacheTTLUsesInjectedClock(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	s := NewInMemoryMemoryStore()
+	ltm := NewLongTermMemory(s, "test:u1", time.Minute)
+	ltm.Clock = ClockFunc(func() time.Time { return now })
+	ltm.Save(map[string]interface{}{"custom": "v1", "meta": map[string]interface{}{}})
+
+	s.Set("test:u1", "long_term", `{"custom":"v2"}`)
+	if data, _ := ltm.Get(); data["custom"] != "v1" {
+		t.Fatalf("expected cached v1 within TTL, got %v", data["custom"])
+	}
+	now = now.Add(2 * time.Minute)
+	if data, _ := ltm.Get(); data["custom"] != "v2" {
+		t.Fatalf("expected store reload after TTL, got %v", data["custom"])
+	}
+}
+
 func TestLTM_Delete(t *testing.T) {
 	s := NewInMemoryMemoryStore()
 	ltm := NewLongTermMemory(s, "test:u1", 0)
@@ -261,6 +504,59 @@ func TestLTM_Delete(t *testing.T) {
 	}
 }
 
+func medicalSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"medical_history": map[string]interface{}{},
+		"medications":     []interface{}{},
+		"meta":            map[string]interface{}{"conversation_count": float64(0)},
+	}
+}
+
+func TestLTM_CustomSchemaDefault(t *testing.T) {
+	session := NewMemorySessionWithSchema("a1", "u1", NewInMemoryMemoryStore(), medicalSchema)
+	data, err := session.LongTerm.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := data["medical_history"]; !ok {
+		t.Fatalf("expected custom schema, got %v", data)
+	}
+	if _, ok := data["basic_info"]; ok {
+		t.Fatal("default schema keys should not be present")
+	}
+}
+
+func TestLTM_CustomSchemaMerge(t *testing.T) {
+	session := NewMemorySessionWithSchema("a1", "u1", NewInMemoryMemoryStore(), medicalSchema)
+	session.LongTerm.Update(map[string]interface{}{
+		"medical_history": map[string]interface{}{"allergy": "penicillin"},
+		"medications":     []interface{}{"ibuprofen"},
+	})
+	result, err := session.LongTerm.Update(map[string]interface{}{
+		"medical_history": map[string]interface{}{"blood_type": "O"},
+		"medications":     []interface{}{"vitamin D"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mh := result["medical_history"].(map[string]interface{})
+	if mh["allergy"] != "penicillin" || mh["blood_type"] != "O" {
+		t.Fatalf("merge failed: %v", mh)
+	}
+	if meds := result["medications"].([]interface{}); len(meds) != 2 {
+		t.Fatalf("expected 2 medications, got %v", meds)
+	}
+	if meta := result["meta"].(map[string]interface{}); meta["conversation_count"] != float64(2) {
+		t.Fatalf("expected count=2, got %v", meta["conversation_count"])
+	}
+
+	prompt := FormatMemoryForPrompt(result, nil, "")
+	if !strings.Contains(prompt, "medical_history: allergy=penicillin, blood_type=O") ||
+		!strings.Contains(prompt, "medications: ibuprofen, vitamin D") {
+		t.Fatalf("expected custom keys in prompt, got:\n%s", prompt)
+	}
+}
+
 // ══════════════════════════════════════════════
 // ConversationBuffer
 // ══════════════════════════════════════════════
@@ -278,7 +574,7 @@ func TestBuffer_AddAndCount(t *testing.T) {
 
 func TestBuffer_ShouldExtract(t *testing.T) {
 	s := NewInMemoryMemoryStore()
-	buf := NewConversationBuffer(s, "test:u1", 3, 24*3600*1e9)
+	buf := NewConversationBuffer(s, "test:u1", 3, 24*time.Hour)
 
 	should, _ := buf.ShouldExtract()
 	if should {
@@ -307,6 +603,27 @@ func TestBuffer_ShouldExtract(t *testing.T) {
 	}
 }
 
+func TestBuffer_ShouldExtract_TimeTrigger(t *testing.T) {
+	s := NewInMemoryMemoryStore()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := ClockFunc(func() time.Time { return now })
+	buf := NewConversationBuffer(s, "test:u1", 10, time.Hour, clock)
+
+	buf.Add("user", "a")
+	buf.GetAndClear()
+	buf.Add("user", "b")
+
+	now = now.Add(59 * time.Minute)
+	if should, _ := buf.ShouldExtract(); should {
+		t.Fatal("interval not yet elapsed, should not trigger")
+	}
+
+	now = now.Add(time.Minute)
+	if should, _ := buf.ShouldExtract(); !should {
+		t.Fatal("interval elapsed, should trigger")
+	}
+}
+
 func TestBuffer_GetAndClear(t *testing.T) {
 	s := NewInMemoryMemoryStore()
 	buf := NewConversationBuffer(s, "test:u1", 5, 0)
@@ -375,6 +692,54 @@ func TestLLMExtractor_EmptyConversations(t *testing.T) {
 	}
 }
 
+func TestLLMExtractor_ConfigBuildsPrompt(t *testing.T) {
+	var gotPrompt string
+	ext := NewLLMMemoryExtractorWithConfig(func(prompt string) (string, error) {
+		gotPrompt = prompt
+		return `{"interests": ["chess"], "basic_info": {"age": 40}}`, nil
+	}, ExtractorConfig{
+		Sections: []string{"interests", "summary"},
+		FewShot: []ExampleTurn{{
+			Conversation: []map[string]string{{"role": "user", "content": "I play go on weekends"}},
+			Output:       `{"interests": ["go"]}`,
+		}},
+		MaxInputMessages: 2,
+	})
+
+	result, err := ext.Extract([]map[string]string{
+		{"role": "user", "content": "old message"},
+		{"role": "user", "content": "I like chess"},
+		{"role": "assistant", "content": "Nice!"},
+	}, map[string]interface{}{"interests": []interface{}{"music"}, "basic_info": map[string]interface{}{"age": 39}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"interests, summary", "示例 1", "I play go on weekends", `{"interests": ["go"]}`, "I like chess", "music"} {
+		if !strings.Contains(gotPrompt, want) {
+			t.Fatalf("prompt missing %q:\n%s", want, gotPrompt)
+		}
+	}
+	if strings.Contains(gotPrompt, "old message") {
+		t.Fatal("MaxInputMessages should drop older messages")
+	}
+	if strings.Contains(gotPrompt, `"age": 39`) {
+		t.Fatal("current memory outside Sections should not be sent")
+	}
+	if _, ok := result["basic_info"]; ok || result["interests"] == nil {
+		t.Fatalf("result should be limited to Sections, got %v", result)
+	}
+}
+
+func TestLLMExtractor_SimpleConstructorPromptUnchanged(t *testing.T) {
+	ext := NewLLMMemoryExtractor(nil, "")
+	conv := []map[string]string{{"role": "user", "content": "hi"}}
+	want := fmt.Sprintf(DefaultExtractionPrompt, "{}", "用户: hi")
+	if got := ext.BuildPrompt(conv, map[string]interface{}{}); got != want {
+		t.Fatalf("unexpected prompt:\n%s", got)
+	}
+}
+
 // ══════════════════════════════════════════════
 // MemoryFormatter
 // ══════════════════════════════════════════════
@@ -399,6 +764,24 @@ func TestFormatter_Empty(t *testing.T) {
 	}
 }
 
+func TestFormatter_SkipsSDKWorkingKeys(t *testing.T) {
+	working := map[string]interface{}{
+		"intent":           "chat",
+		"sdk.opener_count": 2,
+		"sdk.kv_owned":     []string{"sdk.user.emotion_tone"},
+	}
+	result := FormatMemoryForPrompt(nil, working, "")
+	if !strings.Contains(result, "intent: chat") {
+		t.Fatalf("expected application key rendered, got %q", result)
+	}
+	if strings.Contains(result, "sdk.") {
+		t.Fatalf("internal sdk.* keys leaked into prompt: %q", result)
+	}
+	if FormatMemoryForPrompt(nil, map[string]interface{}{"sdk.opener_count": 1}, "") != "" {
+		t.Fatal("expected empty prompt when only sdk.* keys are present")
+	}
+}
+
 func TestFormatter_CustomTemplate(t *testing.T) {
 	m := map[string]interface{}{
 		"basic_info": map[string]interface{}{"age": float64(25)},
@@ -474,7 +857,7 @@ func TestSession_ExtractNoExtractor(t *testing.T) {
 
 func TestSession_ExtractWithExtractor(t *testing.T) {
 	store := NewInMemoryMemoryStore()
-	s := NewMemorySessionWithOptions("a1", "u1", store, 40, 0, 2, 24*3600*1e9)
+	s := NewMemorySessionWithOptions("a1", "u1", store, 40, 0, 2, 24*time.Hour)
 	s.SetExtractor(NewLLMMemoryExtractor(func(prompt string) (string, error) {
 		return `{"basic_info": {"age": 30}}`, nil
 	}, ""))
@@ -491,6 +874,139 @@ func TestSession_ExtractWithExtractor(t *testing.T) {
 	}
 }
 
+func TestSession_HistoryPageAndSearch(t *testing.T) {
+	s := NewMemorySession("a1", "u1", NewInMemoryMemoryStore())
+	s.AddMessage("user", "first")
+	s.AddMessage("assistant", "second")
+	page, _ := s.GetHistoryPage(1, 1)
+	if len(page) != 1 || page[0].Content != "second" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	found, _ := s.SearchHistory("FIRST")
+	if len(found) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(found))
+	}
+}
+
+func TestSession_ExportImportRoundTrip(t *testing.T) {
+	src := NewMemorySession("a1", "u1", NewInMemoryMemoryStore())
+	src.AddMessage("user", "我叫小明")
+	src.AddMessage("assistant", "你好小明")
+	src.UpdateLongTerm(map[string]interface{}{"basic_info": map[string]interface{}{"name": "小明"}})
+	src.Working.Set("intent", "chat")
+
+	data, err := src.Export(SessionExportOptions{IncludeWorking: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := NewInMemoryMemoryStore()
+	other := NewMemorySession("a2", "u1", dst)
+	other.AddMessage("user", "untouched")
+
+	restored, err := ImportSession(dst, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Namespace != "a1:u1" {
+		t.Fatalf("expected namespace a1:u1, got %s", restored.Namespace)
+	}
+
+	reloaded := NewMemorySession("a1", "u1", dst)
+	ctx, _ := reloaded.Load()
+	if len(ctx.ShortTerm) != 2 || ctx.ShortTerm[1].Content != "你好小明" {
+		t.Fatalf("history not restored: %+v", ctx.ShortTerm)
+	}
+	if ctx.ShortTerm[0].Timestamp == "" {
+		t.Fatal("expected timestamps preserved")
+	}
+	bi, _ := ctx.LongTerm["basic_info"].(map[string]interface{})
+	if bi["name"] != "小明" {
+		t.Fatalf("long-term not restored: %v", ctx.LongTerm)
+	}
+	if restored.Working.Get("intent") != "chat" {
+		t.Fatal("expected working memory restored")
+	}
+
+	otherCtx, _ := other.Load()
+	if len(otherCtx.ShortTerm) != 1 || otherCtx.ShortTerm[0].Content != "untouched" {
+		t.Fatal("import should not touch other namespaces")
+	}
+}
+
+func TestSession_ExportExcludesWorkingByDefault(t *testing.T) {
+	s := NewMemorySession("a1", "u1", NewInMemoryMemoryStore())
+	s.Working.Set("k", "v")
+	data, _ := s.Export()
+	var doc SessionExport
+	json.Unmarshal(data, &doc)
+	if doc.Working != nil {
+		t.Fatalf("expected no working memory, got %v", doc.Working)
+	}
+}
+
+func TestImportSession_Invalid(t *testing.T) {
+	if _, err := ImportSession(NewInMemoryMemoryStore(), []byte("not json")); err == nil {
+		t.Fatal("expected error for invalid JSON")
+	}
+	if _, err := ImportSession(NewInMemoryMemoryStore(), []byte(`{"version":1}`)); err == nil {
+		t.Fatal("expected error for missing ids")
+	}
+}
+
+func TestImportSession_RejectsForeignNamespace(t *testing.T) {
+	store := NewInMemoryMemoryStore()
+	victim := NewMemorySession("b", "v", store)
+	victim.AddMessage("user", "private")
+
+	data := []byte(`{"version":1,"agent_id":"a","user_id":"u","namespace":"b:v",` +
+		`"short_term":[{"role":"user","content":"overwritten"}]}`)
+	if _, err := ImportSession(store, data); err == nil {
+		t.Fatal("expected namespace mismatch to be rejected")
+	}
+	ctx, _ := victim.Load()
+	if len(ctx.ShortTerm) != 1 || ctx.ShortTerm[0].Content != "private" {
+		t.Fatalf("victim history must be untouched, got %+v", ctx.ShortTerm)
+	}
+}
+
+func TestImportSession_RejectsInvalidRole(t *testing.T) {
+	store := NewInMemoryMemoryStore()
+	existing := NewMemorySession("a", "u", store)
+	existing.AddMessage("user", "keep me")
+
+	data := []byte(`{"version":1,"agent_id":"a","user_id":"u",` +
+		`"short_term":[{"role":"user","content":"hi"},{"role":"hacker","content":"x"}]}`)
+	_, err := ImportSession(store, data)
+	if !errors.Is(err, ErrInvalidMessageRole) {
+		t.Fatalf("expected ErrInvalidMessageRole, got %v", err)
+	}
+	if n, _ := existing.ShortTerm.Count(); n != 1 {
+		t.Fatalf("failed import must not clear history, got %d messages", n)
+	}
+}
+
+func TestSession_ImportKeepsSessionSettings(t *testing.T) {
+	src := NewMemorySession("a", "u", NewInMemoryMemoryStore())
+	for i := 0; i < 5; i++ {
+		src.AddMessage("user", fmt.Sprintf("m%d", i))
+	}
+	data, _ := src.Export()
+
+	dst := NewMemorySessionWithOptions("a", "u", NewInMemoryMemoryStore(), 3, time.Minute, 5, time.Hour)
+	if err := dst.Import(data); err != nil {
+		t.Fatal(err)
+	}
+	history, _ := dst.ShortTerm.GetHistory(0)
+	if len(history) != 3 || history[0].Content != "m2" {
+		t.Fatalf("expected the session's max of 3 recent messages, got %+v", history)
+	}
+
+	if err := NewMemorySession("a", "other", NewInMemoryMemoryStore()).Import(data); err == nil {
+		t.Fatal("expected import into a different user's session to fail")
+	}
+}
+
 func TestSession_ClearHistory(t *testing.T) {
 	s := NewMemorySession("a1", "u1", NewInMemoryMemoryStore())
 	s.AddMessage("user", "x")
@@ -525,6 +1041,70 @@ func TestSession_UpdateLongTerm(t *testing.T) {
 	}
 }
 
+func TestSession_ConcurrentAccess(t *testing.T) {
+	// Slow list reads widen the window between reading and clearing the
+	// extraction buffer, where an unsynchronized AddMessage would be lost.
+	store := slowListStore{NewInMemoryMemoryStore()}
+	s := NewMemorySessionWithOptions("a1", "u1", store, 1000, time.Minute, 3, 24*time.Hour)
+	var extractedMsgs int64
+	s.SetExtractor(NewLLMMemoryExtractor(func(prompt string) (string, error) {
+		return `{"summary": "ok"}`, nil
+	}, ""))
+	s.extractor = countingExtractor{inner: s.extractor, n: &extractedMsgs}
+
+	const writers, perWriter = 4, 25
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				s.AddMessage("user", fmt.Sprintf("w%d-%d", w, i))
+				s.ExtractIfNeeded()
+			}
+		}(w)
+	}
+	for r := 0; r < 2; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				s.Load()
+				s.UpdateLongTerm(map[string]interface{}{"interests": []interface{}{"x"}})
+				s.FormatForPrompt("")
+			}
+		}()
+	}
+	wg.Wait()
+
+	history, _ := s.ShortTerm.GetHistory(0)
+	if len(history) != writers*perWriter {
+		t.Fatalf("expected %d messages in history, got %d", writers*perWriter, len(history))
+	}
+	remaining, _ := s.Buffer.GetAndClear()
+	if got := atomic.LoadInt64(&extractedMsgs) + int64(len(remaining)); got != writers*perWriter {
+		t.Fatalf("each message should be extracted exactly once: extracted+buffered=%d, want %d", got, writers*perWriter)
+	}
+}
+
+type slowListStore struct{ MemoryStore }
+
+func (s slowListStore) GetList(namespace, key string, limit, offset int) ([]string, error) {
+	items, err := s.MemoryStore.GetList(namespace, key, limit, offset)
+	time.Sleep(100 * time.Microsecond)
+	return items, err
+}
+
+type countingExtractor struct {
+	inner MemoryExtractorInterface
+	n     *int64
+}
+
+func (c countingExtractor) Extract(conv []map[string]string, current map[string]interface{}) (map[string]interface{}, error) {
+	atomic.AddInt64(c.n, int64(len(conv)))
+	return c.inner.Extract(conv, current)
+}
+
 // ══════════════════════════════════════════════
 // DeepMerge
 // ══════════════════════════════════════════════
@@ -102,14 +102,23 @@ func (t *TypedMemoryStore) ListByType(memType MemoryType) ([]TypedMemory, error)
 	}
 
 	seen := make(map[string]bool)
-	var memories []TypedMemory
+	var keys []string
 	for _, id := range ids {
 		if seen[id] {
 			continue
 		}
 		seen[id] = true
-		raw, err := t.store.Get(t.namespace, t.memKey(memType, id))
-		if err != nil || raw == "" {
+		keys = append(keys, t.memKey(memType, id))
+	}
+	values, err := t.store.MGet(t.namespace, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var memories []TypedMemory
+	for _, key := range keys {
+		raw := values[key]
+		if raw == "" {
 			continue
 		}
 		var mem TypedMemory
@@ -3,6 +3,7 @@ package agentsdk
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -36,6 +37,17 @@ type NaturalConversationConfig struct {
 	SummarizeFn      SummarizeFn // required when ContextCompress=true
 	Timezone         string      // default "UTC"
 	FollowUpWindow   time.Duration
+
+	// PersistKVToWorking mirrors fragments.KV (sdk.* keys) into
+	// session.Working after Enhance, so later turns and tools can read e.g.
+	// "sdk.user.emotion_tone". Keys already present in working memory that
+	// the SDK did not write itself are left untouched. Like all "sdk." keys
+	// they are not rendered by MemorySession.FormatForPrompt.
+	PersistKVToWorking bool
+
+	// Clock supplies "now" for NaturalAgentLoop runs (state tracking,
+	// persona ticks); nil = system time. Enhance callers pass now directly.
+	Clock Clock
 }
 
 // DefaultNaturalConversationConfig returns the Recommended baseline.
@@ -69,12 +81,9 @@ type NaturalConversation struct {
 
 // NewNaturalConversation creates the enhancement pipeline.
 func NewNaturalConversation(config NaturalConversationConfig) *NaturalConversation {
-	nc := &NaturalConversation{config: config}
-
-	// Persona: merge blocked phrases into StyleConfig
+	// Persona: merge blocked phrases into StyleConfig once, before the
+	// config is stored, so EffectiveStyleConfig reflects the merge.
 	if config.PersonaConfig != nil {
-		nc.personaConfig = config.PersonaConfig
-		nc.personaTicker = config.PersonaTicker
 		// Build style constraints to get the blocked phrases list
 		sc := persona.BuildStyleConstraints(config.PersonaConfig.StylePolicy)
 		if len(sc.BlockedPhrases) > 0 {
@@ -84,6 +93,12 @@ func NewNaturalConversation(config NaturalConversationConfig) *NaturalConversati
 		}
 	}
 
+	nc := &NaturalConversation{
+		config:        config,
+		personaConfig: config.PersonaConfig,
+		personaTicker: config.PersonaTicker,
+	}
+
 	if config.StateTracking {
 		nc.stateTracker = NewConversationStateTracker(config.Timezone)
 	}
@@ -103,6 +118,28 @@ func NewNaturalConversation(config NaturalConversationConfig) *NaturalConversati
 	return nc
 }
 
+// EffectiveStyleConfig returns the StyleConfig in effect after persona blocked
+// phrases have been merged (and the forbidden-phrases file loaded, if any).
+func (nc *NaturalConversation) EffectiveStyleConfig() StyleConfig {
+	cfg := nc.config.StyleConfig
+	if nc.styleCtrl != nil {
+		cfg = nc.styleCtrl.config
+	}
+	cfg.ForbiddenPhrases = append([]string(nil), cfg.ForbiddenPhrases...)
+	return cfg
+}
+
+// PersonaState runs the persona ticker for userID at now without a full
+// Enhance pass, e.g. so a ProactiveScheduler CheckFn can consult the current
+// mood/energy before reaching out. It touches no session or conversation
+// state. Returns nil when no PersonaConfig or PersonaTicker is configured.
+func (nc *NaturalConversation) PersonaState(userID string, now time.Time) *persona.PersonaTick {
+	if nc.personaTicker == nil || nc.personaConfig == nil {
+		return nil
+	}
+	return nc.personaTicker.Tick(nc.personaConfig, userID, now, nil)
+}
+
 func mergeUniqueStrings(a, b []string) []string {
 	seen := make(map[string]bool, len(a))
 	for _, s := range a {
@@ -126,6 +163,25 @@ func (nc *NaturalConversation) Enhance(
 	history []map[string]interface{},
 	now time.Time,
 ) (*PromptFragments, []map[string]interface{}) {
+	e := nc.enhance(session, userInput, history, now)
+	return e.fragments, e.history
+}
+
+// enhanceOutput carries Enhance's results plus the intermediate state and
+// tone, which Process reports back to the caller.
+type enhanceOutput struct {
+	fragments *PromptFragments
+	history   []map[string]interface{}
+	state     *ConversationState
+	tone      *EmotionalTone
+}
+
+func (nc *NaturalConversation) enhance(
+	session *MemorySession,
+	userInput string,
+	history []map[string]interface{},
+	now time.Time,
+) enhanceOutput {
 	fragments := NewPromptFragments()
 	enhancedHistory := history
 
@@ -162,8 +218,9 @@ func (nc *NaturalConversation) Enhance(
 	}
 
 	// 2. Emotion Detection
+	var tone *EmotionalTone
 	if nc.emotionDet != nil {
-		tone := nc.emotionDet.Detect(userInput, state)
+		tone = nc.emotionDet.Detect(userInput, state)
 		if prompt := tone.FormatForPrompt(); prompt != "" {
 			fragments.AddSystem(prompt)
 			fragments.AddWarning("tone." + tone.Tone + ":" + fmt.Sprintf("%.2f", tone.Confidence))
@@ -185,6 +242,7 @@ func (nc *NaturalConversation) Enhance(
 
 	// 4. Style Prompt
 	if nc.styleCtrl != nil {
+		fragments.SetKV("sdk.style.forbidden_count", len(nc.styleCtrl.config.ForbiddenPhrases))
 		if prompt := nc.styleCtrl.BuildStylePrompt(); prompt != "" {
 			fragments.AddSystem(prompt)
 			fragments.AddWarning("style.prompt:preferred_" + fmt.Sprintf("%d", nc.config.StyleConfig.PreferredLength))
@@ -193,14 +251,74 @@ func (nc *NaturalConversation) Enhance(
 
 	// 5. Context Compression
 	if nc.compressor != nil {
-		compressed, err := nc.compressor.Compress(history, session.Working)
-		if err == nil && len(compressed) != len(history) {
+		compressed, err := nc.compressor.compress(history, session.Working)
+		if err != nil {
+			fragments.AddWarning("compressor.error:" + err.Error())
+			if len(compressed) != len(history) {
+				enhancedHistory = compressed
+				fragments.AddWarning("compressor.truncated")
+			}
+		} else if len(compressed) != len(history) {
 			enhancedHistory = compressed
 			fragments.AddWarning("compressor.summarized")
 		}
 	}
 
-	return fragments, enhancedHistory
+	if nc.config.PersistKVToWorking {
+		persistKVToWorking(fragments.KV, session.Working)
+	}
+
+	return enhanceOutput{fragments: fragments, history: enhancedHistory, state: state, tone: tone}
+}
+
+// workingKVOwnedKey records which working-memory keys were mirrored from
+// fragments.KV, so later turns may overwrite them but never user-set keys.
+// The marker is a sorted []string so it survives a JSON Export/Import.
+const workingKVOwnedKey = "sdk.kv_owned"
+
+func persistKVToWorking(kv map[string]interface{}, working *WorkingMemory) {
+	owned := kvOwnedKeys(working.Get(workingKVOwnedKey))
+	for k, v := range kv {
+		if working.Get(k) != nil && !owned[k] {
+			continue // set by the application; don't clobber
+		}
+		working.Set(k, v)
+		owned[k] = true
+	}
+	keys := make([]string, 0, len(owned))
+	for k := range owned {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	working.Set(workingKVOwnedKey, keys)
+}
+
+// kvOwnedKeys reads the ownership marker as a set. It accepts the []string
+// written by persistKVToWorking, the []interface{} it becomes after a JSON
+// round trip, and the map form used by earlier versions.
+func kvOwnedKeys(v interface{}) map[string]bool {
+	owned := make(map[string]bool)
+	switch m := v.(type) {
+	case []string:
+		for _, k := range m {
+			owned[k] = true
+		}
+	case []interface{}:
+		for _, k := range m {
+			if s, ok := k.(string); ok {
+				owned[s] = true
+			}
+		}
+	case map[string]bool:
+		for k := range m {
+			owned[k] = true
+		}
+	case map[string]interface{}:
+		for k := range m {
+			owned[k] = true
+		}
+	}
+	return owned
 }
 
 // PostProcess applies local style corrections to LLM output.
@@ -254,30 +372,64 @@ func (nl *NaturalAgentLoop) Run(session *MemorySession, userInput string, histor
 
 // RunContext executes with context support for cancellation.
 func (nl *NaturalAgentLoop) RunContext(ctx context.Context, session *MemorySession, userInput string, history []map[string]interface{}) *AgentLoopResult {
+	return nl.Process(ctx, session, userInput, history).Result
+}
+
+// NaturalResult bundles the reply of one Process call with the metadata
+// computed along the way, for logging and analytics.
+type NaturalResult struct {
+	FinalOutput       string
+	Result            *AgentLoopResult
+	Fragments         *PromptFragments
+	EmotionTone       *EmotionalTone     // nil when EmotionDetection is off
+	ConversationState *ConversationState // nil when StateTracking is off
+	PostProcessed     bool               // PostProcess changed FinalOutput
+}
+
+// Process runs Enhance → loop → PostProcess on loop and returns the reply
+// together with the computed fragments, tone and conversation state.
+func (nc *NaturalConversation) Process(ctx context.Context, loop *AgentLoop, session *MemorySession, userInput string, history []map[string]interface{}) *NaturalResult {
+	return nc.WrapLoop(loop).Process(ctx, session, userInput, history)
+}
+
+// Process executes Enhance → AgentLoop.Run → PostProcess and returns the
+// reply together with the metadata computed along the way.
+func (nl *NaturalAgentLoop) Process(ctx context.Context, session *MemorySession, userInput string, history []map[string]interface{}) *NaturalResult {
 	// Enhance
-	fragments, enhancedHistory := nl.nc.Enhance(session, userInput, history, time.Now())
+	e := nl.nc.enhance(session, userInput, history, nowFrom(nl.nc.config.Clock))
 	nl.mu.Lock()
-	nl.lastFragments = fragments
+	nl.lastFragments = e.fragments
 	nl.mu.Unlock()
 
-	runExtraContext := fragments.Text()
+	runExtraContext := e.fragments.Text()
 	// Do not mutate shared AgentLoop.SystemPrompt in concurrent runs.
 	if nl.nc.personaConfig != nil && nl.nc.personaConfig.SystemPrompt != "" {
 		runExtraContext = mergeSystemPrompt(nl.nc.personaConfig.SystemPrompt, runExtraContext)
 	}
 
-	// Run
-	result := nl.inner.RunContext(ctx, userInput, enhancedHistory, runExtraContext)
+	// Run on a per-call copy so tools see this session via ToolContext.Session.
+	run := nl.inner.Clone()
+	run.Session = session
+	result := run.RunContext(ctx, userInput, e.history, runExtraContext)
 
 	// PostProcess
+	changed := false
 	if result.StoppedReason == "completed" && result.FinalOutput != "" {
-		corrected, changed := nl.nc.PostProcess(result.FinalOutput)
+		var corrected string
+		corrected, changed = nl.nc.PostProcess(result.FinalOutput)
 		if changed {
 			result.FinalOutput = corrected
 		}
 	}
 
-	return result
+	return &NaturalResult{
+		FinalOutput:       result.FinalOutput,
+		Result:            result,
+		Fragments:         e.fragments,
+		EmotionTone:       e.tone,
+		ConversationState: e.state,
+		PostProcessed:     changed,
+	}
 }
 
 // LastFragments returns the PromptFragments from the most recent Run (for debugging).
@@ -55,6 +55,47 @@ func TestTrack_DaysSinceLast(t *testing.T) {
 	}
 }
 
+func TestTrack_PersistsAcrossTrackerInstances(t *testing.T) {
+	store := NewInMemoryMemoryStore()
+	now := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+
+	// First process: one session five days ago.
+	NewConversationStateTracker("UTC").TouchSession(NewMemorySession("test_agent", "user_1", store), now.AddDate(0, 0, -5))
+
+	if v, _ := store.Get("test_agent:user_1", "sdk.last_session_at"); v != now.AddDate(0, 0, -5).Format(time.RFC3339) {
+		t.Fatalf("expected sdk.last_session_at to be persisted, got %q", v)
+	}
+	if v, _ := store.Get("test_agent:user_1", "sdk.total_sessions"); v != "1" {
+		t.Fatalf("expected sdk.total_sessions=1, got %q", v)
+	}
+
+	// After a restart: fresh tracker and session over the same store.
+	state := NewConversationStateTracker("UTC").Track(NewMemorySession("test_agent", "user_1", store), "hi again", now)
+	if state.IsFirstConversation || state.DaysSinceLast != 5 || state.TotalSessions != 1 {
+		t.Fatalf("expected prior session to be read back, got first=%v days=%d total=%d",
+			state.IsFirstConversation, state.DaysSinceLast, state.TotalSessions)
+	}
+}
+
+func TestTrack_ReadsLegacyMeta(t *testing.T) {
+	store := NewInMemoryMemoryStore()
+	now := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	legacy := fmt.Sprintf(`{"total_sessions":4,"last_at":%q}`, now.AddDate(0, 0, -2).Format(time.RFC3339))
+	store.Set("test_agent:user_1", "sdk.conversation_meta", legacy)
+
+	tracker := NewConversationStateTracker("UTC")
+	session := NewMemorySession("test_agent", "user_1", store)
+	state := tracker.Track(session, "hi", now)
+	if state.DaysSinceLast != 2 || state.TotalSessions != 4 {
+		t.Fatalf("expected legacy meta to be read, got days=%d total=%d", state.DaysSinceLast, state.TotalSessions)
+	}
+
+	tracker.TouchSession(session, now)
+	if v, _ := store.Get("test_agent:user_1", "sdk.total_sessions"); v != "5" {
+		t.Fatalf("expected legacy count to carry over, got %q", v)
+	}
+}
+
 func TestTrack_IsFollowUp(t *testing.T) {
 	tracker := NewConversationStateTracker("UTC")
 	session := newTestSession()
@@ -218,6 +259,71 @@ func TestDetect_FollowUpBoost(t *testing.T) {
 	_ = tone
 }
 
+func TestDetect_Negation_Anxious(t *testing.T) {
+	d := NewEmotionalToneDetector()
+	for _, input := range []string{"我一点都不着急", "别急，慢慢来", "I'm not in a hurry", "no hurry at all"} {
+		tone := d.Detect(input, nil)
+		if tone.Tone != "neutral" || tone.Scores["anxious"] != 0 {
+			t.Fatalf("%q: expected neutral, got %s (scores: %v)", input, tone.Tone, tone.Scores)
+		}
+	}
+}
+
+func TestDetect_Negation_Angry(t *testing.T) {
+	d := NewEmotionalToneDetector()
+	for _, input := range []string{"这个不垃圾", "it's not terrible", "this isn't useless"} {
+		tone := d.Detect(input, nil)
+		if tone.Tone != "neutral" || tone.Scores["angry"] != 0 {
+			t.Fatalf("%q: expected neutral, got %s (scores: %v)", input, tone.Tone, tone.Scores)
+		}
+	}
+}
+
+func TestDetect_Negation_NonNegatedControl(t *testing.T) {
+	d := NewEmotionalToneDetector()
+	// Negation outside the window or behind punctuation does not suppress.
+	cases := map[string]string{
+		"我很着急":                         "anxious",
+		"不行，快点":                        "anxious",
+		"this is terrible":             "angry",
+		"no, this is terrible, fix it": "angry",
+	}
+	for input, want := range cases {
+		tone := d.Detect(input, nil)
+		if tone.Tone != want {
+			t.Fatalf("%q: expected %s, got %s (scores: %v)", input, want, tone.Tone, tone.Scores)
+		}
+	}
+}
+
+func TestDetect_Negation_CompoundsAreNotNegators(t *testing.T) {
+	d := NewEmotionalToneDetector()
+	cases := map[string]string{
+		"我特别着急":  "anxious",
+		"特别难过":   "sad",
+		"我特别失望":  "sad",
+		"差不多难过":  "sad",
+		"不得不着急":  "anxious",
+		"别人都很失望": "sad",
+	}
+	for input, want := range cases {
+		tone := d.Detect(input, nil)
+		if tone.Tone != want {
+			t.Fatalf("%q: expected %s, got %s (scores: %v)", input, want, tone.Tone, tone.Scores)
+		}
+	}
+}
+
+func TestDetect_Negation_WholeWordsAndAdverb(t *testing.T) {
+	d := NewEmotionalToneDetector()
+	for _, input := range []string{"我没有难过", "不太着急", "没那么失望", "别着急", "我不是很难过"} {
+		tone := d.Detect(input, nil)
+		if tone.Tone != "neutral" {
+			t.Fatalf("%q: expected neutral, got %s (scores: %v)", input, tone.Tone, tone.Scores)
+		}
+	}
+}
+
 func TestDetect_Neutral_NoOutput(t *testing.T) {
 	d := NewEmotionalToneDetector()
 	tone := d.Detect("今天天气怎么样", nil)
@@ -391,6 +497,40 @@ func TestOpener_FrequencyLimit(t *testing.T) {
 	}
 }
 
+func TestOpener_CustomSituationOverridesDefault(t *testing.T) {
+	g := NewOpenerGenerator()
+	g.RegisterSituation("vip_return", func(s *ConversationState) bool {
+		return s.TotalSessions >= 10 && s.DaysSinceLast >= 3
+	}, "老朋友{days_since_last}天没来了，第{total_sessions}次见面，热情一点。")
+
+	s := g.Generate(&ConversationState{DaysSinceLast: 5, TotalSessions: 12}, 0)
+	if s.Situation != "vip_return" {
+		t.Fatalf("expected vip_return, got %s", s.Situation)
+	}
+	if s.Hint != "老朋友5天没来了，第12次见面，热情一点。" {
+		t.Fatalf("unexpected hint: %s", s.Hint)
+	}
+
+	// No custom match → built-in long_absence still applies
+	s = g.Generate(&ConversationState{DaysSinceLast: 5, TotalSessions: 2}, 0)
+	if s.Situation != "long_absence" {
+		t.Fatalf("expected default long_absence, got %s", s.Situation)
+	}
+}
+
+func TestOpener_CustomSituationFrequencyLimit(t *testing.T) {
+	g := NewOpenerGenerator(OpenerConfig{MaxMentionsPerSession: 1, LongAbsenceDays: 3})
+	g.RegisterSituation("birthday", func(*ConversationState) bool { return true }, "生日快乐")
+
+	if s := g.Generate(&ConversationState{}, 0); s.Situation != "birthday" {
+		t.Fatalf("expected birthday, got %s", s.Situation)
+	}
+	s := g.Generate(&ConversationState{}, 1)
+	if s.Situation != "normal" || s.Hint != "" {
+		t.Fatalf("expected frequency limit to suppress custom situation, got %+v", s)
+	}
+}
+
 func TestOpener_Normal(t *testing.T) {
 	g := NewOpenerGenerator()
 	state := &ConversationState{DaysSinceLast: 0, TimeOfDay: "afternoon"}
@@ -546,6 +686,76 @@ func TestCompress_CustomEstimator(t *testing.T) {
 	}
 }
 
+func TestCompress_SummarizeError_ReturnsHistoryAndError(t *testing.T) {
+	fn := func(msgs []map[string]interface{}) (string, error) {
+		return "", fmt.Errorf("llm down")
+	}
+	comp := NewContextCompressor(fn, CompressorConfig{WindowSize: 2, TokenThreshold: 1})
+
+	result, err := comp.Compress(makeHistory(10), NewWorkingMemory())
+	if err == nil || !strings.Contains(err.Error(), "llm down") {
+		t.Fatalf("expected summarize error, got %v", err)
+	}
+	if len(result) != 10 {
+		t.Fatalf("expected full history without fallback, got %d", len(result))
+	}
+}
+
+func TestCompress_SummarizeError_FallbackTruncates(t *testing.T) {
+	fn := func(msgs []map[string]interface{}) (string, error) {
+		return "", fmt.Errorf("llm down")
+	}
+	comp := NewContextCompressor(fn, CompressorConfig{
+		WindowSize:      2,
+		TokenThreshold:  1,
+		FallbackOnError: true,
+		TruncateWindow:  4,
+	})
+
+	history := makeHistory(10)
+	result, err := comp.Compress(history, NewWorkingMemory())
+	if err != nil {
+		t.Fatalf("expected fallback to swallow the error, got %v", err)
+	}
+	if len(result) != 4 || result[0]["content"] != history[6]["content"] {
+		t.Fatalf("expected last 4 messages, got %d", len(result))
+	}
+
+	// TruncateWindow 0 falls back to WindowSize.
+	comp.config.TruncateWindow = 0
+	result, _ = comp.Compress(history, NewWorkingMemory())
+	if len(result) != 2 {
+		t.Fatalf("expected WindowSize (2) messages, got %d", len(result))
+	}
+}
+
+func TestNaturalConversation_Enhance_CompressorErrorWarning(t *testing.T) {
+	cfg := DefaultNaturalConversationConfig()
+	cfg.ContextCompress = true
+	cfg.SummarizeFn = func(msgs []map[string]interface{}) (string, error) {
+		return "", fmt.Errorf("llm down")
+	}
+	cfg.CompressorConfig = CompressorConfig{WindowSize: 2, TokenThreshold: 1, FallbackOnError: true}
+	nc := NewNaturalConversation(cfg)
+
+	fragments, history := nc.Enhance(newTestSession(), "hi", makeHistory(10), time.Now())
+	if len(history) != 2 {
+		t.Fatalf("expected truncated history, got %d", len(history))
+	}
+	var sawError, sawTruncated bool
+	for _, w := range fragments.Warnings {
+		if strings.HasPrefix(w, "compressor.error:") && strings.Contains(w, "llm down") {
+			sawError = true
+		}
+		if w == "compressor.truncated" {
+			sawTruncated = true
+		}
+	}
+	if !sawError || !sawTruncated {
+		t.Fatalf("expected compressor error and truncated warnings, got %v", fragments.Warnings)
+	}
+}
+
 // ══════════════════════════════════════════════
 // NaturalConversation integration tests
 // ══════════════════════════════════════════════
@@ -569,6 +779,74 @@ func TestNaturalConversation_Enhance_DefaultConfig(t *testing.T) {
 	_ = history
 }
 
+func TestNaturalConversation_Enhance_PersistKVToWorking(t *testing.T) {
+	cfg := DefaultNaturalConversationConfig()
+	cfg.PersistKVToWorking = true
+	nc := NewNaturalConversation(cfg)
+	session := newTestSession()
+	session.Working.Set("sdk.user.is_followup", "app-owned")
+
+	now := time.Now()
+	nc.Enhance(session, "快点给我看看结果", nil, now)
+	if got := session.Working.Get("sdk.user.emotion_tone"); got != "anxious" {
+		t.Fatalf("expected emotion tone in working memory, got %v", got)
+	}
+	if got := session.Working.Get("sdk.user.is_followup"); got != "app-owned" {
+		t.Fatalf("user-set key clobbered: %v", got)
+	}
+
+	// SDK-owned keys are refreshed on later turns.
+	nc.Enhance(session, "谢谢，太好了，开心", nil, now.Add(time.Minute))
+	if got := session.Working.Get("sdk.user.emotion_tone"); got == "anxious" {
+		t.Fatalf("expected emotion tone to be refreshed, still %v", got)
+	}
+	if got := session.Working.Get("sdk.user.is_followup"); got != "app-owned" {
+		t.Fatalf("user-set key clobbered on second turn: %v", got)
+	}
+	if prompt := session.FormatForPrompt(""); strings.Contains(prompt, "sdk.") {
+		t.Fatalf("persisted KVs leaked into the memory prompt: %q", prompt)
+	}
+}
+
+func TestNaturalConversation_Enhance_PersistKVSurvivesExportImport(t *testing.T) {
+	cfg := DefaultNaturalConversationConfig()
+	cfg.PersistKVToWorking = true
+	nc := NewNaturalConversation(cfg)
+	session := newTestSession()
+	session.Working.Set("sdk.user.is_followup", "app-owned")
+
+	now := time.Now()
+	nc.Enhance(session, "快点给我看看结果", nil, now)
+	data, err := session.Export(SessionExportOptions{IncludeWorking: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ImportSession(NewInMemoryMemoryStore(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := restored.Working.Get(workingKVOwnedKey).([]interface{}); !ok {
+		t.Fatalf("expected JSON-decoded ownership marker, got %T", restored.Working.Get(workingKVOwnedKey))
+	}
+
+	nc.Enhance(restored, "谢谢，太好了，开心", nil, now.Add(time.Minute))
+	if got := restored.Working.Get("sdk.user.emotion_tone"); got == "anxious" {
+		t.Fatalf("SDK-owned key not refreshed after import, still %v", got)
+	}
+	if got := restored.Working.Get("sdk.user.is_followup"); got != "app-owned" {
+		t.Fatalf("user-set key clobbered after import: %v", got)
+	}
+}
+
+func TestNaturalConversation_Enhance_NoPersistByDefault(t *testing.T) {
+	nc := NewNaturalConversation(DefaultNaturalConversationConfig())
+	session := newTestSession()
+	nc.Enhance(session, "快点给我看看结果", nil, time.Now())
+	if got := session.Working.Get("sdk.user.emotion_tone"); got != nil {
+		t.Fatalf("expected no KV in working memory by default, got %v", got)
+	}
+}
+
 func TestNaturalConversation_PostProcess(t *testing.T) {
 	nc := NewNaturalConversation(DefaultNaturalConversationConfig())
 	output := "这是回复。希望对你有帮助？"
@@ -625,6 +903,110 @@ func TestNaturalConversation_WrapLoop_WithContext(t *testing.T) {
 	}
 }
 
+func TestNaturalConversation_Process_BundlesMetadata(t *testing.T) {
+	nc := NewNaturalConversation(DefaultNaturalConversationConfig())
+
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		return &LLMMessage{Content: "这是回复。希望对你有帮助？"}, nil
+	}
+	loop := NewAgentLoop(llm, NewToolRegistry(), "", 10, nil)
+
+	session := newTestSession()
+	res := nc.Process(context.Background(), loop, session, "快点给我看看结果", nil)
+
+	if res.Result == nil || res.Result.StoppedReason != "completed" {
+		t.Fatalf("expected completed result, got %+v", res.Result)
+	}
+	if res.FinalOutput != res.Result.FinalOutput {
+		t.Fatalf("FinalOutput mismatch: %q vs %q", res.FinalOutput, res.Result.FinalOutput)
+	}
+	if !res.PostProcessed || strings.Contains(res.FinalOutput, "希望对你有帮助") {
+		t.Fatalf("expected PostProcess to rewrite output, got %q (changed=%v)", res.FinalOutput, res.PostProcessed)
+	}
+	if res.Fragments == nil || len(res.Fragments.SystemAdditions) == 0 {
+		t.Fatal("expected fragments with system additions")
+	}
+	if res.ConversationState == nil || res.ConversationState.TurnIndex != 1 {
+		t.Fatalf("expected conversation state for turn 1, got %+v", res.ConversationState)
+	}
+	if res.EmotionTone == nil || res.EmotionTone.Tone != "anxious" {
+		t.Fatalf("expected anxious tone, got %+v", res.EmotionTone)
+	}
+	if got := res.Fragments.KV["sdk.user.emotion_tone"]; got != res.EmotionTone.Tone {
+		t.Fatalf("KV tone %v != EmotionTone %q", got, res.EmotionTone.Tone)
+	}
+}
+
+func TestNaturalConversation_Process_DisabledStagesNil(t *testing.T) {
+	cfg := DefaultNaturalConversationConfig()
+	cfg.StateTracking = false
+	cfg.EmotionDetection = false
+	cfg.StylePostProcess = false
+	nc := NewNaturalConversation(cfg)
+
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		return &LLMMessage{Content: "ok"}, nil
+	}
+	res := nc.Process(context.Background(), NewAgentLoop(llm, NewToolRegistry(), "", 10, nil), newTestSession(), "hi", nil)
+
+	if res.FinalOutput != "ok" || res.PostProcessed {
+		t.Fatalf("unexpected output %q (changed=%v)", res.FinalOutput, res.PostProcessed)
+	}
+	if res.ConversationState != nil || res.EmotionTone != nil {
+		t.Fatal("expected nil state and tone when stages are disabled")
+	}
+	if res.Fragments == nil {
+		t.Fatal("expected fragments even when stages are disabled")
+	}
+}
+
+func TestNaturalConversation_WrapLoop_ToolSeesSession(t *testing.T) {
+	nc := NewNaturalConversation(DefaultNaturalConversationConfig())
+
+	reg := NewToolRegistry()
+	reg.Register(&Tool{
+		Name:        "remember_preference",
+		Description: "Persist a user preference",
+		Parameters:  []ToolParam{{Name: "food", Type: "string", Required: true}},
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			if ctx.Session == nil {
+				return nil, fmt.Errorf("no session")
+			}
+			_, err := ctx.Session.LongTerm.Update(map[string]interface{}{
+				"preferences": map[string]interface{}{"food": args["food"]},
+			})
+			return "saved", err
+		},
+	})
+
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		callCount++
+		if callCount == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{
+				{"remember_preference", `{"food":"noodles"}`},
+			}, ""), nil
+		}
+		return makeFinalResp("记住了"), nil
+	}
+
+	loop := NewAgentLoop(llm, reg, "", 5, nil)
+	session := newTestSession()
+	result := nc.WrapLoop(loop).Run(session, "I love noodles", nil)
+
+	if rec := result.Turns[0].ToolCalls[0]; rec.Error != "" {
+		t.Fatalf("tool failed: %s", rec.Error)
+	}
+	data, _ := session.LongTerm.Get()
+	prefs, _ := data["preferences"].(map[string]interface{})
+	if prefs["food"] != "noodles" {
+		t.Fatalf("expected preference persisted via ToolContext.Session, got %v", data["preferences"])
+	}
+	if loop.Session != nil {
+		t.Fatal("wrapped loop must not be mutated")
+	}
+}
+
 func TestNaturalConversation_DefaultConfig_RecommendedOnly(t *testing.T) {
 	config := DefaultNaturalConversationConfig()
 
@@ -705,6 +1087,32 @@ func TestNaturalConversation_WithPersonaTick(t *testing.T) {
 	}
 }
 
+func TestNaturalConversation_PersonaState(t *testing.T) {
+	config := DefaultNaturalConversationConfig()
+	config.PersonaConfig = compileTestPersona()
+	config.PersonaTicker = persona.NewLocalTicker()
+	nc := NewNaturalConversation(config)
+
+	now := time.Date(2025, 6, 15, 14, 30, 0, 0, time.UTC)
+	tick := nc.PersonaState("user_1", now)
+	if tick == nil {
+		t.Fatal("expected tick when persona is configured")
+	}
+
+	state := persona.ResolveState(config.PersonaConfig, now)
+	wantMood := persona.CalculateMood(config.PersonaConfig.MoodModel.BaseMood, state.Energy).Label
+	if tick.CurrentState.Mood != wantMood {
+		t.Fatalf("expected mood %q, got %q", wantMood, tick.CurrentState.Mood)
+	}
+	if tick.CurrentState.Activity != state.Activity {
+		t.Fatalf("expected activity %q, got %q", state.Activity, tick.CurrentState.Activity)
+	}
+
+	if NewNaturalConversation(DefaultNaturalConversationConfig()).PersonaState("user_1", now) != nil {
+		t.Fatal("expected nil without persona config")
+	}
+}
+
 func TestNaturalConversation_PersonaBlockedPhrasesMerged(t *testing.T) {
 	config := DefaultNaturalConversationConfig()
 	config.PersonaConfig = compileTestPersona()
@@ -730,6 +1138,39 @@ func TestNaturalConversation_PersonaBlockedPhrasesMerged(t *testing.T) {
 	_ = violations
 }
 
+func TestNaturalConversation_EffectiveStyleConfig(t *testing.T) {
+	config := DefaultNaturalConversationConfig()
+	config.StyleConfig.ForbiddenPhrases = []string{"作为一个AI", "有什么我可以帮你的"}
+	config.PersonaConfig = compileTestPersona()
+
+	nc := NewNaturalConversation(config)
+
+	// Persona contributes "我在等你" and "你想聊什么"; the third blocked phrase is a duplicate.
+	eff := nc.EffectiveStyleConfig()
+	if len(eff.ForbiddenPhrases) != 4 {
+		t.Fatalf("expected 4 merged phrases, got %v", eff.ForbiddenPhrases)
+	}
+	for _, p := range []string{"我在等你", "你想聊什么"} {
+		found := false
+		for _, fp := range eff.ForbiddenPhrases {
+			if fp == p {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected persona phrase %q in effective config", p)
+		}
+	}
+	if len(config.StyleConfig.ForbiddenPhrases) != 2 {
+		t.Fatal("caller's config should not be mutated")
+	}
+
+	fragments, _ := nc.Enhance(newTestSession(), "你好", nil, time.Now())
+	if fragments.KV["sdk.style.forbidden_count"] != 4 {
+		t.Fatalf("expected sdk.style.forbidden_count=4, got %v", fragments.KV["sdk.style.forbidden_count"])
+	}
+}
+
 func TestNaturalConversation_PersonaSystemPromptOverride(t *testing.T) {
 	personaConfig := compileTestPersona()
 
@@ -1,6 +1,9 @@
 package agentsdk
 
 import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 )
@@ -43,6 +46,8 @@ type UserStore interface {
 	GetEnabledUsers(triggerName string) []string
 	RecordSent(userID, triggerName string, sentAt time.Time)
 	AlreadySentToday(userID, triggerName string) bool
+	RecordActivity(userID string, at time.Time)
+	LastActiveAt(userID string) time.Time
 }
 
 // ──────────────────────────────────────────────
@@ -52,16 +57,21 @@ type UserStore interface {
 // InMemoryUserStore is a thread-safe, in-memory UserStore.
 // Data is lost on restart.
 type InMemoryUserStore struct {
-	mu       sync.RWMutex
-	enabled  map[string]map[string]bool // triggerName -> userID -> true
-	sentDate map[string]string          // "userID|triggerName" -> "2006-01-02"
+	mu         sync.RWMutex
+	enabled    map[string]map[string]bool // triggerName -> userID -> true
+	sentDate   map[string]string          // "userID|triggerName" -> "2006-01-02"
+	lastActive map[string]time.Time       // userID -> last inbound activity
+
+	// Clock supplies "today" for AlreadySentToday; nil uses time.Now.
+	Clock Clock
 }
 
 // NewInMemoryUserStore creates a new in-memory user store.
 func NewInMemoryUserStore() *InMemoryUserStore {
 	return &InMemoryUserStore{
-		enabled:  make(map[string]map[string]bool),
-		sentDate: make(map[string]string),
+		enabled:    make(map[string]map[string]bool),
+		sentDate:   make(map[string]string),
+		lastActive: make(map[string]time.Time),
 	}
 }
 
@@ -117,7 +127,159 @@ func (s *InMemoryUserStore) AlreadySentToday(userID, triggerName string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	key := userID + "|" + triggerName
-	return s.sentDate[key] == time.Now().Format("2006-01-02")
+	return s.sentDate[key] == nowFrom(s.Clock).Format("2006-01-02")
+}
+
+func (s *InMemoryUserStore) RecordActivity(userID string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastActive[userID] = at
+}
+
+// LastActiveAt returns the last recorded activity, or the zero time if none.
+func (s *InMemoryUserStore) LastActiveAt(userID string) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastActive[userID]
+}
+
+// userStoreSnapshot is the JSON form used by Export/Import.
+type userStoreSnapshot struct {
+	Enabled    map[string]map[string]bool `json:"enabled"`
+	SentDate   map[string]string          `json:"sent_date"`
+	LastActive map[string]time.Time       `json:"last_active,omitempty"`
+}
+
+// Export serializes the store's state as JSON so it can be persisted across
+// restarts (otherwise a daily message may fire twice after a redeploy).
+func (s *InMemoryUserStore) Export() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return json.Marshal(userStoreSnapshot{
+		Enabled:    s.enabled,
+		SentDate:   s.sentDate,
+		LastActive: s.lastActive,
+	})
+}
+
+// Import replaces the store's state with data produced by Export.
+func (s *InMemoryUserStore) Import(data []byte) error {
+	var snap userStoreSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("proactive: import user store: %w", err)
+	}
+	if snap.Enabled == nil {
+		snap.Enabled = make(map[string]map[string]bool)
+	}
+	if snap.SentDate == nil {
+		snap.SentDate = make(map[string]string)
+	}
+	if snap.LastActive == nil {
+		snap.LastActive = make(map[string]time.Time)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = snap.Enabled
+	s.sentDate = snap.SentDate
+	s.lastActive = snap.LastActive
+	return nil
+}
+
+// ──────────────────────────────────────────────
+// MemoryStoreUserStore (persistent)
+// ──────────────────────────────────────────────
+
+// MemoryStoreUserStore is a UserStore backed by a MemoryStore, so trigger
+// state survives restarts when the store is Redis/MySQL. Layout:
+//
+//	{prefix}:enabled:{trigger}  userID -> "1"
+//	{prefix}:sent               "userID|trigger" -> "2006-01-02"
+//	{prefix}:active             userID -> RFC 3339 timestamp
+//
+// UserStore methods cannot return errors, so store failures are logged and
+// treated as "not enabled" / "not sent".
+type MemoryStoreUserStore struct {
+	store  MemoryStore
+	prefix string
+
+	// Clock supplies "today" for AlreadySentToday; nil uses time.Now.
+	Clock Clock
+}
+
+// NewMemoryStoreUserStore creates a persistent UserStore. prefix defaults to "proactive".
+func NewMemoryStoreUserStore(store MemoryStore, prefix string) *MemoryStoreUserStore {
+	if prefix == "" {
+		prefix = "proactive"
+	}
+	return &MemoryStoreUserStore{store: store, prefix: prefix}
+}
+
+func (s *MemoryStoreUserStore) enabledNS(triggerName string) string {
+	return s.prefix + ":enabled:" + triggerName
+}
+
+func (s *MemoryStoreUserStore) IsEnabled(userID, triggerName string) bool {
+	v, err := s.store.Get(s.enabledNS(triggerName), userID)
+	if err != nil {
+		logWarnf("[ProactiveScheduler] UserStore get failed: %v", err)
+	}
+	return v != ""
+}
+
+func (s *MemoryStoreUserStore) Enable(userID, triggerName string) {
+	if err := s.store.Set(s.enabledNS(triggerName), userID, "1"); err != nil {
+		logWarnf("[ProactiveScheduler] UserStore enable failed: %v", err)
+	}
+}
+
+func (s *MemoryStoreUserStore) Disable(userID, triggerName string) {
+	if err := s.store.Delete(s.enabledNS(triggerName), userID); err != nil {
+		logWarnf("[ProactiveScheduler] UserStore disable failed: %v", err)
+	}
+}
+
+func (s *MemoryStoreUserStore) GetEnabledUsers(triggerName string) []string {
+	users, err := s.store.ListKeys(s.enabledNS(triggerName))
+	if err != nil {
+		logWarnf("[ProactiveScheduler] UserStore list failed: %v", err)
+		return nil
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	return users
+}
+
+func (s *MemoryStoreUserStore) RecordSent(userID, triggerName string, sentAt time.Time) {
+	if err := s.store.Set(s.prefix+":sent", userID+"|"+triggerName, sentAt.Format("2006-01-02")); err != nil {
+		logWarnf("[ProactiveScheduler] UserStore record sent failed: %v", err)
+	}
+}
+
+func (s *MemoryStoreUserStore) AlreadySentToday(userID, triggerName string) bool {
+	v, err := s.store.Get(s.prefix+":sent", userID+"|"+triggerName)
+	if err != nil {
+		logWarnf("[ProactiveScheduler] UserStore get failed: %v", err)
+	}
+	return v == nowFrom(s.Clock).Format("2006-01-02")
+}
+
+func (s *MemoryStoreUserStore) RecordActivity(userID string, at time.Time) {
+	if err := s.store.Set(s.prefix+":active", userID, at.Format(time.RFC3339Nano)); err != nil {
+		logWarnf("[ProactiveScheduler] UserStore record activity failed: %v", err)
+	}
+}
+
+func (s *MemoryStoreUserStore) LastActiveAt(userID string) time.Time {
+	v, err := s.store.Get(s.prefix+":active", userID)
+	if err != nil || v == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
 }
 
 // ──────────────────────────────────────────────
@@ -153,6 +315,22 @@ type ProactiveScheduler struct {
 	UserStore UserStore
 	State     map[string]interface{}
 
+	// Jitter, when > 0, delays each trigger by a random duration in
+	// [0, min(Jitter, Interval)) so triggers sharing a tick don't all fire
+	// at once. Jittered triggers run concurrently, so CheckFn/MessageFn
+	// must guard any shared State they mutate.
+	Jitter time.Duration
+	// MaxConcurrentSends, when > 0, delivers messages in parallel with at
+	// most this many SendFn calls in flight. 0 keeps sends sequential.
+	// UserStore must be safe for concurrent use when this is set.
+	MaxConcurrentSends int
+	// SuppressIfActiveWithin, when > 0, skips users whose last recorded
+	// activity (see RecordActivity) is within this window, so proactive
+	// messages don't interrupt a live conversation.
+	SuppressIfActiveWithin time.Duration
+	// Clock supplies TriggerContext.Now and activity timestamps; nil = system time.
+	Clock Clock
+
 	mu       sync.RWMutex
 	triggers map[string]*Trigger
 	stopCh   chan struct{}
@@ -165,11 +343,11 @@ type ProactiveScheduler struct {
 //   - interval: polling interval (e.g. 60*time.Second)
 //   - sendFn: callback to deliver messages to users
 //   - userStore: optional UserStore for persistence (nil = in-memory)
+//
+// The default in-memory store follows the scheduler's Clock. A custom store
+// that should use the same time source needs its own Clock set.
 func NewProactiveScheduler(interval time.Duration, sendFn SendFn, userStore UserStore) *ProactiveScheduler {
-	if userStore == nil {
-		userStore = NewInMemoryUserStore()
-	}
-	return &ProactiveScheduler{
+	s := &ProactiveScheduler{
 		Interval:  interval,
 		SendFn:    sendFn,
 		UserStore: userStore,
@@ -177,6 +355,12 @@ func NewProactiveScheduler(interval time.Duration, sendFn SendFn, userStore User
 		triggers:  make(map[string]*Trigger),
 		stopCh:    make(chan struct{}),
 	}
+	if userStore == nil {
+		store := NewInMemoryUserStore()
+		store.Clock = ClockFunc(func() time.Time { return nowFrom(s.Clock) })
+		s.UserStore = store
+	}
+	return s
 }
 
 // AddTrigger registers a named trigger with check and message functions.
@@ -261,6 +445,12 @@ func (s *ProactiveScheduler) DisableUser(userID string, triggerNames ...string)
 	}
 }
 
+// RecordActivity marks the user as active now. Call it on every inbound
+// message when using SuppressIfActiveWithin.
+func (s *ProactiveScheduler) RecordActivity(userID string) {
+	s.UserStore.RecordActivity(userID, nowFrom(s.Clock))
+}
+
 // IsUserEnabled checks if the user has any (or a specific) trigger enabled.
 func (s *ProactiveScheduler) IsUserEnabled(userID string, triggerName string) bool {
 	if triggerName != "" {
@@ -293,7 +483,7 @@ func (s *ProactiveScheduler) pollLoop() {
 }
 
 func (s *ProactiveScheduler) runAllTriggers() {
-	now := time.Now()
+	now := nowFrom(s.Clock)
 	ctx := &TriggerContext{
 		Now:       now,
 		Today:     now.Format("2006-01-02"),
@@ -306,14 +496,52 @@ func (s *ProactiveScheduler) runAllTriggers() {
 	for _, t := range s.triggers {
 		triggers = append(triggers, t)
 	}
+	stopCh := s.stopCh
 	s.mu.RUnlock()
 
+	var sem chan struct{}
+	if s.MaxConcurrentSends > 0 {
+		sem = make(chan struct{}, s.MaxConcurrentSends)
+	}
+
+	if s.Jitter <= 0 {
+		for _, trigger := range triggers {
+			s.runTrigger(ctx, trigger, sem)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
 	for _, trigger := range triggers {
-		s.runTrigger(ctx, trigger)
+		wg.Add(1)
+		go func(trigger *Trigger, delay time.Duration) {
+			defer wg.Done()
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-stopCh:
+				return
+			case <-timer.C:
+			}
+			s.runTrigger(ctx, trigger, sem)
+		}(trigger, s.jitterDelay())
 	}
+	wg.Wait()
 }
 
-func (s *ProactiveScheduler) runTrigger(ctx *TriggerContext, trigger *Trigger) {
+// jitterDelay picks a random delay in [0, min(Jitter, Interval)).
+func (s *ProactiveScheduler) jitterDelay() time.Duration {
+	window := s.Jitter
+	if s.Interval > 0 && s.Interval < window {
+		window = s.Interval
+	}
+	if window <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(window)))
+}
+
+func (s *ProactiveScheduler) runTrigger(ctx *TriggerContext, trigger *Trigger, sem chan struct{}) {
 	defer func() {
 		if r := recover(); r != nil {
 			logErrorf("[ProactiveScheduler] Trigger %q panic: %v", trigger.Name, r)
@@ -330,28 +558,61 @@ func (s *ProactiveScheduler) runTrigger(ctx *TriggerContext, trigger *Trigger) {
 		return
 	}
 
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	for _, userID := range userIDs {
 		if s.UserStore.AlreadySentToday(userID, trigger.Name) {
 			continue
 		}
+		if s.recentlyActive(userID, ctx.Now) {
+			logDebugf("[ProactiveScheduler] Suppressed (recently active) | trigger=%s user=%s", trigger.Name, userID)
+			continue
+		}
 
 		text := trigger.MessageFn(ctx, userID)
 		if text == "" {
 			continue
 		}
 
-		if s.SendFn != nil {
-			if err := s.SendFn(userID, text); err != nil {
-				logWarnf("[ProactiveScheduler] Send failed | trigger=%s user=%s error=%v",
-					trigger.Name, userID, err)
-				continue
-			}
-		} else {
-			logWarnf("[ProactiveScheduler] SendFn not set, skipping send to %s", userID)
+		if sem == nil {
+			s.deliver(ctx, trigger, userID, text)
 			continue
 		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(userID, text string) {
+			defer func() {
+				if r := recover(); r != nil {
+					logErrorf("[ProactiveScheduler] Trigger %q send panic: %v", trigger.Name, r)
+				}
+				<-sem
+				wg.Done()
+			}()
+			s.deliver(ctx, trigger, userID, text)
+		}(userID, text)
+	}
+}
+
+func (s *ProactiveScheduler) recentlyActive(userID string, now time.Time) bool {
+	if s.SuppressIfActiveWithin <= 0 {
+		return false
+	}
+	last := s.UserStore.LastActiveAt(userID)
+	return !last.IsZero() && now.Sub(last) < s.SuppressIfActiveWithin
+}
 
-		s.UserStore.RecordSent(userID, trigger.Name, ctx.Now)
-		logInfof("[ProactiveScheduler] Sent | trigger=%s user=%s", trigger.Name, userID)
+func (s *ProactiveScheduler) deliver(ctx *TriggerContext, trigger *Trigger, userID, text string) {
+	if s.SendFn == nil {
+		logWarnf("[ProactiveScheduler] SendFn not set, skipping send to %s", userID)
+		return
 	}
+	if err := s.SendFn(userID, text); err != nil {
+		logWarnf("[ProactiveScheduler] Send failed | trigger=%s user=%s error=%v",
+			trigger.Name, userID, err)
+		return
+	}
+
+	s.UserStore.RecordSent(userID, trigger.Name, ctx.Now)
+	logInfof("[ProactiveScheduler] Sent | trigger=%s user=%s", trigger.Name, userID)
 }
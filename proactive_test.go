@@ -279,3 +279,279 @@ func TestProactiveScheduler_PollLoopRuns(t *testing.T) {
 		t.Fatalf("expected at least 2 poll cycles, got %d", callCount)
 	}
 }
+
+func TestProactiveScheduler_MaxConcurrentSends(t *testing.T) {
+	var mu sync.Mutex
+	active, peak, total := 0, 0, 0
+
+	sendFn := func(userID, text string) error {
+		mu.Lock()
+		active++
+		total++
+		if active > peak {
+			peak = active
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return nil
+	}
+
+	s := NewProactiveScheduler(time.Second, sendFn, nil)
+	s.MaxConcurrentSends = 3
+
+	users := make([]string, 12)
+	for i := range users {
+		users[i] = string(rune('a' + i))
+	}
+	s.AddTrigger("broadcast", func(ctx *TriggerContext) []string {
+		return users
+	}, func(ctx *TriggerContext, userID string) string {
+		return "Hi " + userID
+	})
+
+	s.runAllTriggers()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if total != len(users) {
+		t.Fatalf("expected %d sends, got %d", len(users), total)
+	}
+	if peak > 3 {
+		t.Fatalf("expected at most 3 concurrent sends, got %d", peak)
+	}
+	if !s.UserStore.AlreadySentToday("a", "broadcast") {
+		t.Fatal("parallel sends should still be recorded")
+	}
+}
+
+func TestProactiveScheduler_JitterRunsAllTriggers(t *testing.T) {
+	var mu sync.Mutex
+	sent := map[string]bool{}
+
+	sendFn := func(userID, text string) error {
+		mu.Lock()
+		sent[text] = true
+		mu.Unlock()
+		return nil
+	}
+
+	s := NewProactiveScheduler(time.Second, sendFn, nil)
+	s.Jitter = 30 * time.Millisecond
+	for _, name := range []string{"t1", "t2", "t3"} {
+		name := name
+		s.AddTrigger(name, func(ctx *TriggerContext) []string {
+			return []string{"u1"}
+		}, func(ctx *TriggerContext, userID string) string {
+			return name
+		})
+	}
+
+	s.runAllTriggers()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 3 {
+		t.Fatalf("expected all 3 jittered triggers to send before returning, got %v", sent)
+	}
+}
+
+func TestProactiveScheduler_JitterDelayBoundedByInterval(t *testing.T) {
+	s := NewProactiveScheduler(20*time.Millisecond, nil, nil)
+	s.Jitter = time.Hour
+	for i := 0; i < 50; i++ {
+		if d := s.jitterDelay(); d < 0 || d >= 20*time.Millisecond {
+			t.Fatalf("jitter delay %s outside interval", d)
+		}
+	}
+}
+
+func TestInMemoryUserStore_LastActiveAt(t *testing.T) {
+	store := NewInMemoryUserStore()
+	if !store.LastActiveAt("u1").IsZero() {
+		t.Fatal("last activity should default to zero")
+	}
+	at := time.Now().Add(-time.Minute)
+	store.RecordActivity("u1", at)
+	if !store.LastActiveAt("u1").Equal(at) {
+		t.Fatalf("expected %v, got %v", at, store.LastActiveAt("u1"))
+	}
+}
+
+func TestProactiveScheduler_SuppressIfActiveWithin(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	sendFn := func(userID, text string) error {
+		mu.Lock()
+		sent = append(sent, userID)
+		mu.Unlock()
+		return nil
+	}
+
+	s := NewProactiveScheduler(time.Second, sendFn, nil)
+	s.SuppressIfActiveWithin = 10 * time.Minute
+	s.AddTrigger("daily", func(ctx *TriggerContext) []string {
+		return []string{"active", "idle", "never"}
+	}, func(ctx *TriggerContext, userID string) string {
+		return "Good morning"
+	})
+
+	s.RecordActivity("active")
+	s.UserStore.RecordActivity("idle", time.Now().Add(-time.Hour))
+
+	s.runAllTriggers()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 sends, got %v", sent)
+	}
+	for _, uid := range sent {
+		if uid == "active" {
+			t.Fatal("recently active user should be skipped")
+		}
+	}
+	if s.UserStore.AlreadySentToday("active", "daily") {
+		t.Fatal("suppressed user should not be marked as sent")
+	}
+}
+
+func TestInMemoryUserStore_ExportImport(t *testing.T) {
+	store := NewInMemoryUserStore()
+	store.Enable("u1", "daily")
+	store.RecordSent("u1", "daily", time.Now())
+	active := time.Now().Add(-5 * time.Minute).Round(0)
+	store.RecordActivity("u1", active)
+
+	data, err := store.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := NewInMemoryUserStore()
+	if err := reloaded.Import(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.IsEnabled("u1", "daily") {
+		t.Fatal("enabled state should survive reload")
+	}
+	if !reloaded.AlreadySentToday("u1", "daily") {
+		t.Fatal("sent state should survive reload")
+	}
+	if !reloaded.LastActiveAt("u1").Equal(active) {
+		t.Fatalf("last activity should survive reload, got %v", reloaded.LastActiveAt("u1"))
+	}
+
+	count := 0
+	s := NewProactiveScheduler(time.Second, func(userID, text string) error {
+		count++
+		return nil
+	}, reloaded)
+	s.AddTrigger("daily", func(ctx *TriggerContext) []string {
+		return ctx.Scheduler.UserStore.GetEnabledUsers("daily")
+	}, func(ctx *TriggerContext, userID string) string {
+		return "Hi"
+	})
+	s.runAllTriggers()
+	if count != 0 {
+		t.Fatalf("reloaded store should suppress a second daily send, got %d", count)
+	}
+}
+
+func TestInMemoryUserStore_ImportInvalid(t *testing.T) {
+	store := NewInMemoryUserStore()
+	store.Enable("u1", "daily")
+	if err := store.Import([]byte("not json")); err == nil {
+		t.Fatal("expected error for invalid data")
+	}
+	if !store.IsEnabled("u1", "daily") {
+		t.Fatal("failed import should keep existing state")
+	}
+}
+
+func TestMemoryStoreUserStore_Persistence(t *testing.T) {
+	backing := NewInMemoryMemoryStore()
+
+	first := NewMemoryStoreUserStore(backing, "")
+	first.Enable("u1", "daily")
+	first.Enable("u2", "daily")
+	first.Disable("u2", "daily")
+	first.RecordSent("u1", "daily", time.Now())
+	first.RecordActivity("u1", time.Now())
+
+	// A fresh instance over the same backing store sees the same state.
+	second := NewMemoryStoreUserStore(backing, "")
+	if !second.IsEnabled("u1", "daily") || second.IsEnabled("u2", "daily") {
+		t.Fatal("enabled state should be read from the backing store")
+	}
+	if users := second.GetEnabledUsers("daily"); len(users) != 1 || users[0] != "u1" {
+		t.Fatalf("expected [u1], got %v", users)
+	}
+	if !second.AlreadySentToday("u1", "daily") {
+		t.Fatal("sent state should persist")
+	}
+	if second.AlreadySentToday("u1", "weekly") {
+		t.Fatal("sent state is per trigger")
+	}
+	if second.LastActiveAt("u1").IsZero() || !second.LastActiveAt("u2").IsZero() {
+		t.Fatal("last activity should persist per user")
+	}
+	if second.GetEnabledUsers("missing") != nil {
+		t.Fatal("unknown trigger should return nil")
+	}
+}
+
+func TestProactiveScheduler_InjectedClock(t *testing.T) {
+	fixed := time.Date(2024, 12, 24, 8, 0, 0, 0, time.UTC)
+	s := NewProactiveScheduler(time.Second, func(userID, text string) error { return nil }, nil)
+	s.Clock = ClockFunc(func() time.Time { return fixed })
+
+	var gotNow time.Time
+	var gotToday string
+	s.AddTrigger("xmas", func(ctx *TriggerContext) []string {
+		gotNow, gotToday = ctx.Now, ctx.Today
+		return nil
+	}, func(ctx *TriggerContext, userID string) string { return "" })
+	s.runAllTriggers()
+
+	if !gotNow.Equal(fixed) || gotToday != "2024-12-24" {
+		t.Fatalf("expected injected time, got now=%v today=%q", gotNow, gotToday)
+	}
+
+	s.RecordActivity("u1")
+	if at := s.UserStore.LastActiveAt("u1"); !at.Equal(fixed) {
+		t.Fatalf("expected activity at injected time, got %v", at)
+	}
+}
+
+func TestProactiveScheduler_DailyDedupUsesClock(t *testing.T) {
+	now := time.Date(2024, 12, 24, 8, 0, 0, 0, time.UTC)
+	sends := 0
+	s := NewProactiveScheduler(time.Second, func(userID, text string) error {
+		sends++
+		return nil
+	}, nil)
+	s.Clock = ClockFunc(func() time.Time { return now })
+	s.AddTrigger("daily", func(ctx *TriggerContext) []string {
+		return []string{"u1"}
+	}, func(ctx *TriggerContext, userID string) string { return "Hi" })
+
+	s.runAllTriggers()
+	s.runAllTriggers()
+	if sends != 1 {
+		t.Fatalf("expected one send on the injected day, got %d", sends)
+	}
+	if !s.UserStore.AlreadySentToday("u1", "daily") {
+		t.Fatal("expected send recorded on the injected day")
+	}
+
+	now = now.Add(24 * time.Hour)
+	s.runAllTriggers()
+	if sends != 2 {
+		t.Fatalf("expected a new send on the next injected day, got %d", sends)
+	}
+}
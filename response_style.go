@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -26,6 +28,18 @@ type StyleConfig struct {
 	ForbiddenPhrasesFile string
 	EndStyle             string // "no_question" = convert trailing ? to .
 	EnableRetry          bool   // advanced: retry via LLM if violations found (default false)
+	// By default fenced code blocks and inline code are kept intact when
+	// truncating: the cut moves before the block, or past it if that would
+	// drop below MinPreserve. DisableCodeBlockPreservation cuts through them.
+	DisableCodeBlockPreservation bool
+	// FuzzyForbidden matches forbidden phrases ignoring whitespace/punctuation
+	// between characters ("作为 一个 AI") and with word boundaries around
+	// Latin text, instead of plain substring matching.
+	FuzzyForbidden bool
+	// NaturalEndings replaces the built-in sentences appended after a
+	// truncation. Endings in the same script as the truncated text are
+	// preferred; punctuation-only endings such as "." match any text.
+	NaturalEndings []string
 }
 
 // DefaultStyleConfig returns production-ready defaults.
@@ -59,6 +73,13 @@ var naturalEndings = []string{
 	"回头再细说。",
 }
 
+// English counterparts of naturalEndings, used for Latin-script text.
+var naturalEndingsEN = []string{
+	"That's the gist for now.",
+	"More on that later.",
+	"Let's leave it there for now.",
+}
+
 var (
 	styleRngOnce sync.Once
 	styleRng     *rand.Rand
@@ -73,7 +94,8 @@ func getStyleRng() *rand.Rand {
 
 // ResponseStyleController enforces response style rules via local post-processing.
 type ResponseStyleController struct {
-	config StyleConfig
+	config    StyleConfig
+	forbidden []*regexp.Regexp // compiled when FuzzyForbidden, parallel to config.ForbiddenPhrases
 }
 
 // NewResponseStyleController creates a style controller.
@@ -91,7 +113,14 @@ func NewResponseStyleController(config ...StyleConfig) *ResponseStyleController
 		}
 	}
 	cfg.ForbiddenPhrases = normalizeForbiddenPhrases(cfg.ForbiddenPhrases)
-	return &ResponseStyleController{config: cfg}
+	c := &ResponseStyleController{config: cfg}
+	if cfg.FuzzyForbidden {
+		c.forbidden = make([]*regexp.Regexp, len(cfg.ForbiddenPhrases))
+		for i, phrase := range cfg.ForbiddenPhrases {
+			c.forbidden[i] = fuzzyPhraseRegexp(phrase)
+		}
+	}
+	return c
 }
 
 // LoadForbiddenPhrasesFile loads forbidden phrases from a text file.
@@ -140,7 +169,15 @@ func (c *ResponseStyleController) PostProcess(output string) (string, bool, []st
 	var violations []string
 
 	// 1. Remove forbidden phrases
-	for _, phrase := range c.config.ForbiddenPhrases {
+	for i, phrase := range c.config.ForbiddenPhrases {
+		if c.forbidden != nil {
+			if re := c.forbidden[i]; re != nil && re.MatchString(result) {
+				result = re.ReplaceAllString(result, "")
+				violations = append(violations, fmt.Sprintf("style.forbidden_removed:%s", phrase))
+				changed = true
+			}
+			continue
+		}
 		if strings.Contains(result, phrase) {
 			result = strings.ReplaceAll(result, phrase, "")
 			violations = append(violations, fmt.Sprintf("style.forbidden_removed:%s", phrase))
@@ -156,7 +193,11 @@ func (c *ResponseStyleController) PostProcess(output string) (string, bool, []st
 	// 2. Truncate if too long (with MinPreserve protection)
 	runeCount := utf8.RuneCountInString(result)
 	if c.config.MaxLength > 0 && runeCount > c.config.MaxLength && runeCount > c.config.MinPreserve {
-		truncated := truncateNatural(result, c.config.MaxLength)
+		var spans [][2]int
+		if !c.config.DisableCodeBlockPreservation {
+			spans = findCodeSpans([]rune(result))
+		}
+		truncated := truncateNatural(result, c.config.MaxLength, c.config.MinPreserve, spans, c.config.NaturalEndings)
 		if truncated != result {
 			result = truncated
 			violations = append(violations, fmt.Sprintf("style.truncated:exceeded_%d", c.config.MaxLength))
@@ -203,8 +244,12 @@ func (c *ResponseStyleController) BuildRetryPrompt(output string, violations []s
 }
 
 // truncateNatural truncates text to maxRunes at the nearest sentence boundary,
-// then appends a random natural ending sentence.
-func truncateNatural(text string, maxRunes int) string {
+// then appends a random natural ending sentence (from endings if non-empty,
+// else the built-in set for the text's script).
+// Boundaries inside protected spans (code blocks) are skipped, and a cut that
+// would land inside one is moved before it, or past it if that would keep
+// fewer than minPreserve runes.
+func truncateNatural(text string, maxRunes, minPreserve int, spans [][2]int, endings []string) string {
 	runes := []rune(text)
 	if len(runes) <= maxRunes {
 		return text
@@ -214,6 +259,9 @@ func truncateNatural(text string, maxRunes int) string {
 	sentenceEnds := []rune{'。', '！', '？', '.', '!', '?', '\n'}
 	bestCut := maxRunes
 	for i := maxRunes - 1; i >= maxRunes/2; i-- {
+		if spanAt(spans, i) >= 0 {
+			continue
+		}
 		for _, sep := range sentenceEnds {
 			if runes[i] == sep {
 				bestCut = i + 1
@@ -222,14 +270,174 @@ func truncateNatural(text string, maxRunes int) string {
 		}
 	}
 found:
+	if idx := spanAt(spans, bestCut-1); idx >= 0 && bestCut < spans[idx][1] {
+		start, end := spans[idx][0], spans[idx][1]
+		if start > 0 && start >= minPreserve {
+			bestCut = start
+		} else {
+			bestCut = end
+		}
+	}
 	truncated := strings.TrimSpace(string(runes[:bestCut]))
+	if strings.HasSuffix(truncated, "```") {
+		truncated += "\n"
+	}
 
 	// Append random natural ending
-	rng := getStyleRng()
-	ending := naturalEndings[rng.Intn(len(naturalEndings))]
+	ending := pickNaturalEnding(truncated, endings)
+	if ending == "" {
+		return truncated
+	}
+	if textScript(ending) == "latin" && truncated != "" && !strings.HasSuffix(truncated, "\n") {
+		truncated += " "
+	}
 	return truncated + ending
 }
 
+// pickNaturalEnding chooses a random ending whose script matches text.
+// With no custom endings it uses naturalEndingsEN for Latin text and
+// naturalEndings otherwise; if no custom ending matches, any is used.
+func pickNaturalEnding(text string, endings []string) string {
+	script := textScript(text)
+	if len(endings) == 0 {
+		endings = naturalEndings
+		if script == "latin" {
+			endings = naturalEndingsEN
+		}
+	}
+	var matching []string
+	for _, e := range endings {
+		if es := textScript(e); es == "" || es == script {
+			matching = append(matching, e)
+		}
+	}
+	if len(matching) == 0 {
+		matching = endings
+	}
+	if len(matching) == 0 {
+		return ""
+	}
+	return matching[getStyleRng().Intn(len(matching))]
+}
+
+// textScript classifies text as "cjk" or "latin" by whichever letters
+// dominate, or "" when it has neither (e.g. punctuation only).
+func textScript(text string) string {
+	var cjk, latin int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjk++
+		case r < utf8.RuneSelf && unicode.IsLetter(r):
+			latin++
+		}
+	}
+	switch {
+	case cjk == 0 && latin == 0:
+		return ""
+	case latin > cjk:
+		return "latin"
+	default:
+		return "cjk"
+	}
+}
+
+// findCodeSpans returns rune ranges [start, end) of fenced code blocks and
+// inline code. An unterminated fence extends to the end of the text.
+func findCodeSpans(runes []rune) [][2]int {
+	var spans [][2]int
+	n := len(runes)
+	isFence := func(i int) bool {
+		return i+2 < n && runes[i] == '`' && runes[i+1] == '`' && runes[i+2] == '`'
+	}
+	for i := 0; i < n; i++ {
+		if runes[i] != '`' {
+			continue
+		}
+		if isFence(i) {
+			end := n
+			for j := i + 3; j < n; j++ {
+				if isFence(j) {
+					end = j + 3
+					break
+				}
+			}
+			spans = append(spans, [2]int{i, end})
+			i = end - 1
+			continue
+		}
+		for j := i + 1; j < n && runes[j] != '\n'; j++ {
+			if runes[j] == '`' {
+				spans = append(spans, [2]int{i, j + 1})
+				i = j
+				break
+			}
+		}
+	}
+	return spans
+}
+
+// spanAt returns the index of the span containing rune position pos, or -1.
+func spanAt(spans [][2]int, pos int) int {
+	for i, sp := range spans {
+		if pos >= sp[0] && pos < sp[1] {
+			return i
+		}
+	}
+	return -1
+}
+
+// fuzzyPhraseRegexp builds a case-insensitive pattern for phrase that tolerates
+// whitespace/punctuation between characters, keeps Latin words intact, and
+// requires word boundaries where the phrase starts or ends with Latin text.
+// Returns nil if the phrase has no matchable characters.
+func fuzzyPhraseRegexp(phrase string) *regexp.Regexp {
+	type elem struct {
+		r     rune
+		latin bool
+		gap   bool // separated from the previous element in the phrase
+	}
+	var elems []elem
+	gap := false
+	for _, r := range phrase {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) {
+			gap = true
+			continue
+		}
+		latin := r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+		elems = append(elems, elem{r: r, latin: latin, gap: gap})
+		gap = false
+	}
+	if len(elems) == 0 {
+		return nil
+	}
+
+	const sep = `[\s\p{P}]*`
+	var b strings.Builder
+	b.WriteString("(?i)")
+	if elems[0].latin {
+		b.WriteString(`\b`)
+	}
+	for i, e := range elems {
+		if i > 0 {
+			prev := elems[i-1]
+			switch {
+			case prev.latin && e.latin && !e.gap:
+				// same Latin word: no separator allowed
+			case prev.latin && e.latin:
+				b.WriteString(`[\s\p{P}]+`)
+			default:
+				b.WriteString(sep)
+			}
+		}
+		b.WriteString(regexp.QuoteMeta(string(e.r)))
+	}
+	if elems[len(elems)-1].latin {
+		b.WriteString(`\b`)
+	}
+	return regexp.MustCompile(b.String())
+}
+
 func cleanupWhitespace(s string) string {
 	// Collapse multiple newlines into two
 	for strings.Contains(s, "\n\n\n") {
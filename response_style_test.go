@@ -43,3 +43,143 @@ func TestNewResponseStyleController_ForbiddenFileOverride(t *testing.T) {
 		t.Fatalf("forbidden phrase should be removed, got: %s", out)
 	}
 }
+
+func TestPostProcess_PreserveCodeBlocks_CutsBeforeBlock(t *testing.T) {
+	ctrl := NewResponseStyleController(StyleConfig{MaxLength: 60, MinPreserve: 10})
+	intro := "Here is how you print a greeting in Go"
+	code := "```go\nfmt.Println(\"hello, world\")\nfmt.Println(\"bye\")\n```"
+	out, changed, _ := ctrl.PostProcess(intro + "\n" + code + "\nDone.")
+	if !changed {
+		t.Fatal("expected truncation")
+	}
+	if strings.Contains(out, "```") {
+		t.Fatalf("expected cut before the code block, got: %q", out)
+	}
+	if !strings.HasPrefix(out, intro) {
+		t.Fatalf("expected intro preserved, got: %q", out)
+	}
+}
+
+func TestPostProcess_PreserveCodeBlocks_ExtendsPastBlock(t *testing.T) {
+	ctrl := NewResponseStyleController(StyleConfig{MaxLength: 30, MinPreserve: 10})
+	code := "```\nline one of the code\nline two of the code\n```"
+	out, changed, _ := ctrl.PostProcess("Code:\n" + code + "\nAnd some trailing explanation here.")
+	if !changed {
+		t.Fatal("expected truncation")
+	}
+	if !strings.Contains(out, code) {
+		t.Fatalf("expected the whole code block kept, got: %q", out)
+	}
+	if strings.Contains(out, "trailing explanation") {
+		t.Fatalf("expected text after the block dropped, got: %q", out)
+	}
+}
+
+func TestPostProcess_PreserveCodeBlocksDisabled(t *testing.T) {
+	ctrl := NewResponseStyleController(StyleConfig{
+		MaxLength:                    30,
+		MinPreserve:                  10,
+		DisableCodeBlockPreservation: true,
+	})
+	code := "```\nline one of the code\nline two of the code\n```"
+	out, _, _ := ctrl.PostProcess("Code:\n" + code + "\nAnd some trailing explanation here.")
+	if strings.Count(out, "```") != 1 {
+		t.Fatalf("expected block cut through without preservation, got: %q", out)
+	}
+}
+
+func TestFindCodeSpans(t *testing.T) {
+	spans := findCodeSpans([]rune("use `x` then ```\ny\n``` and ```z"))
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %v", spans)
+	}
+	if spans[0] != [2]int{4, 7} {
+		t.Fatalf("unexpected inline span: %v", spans[0])
+	}
+	if spans[2][1] != len([]rune("use `x` then ```\ny\n``` and ```z")) {
+		t.Fatalf("unterminated fence should extend to end: %v", spans[2])
+	}
+}
+
+func TestPostProcess_FuzzyForbidden_SpacedVariant(t *testing.T) {
+	ctrl := NewResponseStyleController(StyleConfig{
+		ForbiddenPhrases: []string{"作为一个AI", "as an AI"},
+		FuzzyForbidden:   true,
+	})
+	out, changed, violations := ctrl.PostProcess("作为 一个 AI，我觉得这样挺好。")
+	if !changed || strings.Contains(out, "AI") {
+		t.Fatalf("expected spaced variant removed, got: %q", out)
+	}
+	if len(violations) != 1 || violations[0] != "style.forbidden_removed:作为一个AI" {
+		t.Fatalf("unexpected violations: %v", violations)
+	}
+
+	out, _, _ = ctrl.PostProcess("Well, As an  AI, I cannot say.")
+	if strings.Contains(out, "AI") {
+		t.Fatalf("expected case/spacing variant removed, got: %q", out)
+	}
+}
+
+func TestPostProcess_FuzzyForbidden_WordBoundary(t *testing.T) {
+	ctrl := NewResponseStyleController(StyleConfig{
+		ForbiddenPhrases: []string{"AI"},
+		FuzzyForbidden:   true,
+	})
+	out, changed, _ := ctrl.PostProcess("Check your MAIL for the AIRLINE ticket.")
+	if changed || out != "Check your MAIL for the AIRLINE ticket." {
+		t.Fatalf("expected no removal inside words, got: %q", out)
+	}
+
+	substr := NewResponseStyleController(StyleConfig{ForbiddenPhrases: []string{"AI"}})
+	if out, _, _ := substr.PostProcess("Check your MAIL."); out == "Check your MAIL." {
+		t.Fatal("substring mode is expected to over-match")
+	}
+}
+
+func TestPostProcess_CustomNaturalEndings(t *testing.T) {
+	ctrl := NewResponseStyleController(StyleConfig{
+		MaxLength:      30,
+		MinPreserve:    10,
+		NaturalEndings: []string{"……就这样吧。", "That's all."},
+	})
+	long := "第一句话到这里结束。第二句话继续说下去。第三句话还在延伸。第四句话也很长呢。"
+	for i := 0; i < 10; i++ {
+		out, changed, _ := ctrl.PostProcess(long)
+		if !changed {
+			t.Fatal("expected truncation")
+		}
+		if !strings.HasSuffix(out, "……就这样吧。") {
+			t.Fatalf("expected the Chinese custom ending, got: %q", out)
+		}
+	}
+}
+
+func TestPostProcess_EnglishTruncationEnding(t *testing.T) {
+	ctrl := NewResponseStyleController(StyleConfig{MaxLength: 40, MinPreserve: 10})
+	long := "The first sentence ends here. The second one keeps going for a while."
+	out, changed, _ := ctrl.PostProcess(long)
+	if !changed {
+		t.Fatal("expected truncation")
+	}
+	if !strings.HasPrefix(out, "The first sentence ends here. ") {
+		t.Fatalf("expected cut at the first sentence, got: %q", out)
+	}
+	if strings.ContainsAny(out, "。，") {
+		t.Fatalf("English text should not get a Chinese ending: %q", out)
+	}
+	found := false
+	for _, e := range naturalEndingsEN {
+		if strings.HasSuffix(out, e) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a built-in English ending, got: %q", out)
+	}
+
+	ctrl = NewResponseStyleController(StyleConfig{MaxLength: 40, MinPreserve: 10, NaturalEndings: []string{"..."}})
+	out, _, _ = ctrl.PostProcess(long)
+	if out != "The first sentence ends here...." {
+		t.Fatalf("expected punctuation-only ending appended directly, got: %q", out)
+	}
+}
@@ -4,4 +4,11 @@ go 1.22.5
 
 require github.com/cyberFlowTech/zapry-agents-sdk-go v0.0.0
 
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/redis/go-redis/v9 v9.18.0 // indirect
+	go.uber.org/atomic v1.11.0 // indirect
+)
+
 replace github.com/cyberFlowTech/zapry-agents-sdk-go => ../
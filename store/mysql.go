@@ -3,7 +3,10 @@ package store
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	agentsdk "github.com/cyberFlowTech/zapry-agents-sdk-go"
 )
@@ -11,11 +14,16 @@ import (
 // MySQLMemoryStore implements agentsdk.MemoryStore using MySQL.
 //
 // It uses two tables (auto-created if AutoMigrate is true):
-//   - {prefix}_kv:   (namespace, key, value) for KV operations
+//   - {prefix}_kv:   (namespace, key, value, expires_at) for KV operations
 //   - {prefix}_list: (namespace, key, idx, value) for ordered lists
+//
+// The expires_at column backs SetWithTTL and TTL. KV tables created before
+// it existed keep working without it; TTL support is only enabled once the
+// column is present.
 type MySQLMemoryStore struct {
 	db     *sql.DB
 	prefix string
+	hasTTL bool // KV table has the expires_at column
 }
 
 // MySQLStoreConfig configures the MySQL store.
@@ -24,6 +32,11 @@ type MySQLStoreConfig struct {
 	AutoMigrate bool   // create tables if not exist, default true
 }
 
+// With AutoMigrate disabled, an existing KV table without expires_at is used
+// as-is and SetWithTTL returns an error. To enable TTLs, add the column:
+//
+//	ALTER TABLE {prefix}_kv ADD COLUMN expires_at BIGINT NULL
+
 // NewMySQLMemoryStore creates a MemoryStore backed by MySQL.
 // The sql.DB must be already opened with a MySQL driver.
 func NewMySQLMemoryStore(db *sql.DB, config ...MySQLStoreConfig) (*MySQLMemoryStore, error) {
@@ -41,6 +54,11 @@ func NewMySQLMemoryStore(db *sql.DB, config ...MySQLStoreConfig) (*MySQLMemorySt
 			return nil, fmt.Errorf("auto-migrate failed: %w", err)
 		}
 	}
+	hasTTL, err := s.hasExpiresAt()
+	if err != nil {
+		return nil, fmt.Errorf("inspect %s: %w", s.kvTable(), err)
+	}
+	s.hasTTL = hasTTL
 	return s, nil
 }
 
@@ -49,9 +67,10 @@ func (s *MySQLMemoryStore) listTable() string { return s.prefix + "_list" }
 
 func (s *MySQLMemoryStore) migrate() error {
 	kvDDL := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
-		namespace VARCHAR(255) NOT NULL,
-		k         VARCHAR(255) NOT NULL,
-		v         LONGTEXT     NOT NULL,
+		namespace  VARCHAR(255) NOT NULL,
+		k          VARCHAR(255) NOT NULL,
+		v          LONGTEXT     NOT NULL,
+		expires_at BIGINT       NULL,
 		PRIMARY KEY (namespace, k)
 	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`, s.kvTable())
 
@@ -67,15 +86,64 @@ func (s *MySQLMemoryStore) migrate() error {
 	if _, err := s.db.Exec(kvDDL); err != nil {
 		return err
 	}
+	if err := s.migrateExpiresAt(); err != nil {
+		return err
+	}
 	_, err := s.db.Exec(listDDL)
 	return err
 }
 
+// migrateExpiresAt adds the expires_at column to KV tables created before
+// SetWithTTL existed.
+func (s *MySQLMemoryStore) migrateExpiresAt() error {
+	ok, err := s.hasExpiresAt()
+	if err != nil || ok {
+		return err
+	}
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN expires_at BIGINT NULL", s.kvTable()))
+	return err
+}
+
+func (s *MySQLMemoryStore) hasExpiresAt() (bool, error) {
+	var n int
+	err := s.db.QueryRow(
+		`SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=? AND COLUMN_NAME='expires_at'`,
+		s.kvTable(),
+	).Scan(&n)
+	return n > 0, err
+}
+
+// errNoTTLColumn is returned by SetWithTTL when the KV table predates the
+// expires_at column and AutoMigrate is disabled.
+var errNoTTLColumn = errors.New("mysql store: expires_at column missing; run ALTER TABLE <prefix>_kv ADD COLUMN expires_at BIGINT NULL")
+
+func nowMillis() int64 { return time.Now().UnixMilli() }
+
+// liveKV returns the WHERE fragment (with leading AND) that hides expired KV
+// rows, plus its argument. Both are empty when the table has no expires_at.
+func (s *MySQLMemoryStore) liveKV() (string, []interface{}) {
+	if !s.hasTTL {
+		return "", nil
+	}
+	return " AND (expires_at IS NULL OR expires_at > ?)", []interface{}{nowMillis()}
+}
+
+// upsertKV is the plain KV upsert; it clears any TTL left by SetWithTTL.
+func (s *MySQLMemoryStore) upsertKV() string {
+	q := fmt.Sprintf("INSERT INTO %s (namespace, k, v) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE v=VALUES(v)", s.kvTable())
+	if s.hasTTL {
+		q += ", expires_at=NULL"
+	}
+	return q
+}
+
 func (s *MySQLMemoryStore) Get(namespace, key string) (string, error) {
+	live, liveArgs := s.liveKV()
 	var val string
 	err := s.db.QueryRow(
-		fmt.Sprintf("SELECT v FROM %s WHERE namespace=? AND k=?", s.kvTable()),
-		namespace, key,
+		fmt.Sprintf("SELECT v FROM %s WHERE namespace=? AND k=?%s", s.kvTable(), live),
+		append([]interface{}{namespace, key}, liveArgs...)...,
 	).Scan(&val)
 	if err == sql.ErrNoRows {
 		return "", nil
@@ -84,14 +152,95 @@ func (s *MySQLMemoryStore) Get(namespace, key string) (string, error) {
 }
 
 func (s *MySQLMemoryStore) Set(namespace, key, value string) error {
+	_, err := s.db.Exec(s.upsertKV(), namespace, key, value)
+	return err
+}
+
+func (s *MySQLMemoryStore) SetWithTTL(namespace, key, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return s.Set(namespace, key, value)
+	}
+	if !s.hasTTL {
+		return errNoTTLColumn
+	}
 	q := fmt.Sprintf(
-		"INSERT INTO %s (namespace, k, v) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE v=VALUES(v)",
+		"INSERT INTO %s (namespace, k, v, expires_at) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE v=VALUES(v), expires_at=VALUES(expires_at)",
 		s.kvTable(),
 	)
-	_, err := s.db.Exec(q, namespace, key, value)
+	_, err := s.db.Exec(q, namespace, key, value, time.Now().Add(ttl).UnixMilli())
 	return err
 }
 
+func (s *MySQLMemoryStore) TTL(namespace, key string) (time.Duration, error) {
+	if !s.hasTTL {
+		return 0, nil
+	}
+	var expiresAt sql.NullInt64
+	err := s.db.QueryRow(
+		fmt.Sprintf("SELECT expires_at FROM %s WHERE namespace=? AND k=?", s.kvTable()),
+		namespace, key,
+	).Scan(&expiresAt)
+	if err == sql.ErrNoRows || (err == nil && !expiresAt.Valid) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if d := time.Duration(expiresAt.Int64-nowMillis()) * time.Millisecond; d > 0 {
+		return d, nil
+	}
+	return 0, nil
+}
+
+func (s *MySQLMemoryStore) MGet(namespace string, keys []string) (map[string]string, error) {
+	result := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	args := make([]interface{}, 0, len(keys)+2)
+	args = append(args, namespace)
+	for _, k := range keys {
+		args = append(args, k)
+	}
+	live, liveArgs := s.liveKV()
+	args = append(args, liveArgs...)
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(keys)), ",")
+	rows, err := s.db.Query(
+		fmt.Sprintf("SELECT k, v FROM %s WHERE namespace=? AND k IN (%s)%s", s.kvTable(), placeholders, live),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		result[k] = v
+	}
+	return result, rows.Err()
+}
+
+func (s *MySQLMemoryStore) MSet(namespace string, kv map[string]string) error {
+	if len(kv) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	q := s.upsertKV()
+	for k, v := range kv {
+		if _, err := tx.Exec(q, namespace, k, v); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 func (s *MySQLMemoryStore) Delete(namespace, key string) error {
 	_, err := s.db.Exec(
 		fmt.Sprintf("DELETE FROM %s WHERE namespace=? AND k=?", s.kvTable()),
@@ -101,10 +250,12 @@ func (s *MySQLMemoryStore) Delete(namespace, key string) error {
 }
 
 func (s *MySQLMemoryStore) ListKeys(namespace string) ([]string, error) {
+	live, liveArgs := s.liveKV()
+	args := append([]interface{}{namespace}, liveArgs...)
 	rows, err := s.db.Query(
-		fmt.Sprintf("SELECT DISTINCT k FROM %s WHERE namespace=? UNION SELECT DISTINCT k FROM %s WHERE namespace=?",
-			s.kvTable(), s.listTable()),
-		namespace, namespace,
+		fmt.Sprintf("SELECT DISTINCT k FROM %s WHERE namespace=?%s UNION SELECT DISTINCT k FROM %s WHERE namespace=?",
+			s.kvTable(), live, s.listTable()),
+		append(args, namespace)...,
 	)
 	if err != nil {
 		return nil, err
@@ -209,9 +360,7 @@ func (s *MySQLMemoryStore) BatchSet(namespace string, kvs map[string]string) err
 	if err != nil {
 		return err
 	}
-	stmt, err := tx.Prepare(
-		fmt.Sprintf("INSERT INTO %s (namespace, k, v) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE v=VALUES(v)", s.kvTable()),
-	)
+	stmt, err := tx.Prepare(s.upsertKV())
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -243,8 +392,10 @@ func (s *MySQLMemoryStore) DeleteNamespace(namespace string) error {
 
 // Export returns all KV data for a namespace as a JSON-serializable map.
 func (s *MySQLMemoryStore) Export(namespace string) (map[string]string, error) {
+	live, liveArgs := s.liveKV()
 	rows, err := s.db.Query(
-		fmt.Sprintf("SELECT k, v FROM %s WHERE namespace=?", s.kvTable()), namespace,
+		fmt.Sprintf("SELECT k, v FROM %s WHERE namespace=?%s", s.kvTable(), live),
+		append([]interface{}{namespace}, liveArgs...)...,
 	)
 	if err != nil {
 		return nil, err
@@ -22,6 +22,21 @@ type RedisClient interface {
 	Close() error
 }
 
+// RedisTTLClient is implemented by clients that expose PTTL. It is optional:
+// RedisMemoryStore.TTL type-asserts for it so existing RedisClient adapters
+// keep compiling.
+type RedisTTLClient interface {
+	PTTL(ctx context.Context, key string) DurationCmd
+}
+
+// RedisBatchClient is implemented by clients that expose MGET and MSET.
+// Like RedisTTLClient it is optional; without it MGet and MSet issue one
+// command per key.
+type RedisBatchClient interface {
+	MGet(ctx context.Context, keys ...string) SliceCmd
+	MSet(ctx context.Context, values ...interface{}) StatusCmd
+}
+
 // Minimal result interfaces to avoid importing go-redis directly.
 type StringCmd interface {
 	Result() (string, error)
@@ -35,6 +50,12 @@ type IntCmd interface {
 type StringSliceCmd interface {
 	Result() ([]string, error)
 }
+type DurationCmd interface {
+	Result() (time.Duration, error)
+}
+type SliceCmd interface {
+	Result() ([]interface{}, error)
+}
 
 // RedisMemoryStore implements agentsdk.MemoryStore using Redis.
 // Keys are namespaced as "mem:{namespace}:{key}" for KV
@@ -92,6 +113,91 @@ func (r *RedisMemoryStore) Set(namespace, key, value string) error {
 	return r.client.Set(r.ctx, r.kvKey(namespace, key), value, r.ttl).Err()
 }
 
+// SetWithTTL stores value with its own expiry, overriding the configured
+// default TTL; ttl <= 0 falls back to Set.
+func (r *RedisMemoryStore) SetWithTTL(namespace, key, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return r.Set(namespace, key, value)
+	}
+	return r.client.Set(r.ctx, r.kvKey(namespace, key), value, ttl).Err()
+}
+
+// TTL maps Redis' negative PTTL replies (no expiry, missing key) to 0.
+// It returns an error if the client does not implement RedisTTLClient.
+func (r *RedisMemoryStore) TTL(namespace, key string) (time.Duration, error) {
+	c, ok := r.client.(RedisTTLClient)
+	if !ok {
+		return 0, fmt.Errorf("redis store: client %T does not implement PTTL", r.client)
+	}
+	d, err := c.PTTL(r.ctx, r.kvKey(namespace, key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, nil
+	}
+	return d, nil
+}
+
+// MGet uses a single MGET when the client implements RedisBatchClient and
+// falls back to one GET per key otherwise.
+func (r *RedisMemoryStore) MGet(namespace string, keys []string) (map[string]string, error) {
+	result := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	if bc, ok := r.client.(RedisBatchClient); ok {
+		full := make([]string, len(keys))
+		for i, k := range keys {
+			full[i] = r.kvKey(namespace, k)
+		}
+		vals, err := bc.MGet(r.ctx, full...).Result()
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range vals {
+			if i >= len(keys) || v == nil {
+				continue
+			}
+			result[keys[i]] = fmt.Sprint(v)
+		}
+		return result, nil
+	}
+	for _, k := range keys {
+		val, err := r.client.Get(r.ctx, r.kvKey(namespace, k)).Result()
+		if err != nil {
+			if err.Error() == "redis: nil" {
+				continue
+			}
+			return nil, err
+		}
+		result[k] = val
+	}
+	return result, nil
+}
+
+// MSet uses a single MSET when the client implements RedisBatchClient and no
+// default TTL is configured (MSET cannot set expiries); otherwise it issues
+// one SET per key.
+func (r *RedisMemoryStore) MSet(namespace string, kv map[string]string) error {
+	if len(kv) == 0 {
+		return nil
+	}
+	if bc, ok := r.client.(RedisBatchClient); ok && r.ttl <= 0 {
+		pairs := make([]interface{}, 0, 2*len(kv))
+		for k, v := range kv {
+			pairs = append(pairs, r.kvKey(namespace, k), v)
+		}
+		return bc.MSet(r.ctx, pairs...).Err()
+	}
+	for k, v := range kv {
+		if err := r.Set(namespace, k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *RedisMemoryStore) Delete(namespace, key string) error {
 	_, err := r.client.Del(r.ctx, r.kvKey(namespace, key)).Result()
 	return err
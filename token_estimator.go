@@ -0,0 +1,69 @@
+package agentsdk
+
+import (
+	"unicode"
+)
+
+// ──────────────────────────────────────────────
+// Token Estimator — shared token counting heuristic
+// ──────────────────────────────────────────────
+
+// TokenEstimator estimates how many LLM tokens a piece of text occupies.
+//
+// The SDK ships a heuristic implementation; plug in a real tokenizer
+// (e.g. a tiktoken binding) by implementing this interface and passing it to
+// AgentLoop, ContextCompressor or MemoryRetriever.
+type TokenEstimator interface {
+	EstimateTokens(text string) int
+}
+
+// TokenEstimatorFunc adapts a plain function to TokenEstimator.
+type TokenEstimatorFunc func(text string) int
+
+// EstimateTokens implements TokenEstimator.
+func (f TokenEstimatorFunc) EstimateTokens(text string) int { return f(text) }
+
+// HeuristicTokenEstimator approximates tokens without a tokenizer:
+// CJK characters count as one token each, everything else as four
+// characters per token.
+type HeuristicTokenEstimator struct{}
+
+// NewHeuristicTokenEstimator returns the default CJK-aware estimator.
+func NewHeuristicTokenEstimator() *HeuristicTokenEstimator {
+	return &HeuristicTokenEstimator{}
+}
+
+// EstimateTokens implements TokenEstimator.
+func (HeuristicTokenEstimator) EstimateTokens(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if isCJKRune(r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// EstimateMessagesTokens sums the estimated tokens of each message's "content".
+func EstimateMessagesTokens(est TokenEstimator, messages []map[string]interface{}) int {
+	if est == nil {
+		est = NewHeuristicTokenEstimator()
+	}
+	total := 0
+	for _, msg := range messages {
+		content, _ := msg["content"].(string)
+		total += est.EstimateTokens(content)
+	}
+	return total
+}
+
+func isCJKRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK punctuation
+		(r >= 0xFF00 && r <= 0xFFEF) // full-width forms
+}
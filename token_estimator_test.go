@@ -0,0 +1,74 @@
+package agentsdk
+
+import "testing"
+
+func TestHeuristicTokenEstimator_CJKvsASCII(t *testing.T) {
+	est := NewHeuristicTokenEstimator()
+
+	if n := est.EstimateTokens(""); n != 0 {
+		t.Fatalf("expected 0 for empty text, got %d", n)
+	}
+	if n := est.EstimateTokens("abcdefgh"); n != 2 {
+		t.Fatalf("expected 8 ASCII chars = 2 tokens, got %d", n)
+	}
+	if n := est.EstimateTokens("今天天气很好"); n != 6 {
+		t.Fatalf("expected 6 CJK chars = 6 tokens, got %d", n)
+	}
+	if cjk, ascii := est.EstimateTokens("你好世界"), est.EstimateTokens("abcd"); cjk <= ascii {
+		t.Fatalf("CJK text should weigh more than ASCII of equal length: %d vs %d", cjk, ascii)
+	}
+	if n := est.EstimateTokens("hi 你好"); n != 3 {
+		t.Fatalf("expected mixed text = 3 tokens, got %d", n)
+	}
+}
+
+func TestEstimateMessagesTokens(t *testing.T) {
+	msgs := []map[string]interface{}{
+		{"role": "user", "content": "abcd"},
+		{"role": "assistant", "content": "你好"},
+		{"role": "tool"},
+	}
+	if n := EstimateMessagesTokens(nil, msgs); n != 3 {
+		t.Fatalf("expected 3, got %d", n)
+	}
+	fixed := TokenEstimatorFunc(func(string) int { return 10 })
+	if n := EstimateMessagesTokens(fixed, msgs); n != 30 {
+		t.Fatalf("expected custom estimator to be used, got %d", n)
+	}
+}
+
+func TestContextCompressor_UsesTokenEstimator(t *testing.T) {
+	calls := 0
+	c := NewContextCompressor(func(msgs []map[string]interface{}) (string, error) {
+		calls++
+		return "summary", nil
+	}, CompressorConfig{
+		WindowSize:     1,
+		TokenThreshold: 100,
+		SummaryVersion: "v1",
+		TokenEstimator: TokenEstimatorFunc(func(string) int { return 100 }),
+	})
+	history := []map[string]interface{}{
+		{"role": "user", "content": "a"},
+		{"role": "assistant", "content": "b"},
+	}
+	out, _ := c.Compress(history, NewWorkingMemory())
+	if calls != 1 || len(out) != 2 {
+		t.Fatalf("expected compression via custom estimator, calls=%d len=%d", calls, len(out))
+	}
+}
+
+func TestAgentLoop_EstimatedTokens(t *testing.T) {
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		return &LLMMessage{Content: "done"}, nil
+	}
+	loop := NewAgentLoop(llm, nil, "sys", 3, nil)
+	if r := loop.Run("hi", nil, ""); r.EstimatedTokens != 0 {
+		t.Fatalf("expected 0 without estimator, got %d", r.EstimatedTokens)
+	}
+	loop.TokenEstimator = NewHeuristicTokenEstimator()
+	r := loop.Run("hi", nil, "")
+	if r.EstimatedTokens != 2 {
+		t.Fatalf("expected 2 estimated tokens for sys+hi, got %d", r.EstimatedTokens)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -18,19 +20,37 @@ type ToolContext struct {
 	CallID   string
 	Extra    map[string]interface{}
 	Ctx      context.Context // optional: propagates cancellation/timeout to tool handlers (e.g. MCP)
+	// Session is the caller's memory session, set when the loop runs within
+	// one (NaturalAgentLoop, or AgentLoop.Session). May be nil in a bare AgentLoop.
+	Session *MemorySession
+	// Tracer and Span are the loop's tracer and this call's tool span, set
+	// when tracing is enabled; handlers may add events or child spans.
+	Tracer *AgentTracer
+	Span   *TracingSpan
+	// OnDelta receives each chunk of a streaming tool's output (see
+	// ToolHandlerFunc). Set by AgentLoop when Hooks.OnToolDelta is configured.
+	OnDelta func(delta string)
 }
 
 // ToolParam describes a single parameter of a tool.
 type ToolParam struct {
-	Name        string      `json:"name"`
-	Type        string      `json:"type"` // "string", "integer", "number", "boolean", "array", "object"
-	Description string      `json:"description,omitempty"`
-	Required    bool        `json:"required"`
-	Default     interface{} `json:"default,omitempty"`
-	Enum        []string    `json:"enum,omitempty"`
+	Name        string        `json:"name"`
+	Type        string        `json:"type"` // "string", "integer", "number", "boolean", "array", "object"
+	Description string        `json:"description,omitempty"`
+	Required    bool          `json:"required"`
+	Default     interface{}   `json:"default,omitempty"`
+	Enum        []string      `json:"enum,omitempty"`
+	Examples    []interface{} `json:"examples,omitempty"`
+	Items       *ToolParam    `json:"items,omitempty"`      // element schema for "array" params (Name is ignored)
+	Properties  []ToolParam   `json:"properties,omitempty"` // fields of "object" params
 }
 
 // ToolHandlerFunc is the signature for tool execution handlers.
+//
+// A handler may stream its output by returning a <-chan string (or
+// chan string) and closing it when done. Execute then reads the channel
+// within the tool's timeout, passes every chunk to ToolContext.OnDelta, and
+// returns the concatenated chunks as a string result.
 type ToolHandlerFunc func(ctx *ToolContext, args map[string]interface{}) (interface{}, error)
 
 // Tool defines a callable tool with metadata and handler.
@@ -55,27 +75,7 @@ func (t *Tool) ToJSONSchema() map[string]interface{} {
 		}
 	}
 
-	properties := make(map[string]interface{})
-	var required []string
-
-	for _, p := range t.Parameters {
-		prop := map[string]interface{}{
-			"type": p.Type,
-		}
-		if p.Description != "" {
-			prop["description"] = p.Description
-		}
-		if p.Default != nil {
-			prop["default"] = p.Default
-		}
-		if len(p.Enum) > 0 {
-			prop["enum"] = p.Enum
-		}
-		properties[p.Name] = prop
-		if p.Required {
-			required = append(required, p.Name)
-		}
-	}
+	properties, required := toolParamsSchema(t.Parameters)
 
 	schema := map[string]interface{}{
 		"name":        t.Name,
@@ -91,6 +91,49 @@ func (t *Tool) ToJSONSchema() map[string]interface{} {
 	return schema
 }
 
+// toolParamsSchema builds the JSON Schema "properties" map and "required" list for params.
+func toolParamsSchema(params []ToolParam) (map[string]interface{}, []string) {
+	properties := make(map[string]interface{})
+	var required []string
+	for _, p := range params {
+		properties[p.Name] = toolParamSchema(p)
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	return properties, required
+}
+
+// toolParamSchema converts a single ToolParam (recursively) to JSON Schema.
+func toolParamSchema(p ToolParam) map[string]interface{} {
+	prop := map[string]interface{}{
+		"type": p.Type,
+	}
+	if p.Description != "" {
+		prop["description"] = p.Description
+	}
+	if p.Default != nil {
+		prop["default"] = p.Default
+	}
+	if len(p.Enum) > 0 {
+		prop["enum"] = p.Enum
+	}
+	if len(p.Examples) > 0 {
+		prop["examples"] = p.Examples
+	}
+	if p.Items != nil {
+		prop["items"] = toolParamSchema(*p.Items)
+	}
+	if len(p.Properties) > 0 {
+		nested, required := toolParamsSchema(p.Properties)
+		prop["properties"] = nested
+		if len(required) > 0 {
+			prop["required"] = required
+		}
+	}
+	return prop
+}
+
 // ToOpenAISchema exports in OpenAI function calling format.
 func (t *Tool) ToOpenAISchema() map[string]interface{} {
 	return map[string]interface{}{
@@ -202,6 +245,80 @@ func (r *ToolRegistry) Contains(name string) bool {
 	return ok
 }
 
+// maxToolSuggestions caps the names returned by Suggest.
+const maxToolSuggestions = 3
+
+// Suggest returns up to 3 registered tool names close to name, best first:
+// names within an edit distance of a third of their length (ignoring case),
+// names that share a prefix with it, and namespaced names whose last
+// "."-segment equals it (e.g. "read_file" → "mcp.fs.read_file").
+func (r *ToolRegistry) Suggest(name string) []string {
+	target := strings.ToLower(name)
+	if target == "" {
+		return nil
+	}
+	type candidate struct {
+		name  string
+		score int
+	}
+	var matches []candidate
+	for _, n := range r.Names() {
+		lower := strings.ToLower(n)
+		score := levenshtein(target, lower)
+		longest := len(target)
+		if len(lower) > longest {
+			longest = len(lower)
+		}
+		related := score*3 <= longest
+		if shorter := min(len(target), len(lower)); shorter >= 3 &&
+			(strings.HasPrefix(lower, target) || strings.HasPrefix(target, lower)) {
+			related, score = true, min(score, 1)
+		}
+		if i := strings.LastIndex(lower, "."); i >= 0 && lower[i+1:] == target {
+			related, score = true, 0
+		}
+		if related {
+			matches = append(matches, candidate{name: n, score: score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].name < matches[j].name
+	})
+	if len(matches) > maxToolSuggestions {
+		matches = matches[:maxToolSuggestions]
+	}
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.name
+	}
+	return result
+}
+
+// levenshtein returns the edit distance between a and b, by rune.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
 // ─── Schema export ───
 
 // ToJSONSchema exports all tools as a list of JSON Schema objects.
@@ -228,6 +345,19 @@ func (r *ToolRegistry) ToOpenAISchema() []map[string]interface{} {
 
 // ─── Execution ───
 
+// MissingParamError is returned by Execute when a required argument is
+// absent. It matches ErrToolMissingRequiredArg via errors.Is.
+type MissingParamError struct {
+	Tool string
+	Name string
+}
+
+func (e *MissingParamError) Error() string {
+	return fmt.Sprintf("%v: tool %q missing required argument: %q", ErrToolMissingRequiredArg, e.Tool, e.Name)
+}
+
+func (e *MissingParamError) Unwrap() error { return ErrToolMissingRequiredArg }
+
 // Execute runs a tool by name with the given arguments.
 func (r *ToolRegistry) Execute(name string, args map[string]interface{}, ctx *ToolContext) (interface{}, error) {
 	r.mu.RLock()
@@ -269,7 +399,7 @@ func (r *ToolRegistry) Execute(name string, args map[string]interface{}, ctx *To
 	for _, p := range t.Parameters {
 		if p.Required {
 			if _, exists := args[p.Name]; !exists {
-				return nil, fmt.Errorf("%w: tool %q missing required argument: %q", ErrToolMissingRequiredArg, name, p.Name)
+				return nil, &MissingParamError{Tool: name, Name: p.Name}
 			}
 		}
 	}
@@ -293,11 +423,15 @@ func (r *ToolRegistry) Execute(name string, args map[string]interface{}, ctx *To
 		CallID:   ctx.CallID,
 		Extra:    ctx.Extra,
 		Ctx:      execCtx,
+		Session:  ctx.Session,
+		Tracer:   ctx.Tracer,
+		Span:     ctx.Span,
+		OnDelta:  ctx.OnDelta,
 	}
 
 	// Fast-path: no cancellation channel to listen on.
 	if timeout <= 0 && execCtx.Done() == nil {
-		return t.Handler(callCtx, args)
+		return callToolHandler(t, callCtx, args)
 	}
 
 	type execResult struct {
@@ -306,7 +440,7 @@ func (r *ToolRegistry) Execute(name string, args map[string]interface{}, ctx *To
 	}
 	resultCh := make(chan execResult, 1)
 	go func() {
-		value, err := t.Handler(callCtx, args)
+		value, err := callToolHandler(t, callCtx, args)
 		resultCh <- execResult{value: value, err: err}
 	}()
 
@@ -320,3 +454,51 @@ func (r *ToolRegistry) Execute(name string, args map[string]interface{}, ctx *To
 		return nil, fmt.Errorf("%w: tool %q canceled: %v", ErrToolCancelled, name, execCtx.Err())
 	}
 }
+
+// callToolHandler runs the handler, converting a panic into ErrToolPanic so
+// a faulty tool cannot crash the agent. A streamed result is drained into a
+// string.
+func callToolHandler(t *Tool, ctx *ToolContext, args map[string]interface{}) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logErrorf("[ToolRegistry] tool %s panic: %v", t.Name, r)
+			value, err = nil, fmt.Errorf("%w: tool %q: %v", ErrToolPanic, t.Name, r)
+		}
+	}()
+	value, err = t.Handler(ctx, args)
+	if err != nil {
+		return value, err
+	}
+	switch ch := value.(type) {
+	case <-chan string:
+		return drainToolStream(ctx, ch)
+	case chan string:
+		return drainToolStream(ctx, ch)
+	}
+	return value, nil
+}
+
+// drainToolStream concatenates ch until it is closed, forwarding each chunk
+// to ctx.OnDelta. It stops early, returning ctx.Ctx's error, if the call's
+// context ends first.
+func drainToolStream(ctx *ToolContext, ch <-chan string) (string, error) {
+	var done <-chan struct{}
+	if ctx.Ctx != nil {
+		done = ctx.Ctx.Done()
+	}
+	var b strings.Builder
+	for {
+		select {
+		case delta, ok := <-ch:
+			if !ok {
+				return b.String(), nil
+			}
+			b.WriteString(delta)
+			if ctx.OnDelta != nil {
+				ctx.OnDelta(delta)
+			}
+		case <-done:
+			return b.String(), ctx.Ctx.Err()
+		}
+	}
+}
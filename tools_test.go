@@ -37,6 +37,68 @@ func TestTool_ToJSONSchema(t *testing.T) {
 	}
 }
 
+func TestTool_ToJSONSchema_ArrayItems(t *testing.T) {
+	tool := &Tool{
+		Name: "tag",
+		Parameters: []ToolParam{
+			{
+				Name:     "tags",
+				Type:     "array",
+				Required: true,
+				Items:    &ToolParam{Type: "string", Description: "a tag"},
+				Examples: []interface{}{[]string{"go", "ai"}},
+			},
+		},
+	}
+	props := tool.ToJSONSchema()["parameters"].(map[string]interface{})["properties"].(map[string]interface{})
+	tags := props["tags"].(map[string]interface{})
+	items, ok := tags["items"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected items schema, got %v", tags["items"])
+	}
+	if items["type"] != "string" || items["description"] != "a tag" {
+		t.Fatalf("unexpected items schema: %v", items)
+	}
+	if ex, ok := tags["examples"].([]interface{}); !ok || len(ex) != 1 {
+		t.Fatalf("expected examples, got %v", tags["examples"])
+	}
+}
+
+func TestTool_ToJSONSchema_NestedObject(t *testing.T) {
+	tool := &Tool{
+		Name: "book",
+		Parameters: []ToolParam{
+			{
+				Name: "address",
+				Type: "object",
+				Properties: []ToolParam{
+					{Name: "city", Type: "string", Required: true},
+					{Name: "zip", Type: "string"},
+				},
+			},
+		},
+	}
+	params := tool.ToJSONSchema()["parameters"].(map[string]interface{})
+	if _, ok := params["required"]; ok {
+		t.Fatal("top-level required should be absent")
+	}
+	address := params["properties"].(map[string]interface{})["address"].(map[string]interface{})
+	nested := address["properties"].(map[string]interface{})
+	if _, ok := nested["city"]; !ok {
+		t.Fatal("missing nested property city")
+	}
+	if _, ok := nested["zip"]; !ok {
+		t.Fatal("missing nested property zip")
+	}
+	req := address["required"].([]string)
+	if len(req) != 1 || req[0] != "city" {
+		t.Fatalf("expected nested required=[city], got %v", req)
+	}
+	if _, ok := address["examples"]; ok {
+		t.Fatal("examples should be omitted when empty")
+	}
+}
+
 func TestTool_ToOpenAISchema(t *testing.T) {
 	tool := &Tool{Name: "t", Description: "d"}
 	schema := tool.ToOpenAISchema()
@@ -192,6 +254,102 @@ func TestToolRegistry_ExecuteUnknown(t *testing.T) {
 	}
 }
 
+func TestToolRegistry_Suggest(t *testing.T) {
+	r := NewToolRegistry()
+	noop := func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) { return nil, nil }
+	for _, name := range []string{"get_weather", "get_time", "search", "mcp.fs.read_file"} {
+		r.Register(&Tool{Name: name, Handler: noop})
+	}
+
+	cases := []struct {
+		name string
+		want []string
+	}{
+		{"get_wether", []string{"get_weather"}},
+		{"Search", []string{"search"}},
+		{"read_file", []string{"mcp.fs.read_file"}},
+		{"get", []string{"get_time", "get_weather"}},
+		{"translate_text", nil},
+		{"", nil},
+	}
+	for _, c := range cases {
+		got := r.Suggest(c.name)
+		if strings.Join(got, ",") != strings.Join(c.want, ",") {
+			t.Errorf("Suggest(%q) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestToolRegistry_SuggestCapsAtThree(t *testing.T) {
+	r := NewToolRegistry()
+	noop := func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) { return nil, nil }
+	for _, name := range []string{"tool_a", "tool_b", "tool_c", "tool_d"} {
+		r.Register(&Tool{Name: name, Handler: noop})
+	}
+	if got := r.Suggest("tool_x"); len(got) != 3 {
+		t.Fatalf("expected 3 suggestions, got %v", got)
+	}
+}
+
+func TestToolRegistry_ExecuteMissingParamTyped(t *testing.T) {
+	r := NewToolRegistry()
+	r.Register(&Tool{
+		Name:       "t",
+		Parameters: []ToolParam{{Name: "city", Type: "string", Required: true}},
+		Handler:    func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) { return "ok", nil },
+	})
+
+	_, err := r.Execute("t", nil, nil)
+	var missing *MissingParamError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected MissingParamError, got %v", err)
+	}
+	if missing.Name != "city" || missing.Tool != "t" {
+		t.Fatalf("unexpected error fields: %+v", missing)
+	}
+	if !errors.Is(err, ErrToolMissingRequiredArg) {
+		t.Fatal("MissingParamError should match ErrToolMissingRequiredArg")
+	}
+}
+
+func TestToolRegistry_ExecutePanicRecovered(t *testing.T) {
+	r := NewToolRegistry()
+	r.Register(&Tool{
+		Name: "boom",
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			panic("kaboom")
+		},
+	})
+
+	result, err := r.Execute("boom", nil, nil)
+	if !errors.Is(err, ErrToolPanic) {
+		t.Fatalf("expected ErrToolPanic, got %v", err)
+	}
+	if result != nil {
+		t.Fatalf("expected nil result, got %v", result)
+	}
+	if !strings.Contains(err.Error(), "kaboom") {
+		t.Fatalf("panic value should be in the error, got %v", err)
+	}
+}
+
+func TestToolRegistry_ExecutePanicRecoveredWithTimeout(t *testing.T) {
+	r := NewToolRegistry()
+	r.Register(&Tool{
+		Name:    "boom",
+		Timeout: time.Second,
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			var m map[string]int
+			m["x"] = 1
+			return nil, nil
+		},
+	})
+
+	if _, err := r.Execute("boom", nil, nil); !errors.Is(err, ErrToolPanic) {
+		t.Fatalf("expected ErrToolPanic from timed execution, got %v", err)
+	}
+}
+
 func TestToolRegistry_ExecuteWithContext(t *testing.T) {
 	r := NewToolRegistry()
 	r.Register(&Tool{
@@ -496,6 +654,28 @@ func TestTool_RawJSONSchema_Nil_Fallback(t *testing.T) {
 	}
 }
 
+func TestToolRegistry_ExecuteStreamingTimeout(t *testing.T) {
+	r := NewToolRegistry()
+	r.Register(&Tool{
+		Name:    "stuck_stream",
+		Timeout: 30 * time.Millisecond,
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			ch := make(chan string, 1)
+			ch <- "partial"
+			return ch, nil // never closed
+		},
+	})
+
+	deltas := make(chan string, 1)
+	_, err := r.Execute("stuck_stream", nil, &ToolContext{OnDelta: func(d string) { deltas <- d }})
+	if !errors.Is(err, ErrToolTimeout) {
+		t.Fatalf("expected ErrToolTimeout for an unclosed stream, got %v", err)
+	}
+	if d := <-deltas; d != "partial" {
+		t.Fatalf("expected the first chunk to be forwarded, got %q", d)
+	}
+}
+
 func TestToolRegistry_ExecuteTimeout_Default(t *testing.T) {
 	r := NewToolRegistry()
 	r.SetDefaultTimeout(30 * time.Millisecond)
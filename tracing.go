@@ -1,9 +1,11 @@
 package agentsdk
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	mrand "math/rand"
 	"sync"
 	"time"
 )
@@ -33,10 +35,19 @@ type TracingSpan struct {
 	StartTime  time.Time              `json:"start_time"`
 	EndTime    time.Time              `json:"end_time,omitempty"`
 	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Events     []SpanEvent            `json:"events,omitempty"`
 	Children   []*TracingSpan         `json:"children,omitempty"`
 	Status     string                 `json:"status"` // "running", "ok", "error"
 	Error      string                 `json:"error,omitempty"`
 	mu         sync.Mutex
+	detached   bool // not recorded: trace unsampled or depth limit hit
+}
+
+// SpanEvent is a timed annotation inside a span (e.g. "retry", "cache_hit").
+type SpanEvent struct {
+	Name       string                 `json:"name"`
+	Time       time.Time              `json:"time"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 // DurationMs returns the span duration in milliseconds.
@@ -67,6 +78,13 @@ func (s *TracingSpan) SetAttribute(key string, value interface{}) {
 	s.Attributes[key] = value
 }
 
+// AddEvent records a timed event on the span.
+func (s *TracingSpan) AddEvent(name string, attrs map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Events = append(s.Events, SpanEvent{Name: name, Time: time.Now(), Attributes: attrs})
+}
+
 // AddChild adds a child span.
 func (s *TracingSpan) AddChild(child *TracingSpan) {
 	s.mu.Lock()
@@ -88,8 +106,8 @@ func (e *NullSpanExporter) Export(span *TracingSpan) {}
 type ConsoleSpanExporter struct{}
 
 func (e *ConsoleSpanExporter) Export(span *TracingSpan) {
-	logInfof("[Trace] %s %s | %s | %.1fms",
-		span.Kind, span.Name, span.Status, span.DurationMs())
+	logInfof("[Trace] %s %s | %s | %.1fms | attrs=%v events=%d",
+		span.Kind, span.Name, span.Status, span.DurationMs(), span.Attributes, len(span.Events))
 }
 
 // CallbackSpanExporter calls a function for each span.
@@ -103,9 +121,19 @@ func (e *CallbackSpanExporter) Export(span *TracingSpan) {
 
 // AgentTracer creates and manages spans.
 type AgentTracer struct {
+	// SampleRate is the probability (0..1) that a trace is recorded and
+	// exported. The decision is made once per trace, so a trace is kept or
+	// dropped as a whole. NewAgentTracer defaults it to 1.
+	SampleRate float64
+	// MaxSpanDepth limits span nesting; 0 = unlimited. Spans deeper than
+	// this are not recorded, and their would-be parent gets an
+	// "overflow_spans" attribute counting them.
+	MaxSpanDepth int
+
 	exporter SpanExporterInterface
 	enabled  bool
 	traceID  string
+	sampled  bool
 	stack    []*TracingSpan
 	mu       sync.Mutex
 }
@@ -115,16 +143,21 @@ func NewAgentTracer(exporter SpanExporterInterface, enabled bool) *AgentTracer {
 	if exporter == nil {
 		exporter = &NullSpanExporter{}
 	}
-	return &AgentTracer{exporter: exporter, enabled: enabled}
+	return &AgentTracer{SampleRate: 1, exporter: exporter, enabled: enabled}
 }
 
-// NewTrace starts a new trace.
+// NewTrace starts a new trace and makes its sampling decision.
 func (t *AgentTracer) NewTrace() string {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	t.startTraceLocked()
+	return t.traceID
+}
+
+func (t *AgentTracer) startTraceLocked() {
 	t.traceID = randomHex(16)
+	t.sampled = t.SampleRate >= 1 || (t.SampleRate > 0 && mrand.Float64() < t.SampleRate)
 	t.stack = nil
-	return t.traceID
 }
 
 // StartSpan creates and starts a new span.
@@ -137,7 +170,21 @@ func (t *AgentTracer) StartSpan(name string, kind SpanKindType, attrs map[string
 	defer t.mu.Unlock()
 
 	if t.traceID == "" {
-		t.traceID = randomHex(16)
+		t.startTraceLocked()
+	}
+	if !t.sampled {
+		return &TracingSpan{Name: name, Kind: kind, Status: "running", detached: true}
+	}
+	if t.MaxSpanDepth > 0 && len(t.stack) >= t.MaxSpanDepth {
+		parent := t.stack[len(t.stack)-1]
+		parent.mu.Lock()
+		if parent.Attributes == nil {
+			parent.Attributes = make(map[string]interface{})
+		}
+		n, _ := parent.Attributes["overflow_spans"].(int)
+		parent.Attributes["overflow_spans"] = n + 1
+		parent.mu.Unlock()
+		return &TracingSpan{Name: name, Kind: kind, Status: "running", detached: true}
 	}
 
 	parentID := ""
@@ -170,6 +217,9 @@ func (t *AgentTracer) EndSpan(span *TracingSpan, status string, errMsg string) {
 	}
 
 	span.End(status, errMsg)
+	if span.detached {
+		return
+	}
 
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -213,6 +263,19 @@ func (t *AgentTracer) GuardrailSpan(name string) *TracingSpan {
 	return t.StartSpan(fmt.Sprintf("guardrail:%s", name), SpanKindGuardrail, nil)
 }
 
+type toolSpanKey struct{}
+
+// withToolSpan carries a tool span through a context.Context, for tool
+// implementations (e.g. MCP) that only see the context.
+func withToolSpan(ctx context.Context, span *TracingSpan) context.Context {
+	return context.WithValue(ctx, toolSpanKey{}, span)
+}
+
+func toolSpanFromContext(ctx context.Context) *TracingSpan {
+	span, _ := ctx.Value(toolSpanKey{}).(*TracingSpan)
+	return span
+}
+
 func randomHex(n int) string {
 	b := make([]byte, n)
 	if _, err := rand.Read(b); err != nil {
@@ -0,0 +1,226 @@
+package agentsdk
+
+import (
+	"testing"
+)
+
+func newCollectingTracer() (*AgentTracer, *[]*TracingSpan) {
+	var exported []*TracingSpan
+	tracer := NewAgentTracer(&CallbackSpanExporter{Fn: func(span *TracingSpan) {
+		exported = append(exported, span)
+	}}, true)
+	return tracer, &exported
+}
+
+func TestAgentTracer_SampleRateZeroExportsNothing(t *testing.T) {
+	tracer, exported := newCollectingTracer()
+	tracer.SampleRate = 0
+
+	for i := 0; i < 5; i++ {
+		tracer.NewTrace()
+		root := tracer.AgentSpan("agent_loop")
+		child := tracer.ToolSpan("search", nil)
+		tracer.EndSpan(child, "ok", "")
+		tracer.EndSpan(root, "ok", "")
+	}
+	if len(*exported) != 0 {
+		t.Fatalf("expected no exported spans, got %d", len(*exported))
+	}
+}
+
+func TestAgentTracer_DefaultSamplesEverything(t *testing.T) {
+	tracer, exported := newCollectingTracer()
+
+	tracer.NewTrace()
+	root := tracer.AgentSpan("agent_loop")
+	child := tracer.LLMSpan("gpt", nil)
+	tracer.EndSpan(child, "ok", "")
+	tracer.EndSpan(root, "ok", "")
+
+	if len(*exported) != 1 {
+		t.Fatalf("expected 1 exported root span, got %d", len(*exported))
+	}
+	if len((*exported)[0].Children) != 1 {
+		t.Fatalf("expected child span to be recorded, got %d", len((*exported)[0].Children))
+	}
+}
+
+func TestAgentTracer_MaxSpanDepth(t *testing.T) {
+	tracer, exported := newCollectingTracer()
+	tracer.MaxSpanDepth = 2
+
+	tracer.NewTrace()
+	root := tracer.AgentSpan("agent_loop")
+	llm := tracer.LLMSpan("gpt", nil)
+	deep1 := tracer.ToolSpan("nested_a", nil)
+	tracer.EndSpan(deep1, "ok", "")
+	deep2 := tracer.ToolSpan("nested_b", nil)
+	tracer.EndSpan(deep2, "ok", "")
+	tracer.EndSpan(llm, "ok", "")
+	tool := tracer.ToolSpan("search", nil)
+	tracer.EndSpan(tool, "ok", "")
+	tracer.EndSpan(root, "ok", "")
+
+	if len(*exported) != 1 {
+		t.Fatalf("expected 1 exported root span, got %d", len(*exported))
+	}
+	got := (*exported)[0]
+	if len(got.Children) != 2 {
+		t.Fatalf("expected 2 children at depth 2, got %d", len(got.Children))
+	}
+	llmSpan := got.Children[0]
+	if len(llmSpan.Children) != 0 {
+		t.Fatalf("spans beyond MaxSpanDepth should be dropped, got %d", len(llmSpan.Children))
+	}
+	if llmSpan.Attributes["overflow_spans"] != 2 {
+		t.Fatalf("expected overflow marker of 2, got %v", llmSpan.Attributes["overflow_spans"])
+	}
+	if deep1.Status != "ok" {
+		t.Fatal("dropped spans should still be usable by callers")
+	}
+}
+
+func TestTracingSpan_AttributesAndEventsExported(t *testing.T) {
+	tracer, exported := newCollectingTracer()
+
+	tracer.NewTrace()
+	root := tracer.AgentSpan("agent_loop")
+	root.SetAttribute("tokens", 42)
+	root.AddEvent("retry", map[string]interface{}{"attempt": 1})
+	root.AddEvent("cache_hit", nil)
+	tracer.EndSpan(root, "ok", "")
+
+	if len(*exported) != 1 {
+		t.Fatalf("expected 1 exported span, got %d", len(*exported))
+	}
+	got := (*exported)[0]
+	if got.Attributes["tokens"] != 42 {
+		t.Fatalf("attribute lost on export: %v", got.Attributes)
+	}
+	if len(got.Events) != 2 || got.Events[0].Name != "retry" || got.Events[1].Name != "cache_hit" {
+		t.Fatalf("events lost on export: %+v", got.Events)
+	}
+	if got.Events[0].Attributes["attempt"] != 1 || got.Events[0].Time.IsZero() {
+		t.Fatalf("event attributes/time missing: %+v", got.Events[0])
+	}
+}
+
+func TestAgentLoop_TracingAttributes(t *testing.T) {
+	tracer, exported := newCollectingTracer()
+
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		callCount++
+		if callCount == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{
+				{"get_weather", `{"city":"Paris"}`},
+			}, ""), nil
+		}
+		return makeFinalResp("done"), nil
+	}
+	loop := NewAgentLoop(llm, testRegistry(), "", 5, nil)
+	loop.Tracer = tracer
+	loop.Model = "gpt-test"
+	loop.Run("weather?", nil, "")
+
+	if len(*exported) != 1 {
+		t.Fatalf("expected 1 exported trace, got %d", len(*exported))
+	}
+	root := (*exported)[0]
+	if root.Attributes["model"] != "gpt-test" {
+		t.Fatalf("agent span should carry model, got %v", root.Attributes)
+	}
+
+	var llmSpan, toolSpan *TracingSpan
+	for _, c := range root.Children {
+		switch c.Kind {
+		case SpanKindLLM:
+			if llmSpan == nil {
+				llmSpan = c
+			}
+		case SpanKindTool:
+			toolSpan = c
+		}
+	}
+	if llmSpan == nil || llmSpan.Attributes["model"] != "gpt-test" || llmSpan.Attributes["turn"] != 1 {
+		t.Fatalf("llm span should carry model and turn, got %+v", llmSpan)
+	}
+	if toolSpan == nil || toolSpan.Attributes["tool"] != "get_weather" || toolSpan.Attributes["turn"] != 1 {
+		t.Fatalf("tool span should carry tool and turn, got %+v", toolSpan)
+	}
+	if toolSpan.Attributes["city"] != "Paris" {
+		t.Fatalf("tool span should keep arguments, got %v", toolSpan.Attributes)
+	}
+}
+
+func TestAgentLoop_ToolHandlerEmitsIntoToolSpan(t *testing.T) {
+	tracer, exported := newCollectingTracer()
+
+	reg := NewToolRegistry()
+	reg.Register(&Tool{
+		Name: "fetch",
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			if ctx.Span == nil || ctx.Tracer == nil {
+				t.Error("expected Tracer and Span on ToolContext")
+				return "no span", nil
+			}
+			ctx.Span.AddEvent("http_done", map[string]interface{}{"status": 200})
+			sub := ctx.Tracer.StartSpan("parse", SpanKindCustom, nil)
+			ctx.Tracer.EndSpan(sub, "ok", "")
+			return "ok", nil
+		},
+	})
+
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		callCount++
+		if callCount == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{{"fetch", `{}`}}, ""), nil
+		}
+		return makeFinalResp("done"), nil
+	}
+	loop := NewAgentLoop(llm, reg, "", 5, nil)
+	loop.Tracer = tracer
+	loop.Run("go", nil, "")
+
+	if len(*exported) != 1 {
+		t.Fatalf("expected 1 exported trace, got %d", len(*exported))
+	}
+	var toolSpan *TracingSpan
+	for _, c := range (*exported)[0].Children {
+		if c.Kind == SpanKindTool {
+			toolSpan = c
+		}
+	}
+	if toolSpan == nil {
+		t.Fatal("tool span missing")
+	}
+	if len(toolSpan.Events) != 1 || toolSpan.Events[0].Name != "http_done" {
+		t.Fatalf("expected handler event on tool span, got %+v", toolSpan.Events)
+	}
+	if len(toolSpan.Children) != 1 || toolSpan.Children[0].Name != "parse" {
+		t.Fatalf("expected handler child span under tool span, got %+v", toolSpan.Children)
+	}
+}
+
+func TestAgentLoop_ToolContextWithoutTracer(t *testing.T) {
+	reg := NewToolRegistry()
+	reg.Register(&Tool{
+		Name: "fetch",
+		Handler: func(ctx *ToolContext, args map[string]interface{}) (interface{}, error) {
+			if ctx.Span != nil || ctx.Tracer != nil {
+				t.Error("expected nil Tracer/Span when tracing is off")
+			}
+			return "ok", nil
+		},
+	})
+	callCount := 0
+	llm := func(msgs []map[string]interface{}, tools []map[string]interface{}) (*LLMMessage, error) {
+		callCount++
+		if callCount == 1 {
+			return makeToolCallResp([]struct{ Name, Args string }{{"fetch", `{}`}}, ""), nil
+		}
+		return makeFinalResp("done"), nil
+	}
+	NewAgentLoop(llm, reg, "", 5, nil).Run("go", nil, "")
+}